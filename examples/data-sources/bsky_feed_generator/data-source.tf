@@ -0,0 +1,12 @@
+provider "bsky" {
+  pds_host = "https://bsky.social"
+  handle   = "scoott.blog"
+}
+
+data "bsky_feed_generator" "discover" {
+  uri = "at://did:plc:z72i7hdynmk6r22z27h6tvur/app.bsky.feed.generator/whats-hot"
+}
+
+output "discover_online" {
+  value = data.bsky_feed_generator.discover.is_online && data.bsky_feed_generator.discover.is_valid
+}
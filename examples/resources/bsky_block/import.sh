@@ -0,0 +1,2 @@
+# Block can be imported using the URI
+terraform import bsky_block.spammer "at://did:plc:7kkf4hujjl6wll6pewqahaex/app.bsky.graph.block/3lbo5zov45j2q"
\ No newline at end of file
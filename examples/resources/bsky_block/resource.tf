@@ -0,0 +1,8 @@
+provider "bsky" {
+  pds_host = "https://bsky.social"
+  handle   = "scoott.blog"
+}
+
+resource "bsky_block" "spammer" {
+  subject_did = "did:plc:4llrhdclvdlmmynkwsmg5tdc"
+}
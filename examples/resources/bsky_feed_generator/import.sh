@@ -0,0 +1,2 @@
+# Feed generator can be imported using the URI of the feed
+terraform import bsky_feed_generator.cats "at://did:plc:7kkf4hujjl6wll6pewqahaex/app.bsky.feed.generator/cats"
\ No newline at end of file
@@ -0,0 +1,13 @@
+provider "bsky" {
+  pds_host = "https://bsky.social"
+  handle   = "scoott.blog"
+}
+
+resource "bsky_feed_generator" "cats" {
+  rkey                 = "cats"
+  service_did          = "did:web:feeds.scoott.blog"
+  display_name         = "Cat Pictures"
+  description          = "Every post with a cat picture"
+  avatar_file          = "${path.module}/cats.png"
+  accepts_interactions = true
+}
@@ -0,0 +1,2 @@
+# Follow can be imported using the URI
+terraform import bsky_follow.partner "at://did:plc:7kkf4hujjl6wll6pewqahaex/app.bsky.graph.follow/3lbo5zov45j2q"
\ No newline at end of file
@@ -0,0 +1,17 @@
+provider "bsky" {
+  pds_host = "https://bsky.social"
+  handle   = "scoott.blog"
+}
+
+variable "partners" {
+  type = set(string)
+  default = [
+    "did:plc:z72i7hdynmk6r22z27h6tvur",
+    "did:plc:ewvi7nxzyoun6zhxrhs64oiz",
+  ]
+}
+
+resource "bsky_follow" "partners" {
+  for_each    = var.partners
+  subject_did = each.value
+}
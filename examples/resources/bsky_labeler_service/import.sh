@@ -0,0 +1,2 @@
+# Labeler service can be imported using the DID of the labeler account
+terraform import bsky_labeler_service.spoilers "did:plc:7kkf4hujjl6wll6pewqahaex"
\ No newline at end of file
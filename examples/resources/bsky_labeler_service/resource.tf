@@ -0,0 +1,32 @@
+provider "bsky" {
+  pds_host = "https://bsky.social"
+  handle   = "labels.scoott.blog"
+}
+
+resource "bsky_labeler_service" "spoilers" {
+  label_values = ["!hide", "spoiler"]
+
+  label_value_definitions = [
+    {
+      identifier      = "spoiler"
+      severity        = "inform"
+      blurs           = "content"
+      default_setting = "warn"
+      locales = [
+        {
+          lang        = "en"
+          name        = "Spoiler"
+          description = "Reveals the plot of a recent film or series."
+        },
+        {
+          lang        = "fr"
+          name        = "Divulgâcheur"
+          description = "Révèle l'intrigue d'un film ou d'une série récente."
+        },
+      ]
+    },
+  ]
+
+  reason_types  = ["com.atproto.moderation.defs#reasonOther"]
+  subject_types = ["account", "record"]
+}
@@ -0,0 +1,2 @@
+# List block can be imported using the URI
+terraform import bsky_list_block.spammers "at://did:plc:ewvi7nxzyoun6zhxrhs64oiz/app.bsky.graph.listblock/3lbo5zov45j2q"
\ No newline at end of file
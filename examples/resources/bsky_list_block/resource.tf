@@ -0,0 +1,8 @@
+provider "bsky" {
+  pds_host = "https://bsky.social"
+  handle   = "scoott.blog"
+}
+
+resource "bsky_list_block" "spammers" {
+  list_uri = "at://did:plc:7kkf4hujjl6wll6pewqahaex/app.bsky.graph.list/3lbo5zov45j2q"
+}
@@ -13,3 +13,8 @@ resource "bsky_list_item" "scoott" {
   list_uri    = bsky_list.test-list.uri
   subject_did = "did:plc:7kkf4hujjl6wll6pewqahaex"
 }
+
+resource "bsky_list_item" "by_handle" {
+  list_uri       = bsky_list.test-list.uri
+  subject_handle = "bsky.app"
+}
@@ -0,0 +1,2 @@
+# List members can be imported using the URI of the list. Every list item of the list is adopted.
+terraform import bsky_list_members.spammers "at://did:plc:ewvi7nxzyoun6zhxrhs64oiz/app.bsky.graph.list/3lbo5zov45j2q"
\ No newline at end of file
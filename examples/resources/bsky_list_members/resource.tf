@@ -0,0 +1,16 @@
+provider "bsky" {
+  pds_host = "https://bsky.social"
+  handle   = "scoott.blog"
+}
+
+resource "bsky_list" "spammers" {
+  name        = "Spammers"
+  purpose     = "app.bsky.graph.defs#modlist"
+  description = "Known spam accounts"
+}
+
+resource "bsky_list_members" "spammers" {
+  list_uri     = bsky_list.spammers.uri
+  subject_dids = split("\n", trimspace(file("${path.module}/spammers.txt")))
+  batch_size   = 100
+}
@@ -0,0 +1,31 @@
+provider "bsky" {
+  pds_host = "https://bsky.social"
+  handle   = "scoott.blog"
+}
+
+resource "bsky_list" "spammers_derived" {
+  name        = "Spammers (without partners)"
+  purpose     = "app.bsky.graph.defs#modlist"
+  description = "Known spam accounts, except the ones of partners"
+}
+
+# Copy the members of the canonical list, except partner accounts.
+resource "bsky_list_mirror" "spammers_derived" {
+  list_uri        = bsky_list.spammers_derived.uri
+  source_list_uri = "at://did:plc:7kkf4hujjl6wll6pewqahaex/app.bsky.graph.list/3lbo5zov45j2q"
+  exclude         = ["\\.partner\\.example$"]
+  max_removals    = 50
+}
+
+resource "bsky_list" "partner_blocks" {
+  name        = "Partner block list"
+  purpose     = "app.bsky.graph.defs#modlist"
+  description = "Accounts blocked by our partners"
+}
+
+# Mirror a CSV of DIDs and handles received from a partner.
+resource "bsky_list_mirror" "partner_blocks" {
+  list_uri     = bsky_list.partner_blocks.uri
+  source_file  = "${path.module}/partner-blocks.csv"
+  max_removals = 100
+}
@@ -0,0 +1,2 @@
+# List mute can be imported using the URI of the list
+terraform import bsky_list_mute.spammers "at://did:plc:7kkf4hujjl6wll6pewqahaex/app.bsky.graph.list/3lbo5zov45j2q"
\ No newline at end of file
@@ -0,0 +1,2 @@
+# Post can be imported using the URI
+terraform import bsky_post.release "at://did:plc:7kkf4hujjl6wll6pewqahaex/app.bsky.feed.post/3lbo5zov45j2q"
\ No newline at end of file
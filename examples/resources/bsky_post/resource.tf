@@ -0,0 +1,16 @@
+provider "bsky" {
+  pds_host = "https://bsky.social"
+  handle   = "scoott.blog"
+}
+
+resource "bsky_post" "release" {
+  text  = "terraform-provider-bsky 1.5.0 is out, thanks @scoott.blog! https://github.com/sodle/terraform-provider-bsky #terraform"
+  langs = ["en"]
+
+  images = [
+    {
+      file = "${path.module}/release-banner.png"
+      alt  = "The provider logo next to the version number 1.5.0"
+    },
+  ]
+}
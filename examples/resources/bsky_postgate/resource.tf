@@ -0,0 +1,13 @@
+provider "bsky" {
+  pds_host = "https://bsky.social"
+  handle   = "scoott.blog"
+}
+
+resource "bsky_post" "announcement" {
+  text = "terraform-provider-bsky 1.5.0 is out!"
+}
+
+resource "bsky_postgate" "announcement" {
+  post_uri          = bsky_post.announcement.uri
+  disable_embedding = true
+}
@@ -0,0 +1,2 @@
+# Profile can be imported using the DID of its account
+terraform import bsky_profile.scoott "did:plc:7kkf4hujjl6wll6pewqahaex"
\ No newline at end of file
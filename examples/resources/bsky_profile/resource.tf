@@ -0,0 +1,11 @@
+provider "bsky" {
+  pds_host = "https://bsky.social"
+  handle   = "scoott.blog"
+}
+
+resource "bsky_profile" "scoott" {
+  display_name = "Scott"
+  description  = "Managed with Terraform."
+  labels       = ["!no-unauthenticated"]
+  avatar_file  = "${path.module}/avatar.png"
+}
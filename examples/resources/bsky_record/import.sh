@@ -0,0 +1,2 @@
+# Record can be imported using its URI
+terraform import bsky_record.bot_config "at://did:plc:7kkf4hujjl6wll6pewqahaex/com.example.bot.config/self"
\ No newline at end of file
@@ -0,0 +1,14 @@
+provider "bsky" {
+  pds_host = "https://bsky.social"
+  handle   = "scoott.blog"
+}
+
+resource "bsky_record" "bot_config" {
+  collection = "com.example.bot.config"
+  rkey       = "self"
+  record = jsonencode({
+    enabled   = true
+    schedule  = "0 9 * * *"
+    languages = ["en", "fr"]
+  })
+}
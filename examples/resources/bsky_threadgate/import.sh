@@ -0,0 +1,2 @@
+# Threadgate can be imported using the URI of its post
+terraform import bsky_threadgate.announcement "at://did:plc:7kkf4hujjl6wll6pewqahaex/app.bsky.feed.post/3lbo5zov45j2q"
\ No newline at end of file
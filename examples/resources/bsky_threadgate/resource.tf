@@ -0,0 +1,23 @@
+provider "bsky" {
+  pds_host = "https://bsky.social"
+  handle   = "scoott.blog"
+}
+
+resource "bsky_post" "announcement" {
+  text = "terraform-provider-bsky 1.5.0 is out! Questions go to the maintainers."
+}
+
+resource "bsky_list" "maintainers" {
+  name        = "Maintainers"
+  purpose     = "app.bsky.graph.defs#curatelist"
+  description = "Maintainers of terraform-provider-bsky"
+}
+
+resource "bsky_threadgate" "announcement" {
+  post_uri = bsky_post.announcement.uri
+
+  allow = {
+    mentioned = true
+    lists     = [bsky_list.maintainers.uri]
+  }
+}
@@ -172,6 +172,11 @@ func (l *accountResource) Read(ctx context.Context, req resource.ReadRequest, re
 	}
 
 	account, err := atproto.AdminGetAccountInfo(ctx, l.client, state.Did.ValueString())
+	if classifyXRPCError(err) == xrpcErrorAccountNotFound {
+		// The account was deleted outside of Terraform.
+		resp.State.RemoveResource(ctx)
+		return
+	}
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Failed to retrieve account",
@@ -296,16 +301,17 @@ func (l *accountResource) Configure(_ context.Context, req resource.ConfigureReq
 		return
 	}
 
-	client, ok := req.ProviderData.(*xrpc.Client)
+	data, ok := req.ProviderData.(*providerData)
 
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Data Source Configure Type",
-			fmt.Sprintf("Expected *xrpc.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+			fmt.Sprintf("Expected *providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
 		)
 
 		return
 	}
+	client := data.client
 
 	if client.AdminToken == nil {
 		resp.Diagnostics.AddError(
@@ -0,0 +1,200 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/lex/util"
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// blobConstraints are the accepted MIME types and the maximum size in bytes
+// of a blob field, as defined by its lexicon.
+type blobConstraints struct {
+	accept  []string
+	maxSize int64
+}
+
+// imageConstraints are the constraints of the avatar and banner images of
+// profiles and lists.
+var imageConstraints = blobConstraints{
+	accept:  []string{"image/png", "image/jpeg"},
+	maxSize: 1_000_000,
+}
+
+// postImageConstraints are the constraints of the images embedded in posts.
+var postImageConstraints = blobConstraints{
+	accept:  []string{"image/png", "image/jpeg", "image/gif", "image/webp"},
+	maxSize: 1_000_000,
+}
+
+// blobFile is a local file to upload as a blob.
+type blobFile struct {
+	data     []byte
+	mimeType string
+	// hash is the hex encoded SHA-256 hash of data, which is tracked in the
+	// state so that changes to the file trigger a new upload.
+	hash string
+}
+
+// readBlobFile reads the file at path and checks it against the constraints of
+// the field it is uploaded to.
+func readBlobFile(path string, constraints blobConstraints) (*blobFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > constraints.maxSize {
+		return nil, fmt.Errorf("%s is %d bytes, larger than the limit of %d bytes", path, len(data), constraints.maxSize)
+	}
+	mimeType, _, _ := strings.Cut(http.DetectContentType(data), ";")
+	if !slices.Contains(constraints.accept, mimeType) {
+		return nil, fmt.Errorf("%s is %s, expected one of %s", path, mimeType, strings.Join(constraints.accept, ", "))
+	}
+
+	hash := sha256.Sum256(data)
+	return &blobFile{
+		data:     data,
+		mimeType: mimeType,
+		hash:     hex.EncodeToString(hash[:]),
+	}, nil
+}
+
+// upload uploads the file to the repo of the client. The blob has to be
+// referenced by a record soon after, or the PDS deletes it again.
+func (f *blobFile) upload(ctx context.Context, client *xrpc.Client) (*util.LexBlob, error) {
+	var out atproto.RepoUploadBlob_Output
+	if err := client.Do(ctx, xrpc.Procedure, f.mimeType, "com.atproto.repo.uploadBlob", nil, bytes.NewReader(f.data), &out); err != nil {
+		return nil, fmt.Errorf("could not upload blob: %w", err)
+	}
+	if out.Blob == nil {
+		return nil, fmt.Errorf("uploadBlob returned no blob")
+	}
+	return out.Blob, nil
+}
+
+// blobField is a blob field of a record managed by a pair of attributes: the
+// path of the file to upload, and the hash of the uploaded file.
+type blobField struct {
+	file types.String
+	hash types.String
+}
+
+// update returns the blob to store in the field given the current blob of the
+// record, uploading the file when it changed since the prior state. A field
+// whose file was never configured is left as it is, while removing the file
+// from the configuration clears the field.
+func (f blobField) update(ctx context.Context, client *xrpc.Client, current *util.LexBlob, prior blobField, constraints blobConstraints) (*util.LexBlob, types.String, error) {
+	if f.file.IsNull() {
+		if prior.file.IsNull() {
+			return current, types.StringNull(), nil
+		}
+		return nil, types.StringNull(), nil
+	}
+
+	file, err := readBlobFile(f.file.ValueString(), constraints)
+	if err != nil {
+		return nil, types.StringNull(), err
+	}
+	if current != nil && prior.hash.ValueString() == file.hash {
+		return current, types.StringValue(file.hash), nil
+	}
+	blob, err := file.upload(ctx, client)
+	if err != nil {
+		return nil, types.StringNull(), err
+	}
+	return blob, types.StringValue(file.hash), nil
+}
+
+// blobFileAttribute returns the attribute of the path of a file uploaded to a
+// blob field.
+func blobFileAttribute(description string, constraints blobConstraints) schema.StringAttribute {
+	return schema.StringAttribute{
+		MarkdownDescription: fmt.Sprintf("%s Must be %s of at most %d bytes. When never set, an image set outside of Terraform is left as it is, while removing a file set before removes the image.",
+			description, strings.Join(constraints.accept, " or "), constraints.maxSize),
+		Optional: true,
+	}
+}
+
+// blobHashAttribute returns the attribute tracking the hash of the file of
+// fileAttribute, which is planned from the file so that changing its contents
+// uploads it again. The modifiers run after the hash is planned.
+func blobHashAttribute(fileAttribute string, constraints blobConstraints, modifiers ...planmodifier.String) schema.StringAttribute {
+	return schema.StringAttribute{
+		MarkdownDescription: "SHA-256 hash of the uploaded `" + fileAttribute + "`",
+		Computed:            true,
+		PlanModifiers: append([]planmodifier.String{
+			blobHashPlanModifier{fileAttribute: fileAttribute, constraints: constraints},
+		}, modifiers...),
+	}
+}
+
+// blobHashPlanModifier plans the hash of a blob file attribute from the
+// contents of the file, and checks the file against the constraints of its
+// field.
+type blobHashPlanModifier struct {
+	fileAttribute string
+	constraints   blobConstraints
+}
+
+func (m blobHashPlanModifier) Description(_ context.Context) string {
+	return "The hash is planned from the contents of " + m.fileAttribute + "."
+}
+
+func (m blobHashPlanModifier) MarkdownDescription(ctx context.Context) string {
+	return m.Description(ctx)
+}
+
+func (m blobHashPlanModifier) PlanModifyString(ctx context.Context, req planmodifier.StringRequest, resp *planmodifier.StringResponse) {
+	// Nothing to plan when the resource is destroyed.
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	filePath := req.Path.ParentPath().AtName(m.fileAttribute)
+	var file types.String
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, filePath, &file)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	switch {
+	case file.IsUnknown():
+		resp.PlanValue = types.StringUnknown()
+	case file.IsNull():
+		resp.PlanValue = types.StringNull()
+	default:
+		blob, err := readBlobFile(file.ValueString(), m.constraints)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(
+				filePath,
+				"Invalid image file",
+				"Could not use "+file.ValueString()+" as image: "+err.Error(),
+			)
+			return
+		}
+		resp.PlanValue = types.StringValue(blob.hash)
+	}
+}
+
+// blobURL returns the URL which serves a blob of did from pdsHost, the PDS of
+// the repository the blob belongs to.
+func blobURL(pdsHost string, did string, blob *util.LexBlob) string {
+	query := url.Values{}
+	query.Set("did", did)
+	query.Set("cid", blob.Ref.String())
+	return strings.TrimSuffix(pdsHost, "/") + "/xrpc/com.atproto.sync.getBlob?" + query.Encode()
+}
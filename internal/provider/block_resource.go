@@ -0,0 +1,33 @@
+package provider
+
+import (
+	"github.com/bluesky-social/indigo/api/bsky"
+	"github.com/bluesky-social/indigo/lex/util"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+)
+
+const blockCollection = "app.bsky.graph.block"
+
+// NewBlockResource is a helper function to simplify the provider implementation.
+func NewBlockResource() resource.Resource {
+	return &subjectRecordResource{record: subjectRecord{
+		typeName:           "_block",
+		description:        "Block a Bluesky account",
+		collection:         blockCollection,
+		noun:               "block",
+		subjectAttribute:   "subject_did",
+		subjectDescription: "The DID of the account to block",
+		newModel:           func() subjectRecordModel { return &subjectDidRecordModel{} },
+		validate:           validateSubjectDid("block"),
+		newRecord: func(subject string, createdAt string) *util.LexiconTypeDecoder {
+			return &util.LexiconTypeDecoder{Val: &bsky.GraphBlock{Subject: subject, CreatedAt: createdAt}}
+		},
+		subjectOf: func(record *util.LexiconTypeDecoder) (string, bool) {
+			block, ok := record.Val.(*bsky.GraphBlock)
+			if !ok {
+				return "", false
+			}
+			return block.Subject, true
+		},
+	}}
+}
@@ -0,0 +1,117 @@
+package provider
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/bluesky-social/indigo/api/bsky"
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// The patterns of rich text features follow those of the Bluesky apps. A
+// feature starts the text or follows whitespace, mentions and links may also
+// follow an opening parenthesis.
+var (
+	mentionPattern = regexp.MustCompile(`(?:^|\s|\()(@[a-zA-Z0-9.-]+)`)
+	linkPattern    = regexp.MustCompile(`(?:^|\s|\()(https?://\S+)`)
+	tagPattern     = regexp.MustCompile(`(?:^|\s)([#＃]\S+)`)
+)
+
+// maxTagLength is the maximum number of characters of a hashtag, without the
+// leading #.
+const maxTagLength = 64
+
+// detectFacets returns the facets of the mentions, links and hashtags in text,
+// indexed by their byte offsets. Mentions are resolved to DIDs with resolver,
+// mentions of handles which don't resolve are left as plain text.
+func detectFacets(ctx context.Context, resolver *identityResolver, text string) []*bsky.RichtextFacet {
+	var facets []*bsky.RichtextFacet
+
+	for _, match := range mentionPattern.FindAllStringSubmatchIndex(text, -1) {
+		start, end := match[2], match[3]
+		// Handles can't end with a dot, which ends the sentence instead.
+		end -= len(text[start:end]) - len(strings.TrimRight(text[start:end], "."))
+		handle, err := syntax.ParseHandle(text[start+1 : end])
+		if err != nil {
+			continue
+		}
+		did, err := resolver.resolveHandle(ctx, handle.Normalize().String())
+		if err != nil {
+			tflog.Warn(ctx, "Could not resolve mentioned handle, leaving it as plain text", map[string]any{
+				"handle": handle.String(),
+				"error":  err.Error(),
+			})
+			continue
+		}
+		facets = append(facets, newFacet(start, end, &bsky.RichtextFacet_Features_Elem{
+			RichtextFacet_Mention: &bsky.RichtextFacet_Mention{Did: did},
+		}))
+	}
+
+	for _, match := range linkPattern.FindAllStringSubmatchIndex(text, -1) {
+		start, end := match[2], trimLinkEnd(text, match[2], match[3])
+		facets = append(facets, newFacet(start, end, &bsky.RichtextFacet_Features_Elem{
+			RichtextFacet_Link: &bsky.RichtextFacet_Link{Uri: text[start:end]},
+		}))
+	}
+
+	for _, match := range tagPattern.FindAllStringSubmatchIndex(text, -1) {
+		start, end := match[2], match[3]
+		_, hashSize := utf8.DecodeRuneInString(text[start:])
+		tag := strings.TrimRightFunc(text[start+hashSize:end], unicode.IsPunct)
+		if tag == "" || utf8.RuneCountInString(tag) > maxTagLength || isDigits(tag) {
+			continue
+		}
+		end = start + hashSize + len(tag)
+		facets = append(facets, newFacet(start, end, &bsky.RichtextFacet_Features_Elem{
+			RichtextFacet_Tag: &bsky.RichtextFacet_Tag{Tag: tag},
+		}))
+	}
+
+	sort.SliceStable(facets, func(i, j int) bool {
+		return facets[i].Index.ByteStart < facets[j].Index.ByteStart
+	})
+	return facets
+}
+
+func newFacet(start int, end int, feature *bsky.RichtextFacet_Features_Elem) *bsky.RichtextFacet {
+	return &bsky.RichtextFacet{
+		Index: &bsky.RichtextFacet_ByteSlice{
+			ByteStart: int64(start),
+			ByteEnd:   int64(end),
+		},
+		Features: []*bsky.RichtextFacet_Features_Elem{feature},
+	}
+}
+
+// trimLinkEnd returns the end of the link in text[start:end] without the
+// punctuation ending the sentence around it, or the closing parenthesis of the
+// text around it.
+func trimLinkEnd(text string, start int, end int) int {
+	for end > start {
+		last := text[end-1]
+		switch {
+		case strings.IndexByte(".,;:!?\"'", last) >= 0:
+			end--
+		case last == ')' && !strings.Contains(text[start:end-1], "("):
+			end--
+		default:
+			return end
+		}
+	}
+	return end
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
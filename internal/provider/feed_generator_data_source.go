@@ -0,0 +1,254 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bluesky-social/indigo/api/bsky"
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ datasource.DataSource              = &feedGeneratorDataSource{}
+	_ datasource.DataSourceWithConfigure = &feedGeneratorDataSource{}
+)
+
+// NewFeedGeneratorDataSource is a helper function to simplify the provider implementation.
+func NewFeedGeneratorDataSource() datasource.DataSource {
+	return &feedGeneratorDataSource{}
+}
+
+// feedGeneratorDataSource is the data source implementation. The feed is read
+// from the AppView, which tells whether the feed generator service is online
+// and valid, and the feeds served by the service are read from the service
+// itself.
+type feedGeneratorDataSource struct {
+	data *providerData
+}
+
+// feedGeneratorDataSourceModel maps the data source schema data.
+type feedGeneratorDataSourceModel struct {
+	Account             types.String `tfsdk:"account"`
+	Uri                 types.String `tfsdk:"uri"`
+	Cid                 types.String `tfsdk:"cid"`
+	ServiceDid          types.String `tfsdk:"service_did"`
+	CreatorDid          types.String `tfsdk:"creator_did"`
+	DisplayName         types.String `tfsdk:"display_name"`
+	Description         types.String `tfsdk:"description"`
+	Avatar              types.String `tfsdk:"avatar"`
+	AcceptsInteractions types.Bool   `tfsdk:"accepts_interactions"`
+	ContentMode         types.String `tfsdk:"content_mode"`
+	LikeCount           types.Int64  `tfsdk:"like_count"`
+	IsOnline            types.Bool   `tfsdk:"is_online"`
+	IsValid             types.Bool   `tfsdk:"is_valid"`
+	ServiceEndpoint     types.String `tfsdk:"service_endpoint"`
+	ServiceFeeds        types.List   `tfsdk:"service_feeds"`
+}
+
+// Metadata returns the data source type name.
+func (d *feedGeneratorDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_feed_generator"
+}
+
+// Schema defines the schema for the data source.
+func (d *feedGeneratorDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "A datasource to retrieve custom feeds and the status of the feed generator service serving them",
+		Attributes: map[string]schema.Attribute{
+			"account": schema.StringAttribute{
+				MarkdownDescription: "Name of the account in the `accounts` provider attribute to read the feed with. Defaults to the account the provider logs in to with `handle` and `password` or `oauth`.",
+				Optional:            true,
+			},
+			"uri": schema.StringAttribute{
+				MarkdownDescription: "Atproto URI of the feed",
+				Required:            true,
+			},
+			"cid": schema.StringAttribute{
+				MarkdownDescription: "Commit ID generated by Bluesky",
+				Computed:            true,
+			},
+			"service_did": schema.StringAttribute{
+				MarkdownDescription: "DID of the feed generator service",
+				Computed:            true,
+			},
+			"creator_did": schema.StringAttribute{
+				MarkdownDescription: "DID of the account which declared the feed",
+				Computed:            true,
+			},
+			"display_name": schema.StringAttribute{
+				MarkdownDescription: "Name of the feed",
+				Computed:            true,
+			},
+			"description": schema.StringAttribute{
+				MarkdownDescription: "Description of the feed",
+				Computed:            true,
+			},
+			"avatar": schema.StringAttribute{
+				MarkdownDescription: "The CDN URL for the feed's avatar image",
+				Computed:            true,
+			},
+			"accepts_interactions": schema.BoolAttribute{
+				MarkdownDescription: "Whether the feed generator service accepts feedback about the posts of the feed",
+				Computed:            true,
+			},
+			"content_mode": schema.StringAttribute{
+				MarkdownDescription: "Kind of content of the feed",
+				Computed:            true,
+			},
+			"like_count": schema.Int64Attribute{
+				MarkdownDescription: "Number of likes of the feed",
+				Computed:            true,
+			},
+			"is_online": schema.BoolAttribute{
+				MarkdownDescription: "Whether the AppView could reach the feed generator service",
+				Computed:            true,
+			},
+			"is_valid": schema.BoolAttribute{
+				MarkdownDescription: "Whether the feed generator service declares the feed",
+				Computed:            true,
+			},
+			"service_endpoint": schema.StringAttribute{
+				MarkdownDescription: "Endpoint of the `#bsky_fg` service in the DID document of the feed generator service. Null when the DID document can't be resolved.",
+				Computed:            true,
+			},
+			"service_feeds": schema.ListAttribute{
+				MarkdownDescription: "URIs of the feeds the service reports with `app.bsky.feed.describeFeedGenerator`. Null when the service can't be reached.",
+				ElementType:         types.StringType,
+				Computed:            true,
+			},
+		},
+	}
+}
+
+// Read refreshes the Terraform state with the latest data.
+func (d *feedGeneratorDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data feedGeneratorDataSourceModel
+
+	// Read Terraform configuration data into the model.
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, diags := d.data.publicClient(ctx, data.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	uri := data.Uri.ValueString()
+
+	feed, err := bsky.FeedGetFeedGenerator(ctx, client, uri)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Unable to Read Feed Generator",
+			"Could not read feed URI "+uri+": "+err.Error(),
+		)
+		return
+	}
+	if feed.View == nil {
+		resp.Diagnostics.AddError(
+			"Unable to Read Feed Generator",
+			"The AppView returned no view of feed "+uri,
+		)
+		return
+	}
+
+	view := feed.View
+	data.Cid = types.StringValue(view.Cid)
+	data.ServiceDid = types.StringValue(view.Did)
+	data.CreatorDid = types.StringNull()
+	if view.Creator != nil {
+		data.CreatorDid = types.StringValue(view.Creator.Did)
+	}
+	data.DisplayName = types.StringValue(view.DisplayName)
+	data.Description = types.StringPointerValue(view.Description)
+	data.Avatar = types.StringPointerValue(view.Avatar)
+	data.AcceptsInteractions = types.BoolValue(view.AcceptsInteractions != nil && *view.AcceptsInteractions)
+	data.ContentMode = types.StringPointerValue(view.ContentMode)
+	data.LikeCount = types.Int64Value(0)
+	if view.LikeCount != nil {
+		data.LikeCount = types.Int64Value(*view.LikeCount)
+	}
+	data.IsOnline = types.BoolValue(feed.IsOnline)
+	data.IsValid = types.BoolValue(feed.IsValid)
+
+	// The service itself is only described on a best effort basis, since an
+	// offline service is precisely what this data source helps to notice.
+	data.ServiceEndpoint = types.StringNull()
+	data.ServiceFeeds = types.ListNull(types.StringType)
+	endpoint, err := d.serviceEndpoint(ctx, view.Did)
+	if err != nil {
+		resp.Diagnostics.AddWarning(
+			"Unable to Resolve Feed Generator Service",
+			"Could not find the endpoint of the feed generator service "+view.Did+": "+err.Error(),
+		)
+	} else {
+		data.ServiceEndpoint = types.StringValue(endpoint)
+
+		service := &xrpc.Client{
+			Client: d.data.resolver.httpClient,
+			Host:   endpoint,
+		}
+		description, err := bsky.FeedDescribeFeedGenerator(ctx, service)
+		if err != nil {
+			resp.Diagnostics.AddWarning(
+				"Unable to Describe Feed Generator Service",
+				"Could not describe the feed generator service at "+endpoint+": "+err.Error(),
+			)
+		} else {
+			feeds := []string{}
+			for _, serviceFeed := range description.Feeds {
+				feeds = append(feeds, serviceFeed.Uri)
+			}
+			data.ServiceFeeds, diags = types.ListValueFrom(ctx, types.StringType, feeds)
+			resp.Diagnostics.Append(diags...)
+		}
+	}
+
+	// Set state
+	diags = resp.State.Set(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// serviceEndpoint returns the #bsky_fg service endpoint of the DID document of
+// the feed generator service.
+func (d *feedGeneratorDataSource) serviceEndpoint(ctx context.Context, did string) (string, error) {
+	doc, err := d.data.resolver.resolveDID(ctx, did)
+	if err != nil {
+		return "", err
+	}
+	endpoint := doc.serviceEndpoint("#bsky_fg", "BskyFeedGenerator")
+	if endpoint == "" {
+		return "", fmt.Errorf("DID document of %s has no #bsky_fg service", did)
+	}
+	return endpoint, nil
+}
+
+// Configure adds the provider configured client to the data source.
+func (d *feedGeneratorDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Add a nil check when handling ProviderData because Terraform
+	// sets that data after it calls the ConfigureProvider RPC.
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	d.data = data
+}
@@ -0,0 +1,443 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/api/bsky"
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/bluesky-social/indigo/lex/util"
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const feedGeneratorCollection = "app.bsky.feed.generator"
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ resource.Resource                = &feedGeneratorResource{}
+	_ resource.ResourceWithConfigure   = &feedGeneratorResource{}
+	_ resource.ResourceWithImportState = &feedGeneratorResource{}
+)
+
+// NewFeedGeneratorResource is a helper function to simplify the provider implementation.
+func NewFeedGeneratorResource() resource.Resource {
+	return &feedGeneratorResource{}
+}
+
+// feedGeneratorResource is the resource implementation.
+type feedGeneratorResource struct {
+	data *providerData
+}
+
+type feedGeneratorResourceModel struct {
+	Account             types.String `tfsdk:"account"`
+	Uri                 types.String `tfsdk:"uri"`
+	Cid                 types.String `tfsdk:"cid"`
+	Rkey                types.String `tfsdk:"rkey"`
+	ServiceDid          types.String `tfsdk:"service_did"`
+	DisplayName         types.String `tfsdk:"display_name"`
+	Description         types.String `tfsdk:"description"`
+	AvatarFile          types.String `tfsdk:"avatar_file"`
+	AvatarHash          types.String `tfsdk:"avatar_hash"`
+	AcceptsInteractions types.Bool   `tfsdk:"accepts_interactions"`
+	ContentMode         types.String `tfsdk:"content_mode"`
+}
+
+// Metadata returns the resource type name.
+func (f *feedGeneratorResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_feed_generator"
+}
+
+// Schema defines the schema for the resource.
+func (f *feedGeneratorResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Manage the declaration of a Bluesky custom feed, which tells the AppView which feed generator service serves it",
+		Attributes: map[string]schema.Attribute{
+			"account": accountResourceAttribute(),
+			"uri": schema.StringAttribute{
+				MarkdownDescription: "Atproto URI of the feed",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"cid": schema.StringAttribute{
+				MarkdownDescription: "Commit ID generated by Bluesky",
+				Computed:            true,
+			},
+			"rkey": schema.StringAttribute{
+				MarkdownDescription: "Record key of the feed, the last segment of its URI, which the feed generator service uses to tell its feeds apart",
+				Required:            true,
+				Validators: []validator.String{
+					recordKeyValidator{},
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"service_did": schema.StringAttribute{
+				MarkdownDescription: "DID of the feed generator service, such as `did:web:feeds.example.com`",
+				Required:            true,
+				Validators: []validator.String{
+					didValidator{},
+				},
+			},
+			"display_name": schema.StringAttribute{
+				MarkdownDescription: "Name of the feed",
+				Required:            true,
+				Validators: []validator.String{
+					stringvalidator.LengthBetween(1, 240),
+				},
+			},
+			"description": schema.StringAttribute{
+				MarkdownDescription: "Description of the feed",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtMost(3000),
+				},
+			},
+			"avatar_file": blobFileAttribute("Path to the image file uploaded as avatar of the feed.", imageConstraints),
+			"avatar_hash": blobHashAttribute("avatar_file", imageConstraints),
+			"accepts_interactions": schema.BoolAttribute{
+				MarkdownDescription: "Whether the feed generator service accepts feedback about the posts of the feed through `app.bsky.feed.sendInteractions`",
+				Optional:            true,
+			},
+			"content_mode": schema.StringAttribute{
+				MarkdownDescription: "Kind of content of the feed, either `app.bsky.feed.defs#contentModeUnspecified` or `app.bsky.feed.defs#contentModeVideo` for video feeds",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(
+						"app.bsky.feed.defs#contentModeUnspecified",
+						"app.bsky.feed.defs#contentModeVideo",
+					),
+				},
+			},
+		},
+	}
+}
+
+func (f *feedGeneratorResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	// Retrieve values from a plan.
+	var plan feedGeneratorResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
+		return
+	}
+
+	client, diags := f.data.accountClient(ctx, plan.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Generate API request body from plan.
+	generator := &bsky.FeedGenerator{
+		Did:                 plan.ServiceDid.ValueString(),
+		DisplayName:         plan.DisplayName.ValueString(),
+		Description:         plan.Description.ValueStringPointer(),
+		AcceptsInteractions: plan.AcceptsInteractions.ValueBoolPointer(),
+		ContentMode:         plan.ContentMode.ValueStringPointer(),
+		CreatedAt:           time.Now().Format(time.RFC3339),
+	}
+	var err error
+	avatar := blobField{file: plan.AvatarFile, hash: plan.AvatarHash}
+	generator.Avatar, plan.AvatarHash, err = avatar.update(ctx, client, nil, blobField{}, imageConstraints)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("avatar_file"),
+			"Failed to upload feed avatar",
+			"Could not upload the avatar "+plan.AvatarFile.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+	createRecordInput := &atproto.RepoCreateRecord_Input{
+		Repo:       client.Auth.Did,
+		Collection: feedGeneratorCollection,
+		Rkey:       plan.Rkey.ValueStringPointer(),
+		Record:     &util.LexiconTypeDecoder{Val: generator},
+	}
+
+	// Create new feed generator.
+	record, err := atproto.RepoCreateRecord(ctx, client, createRecordInput)
+	if isSwapConflict(err) {
+		resp.Diagnostics.AddError("Repo changed outside of Terraform", swapConflictDetail("The repo "+client.Auth.Did, err))
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error creating feed generator",
+			"Could not create feed generator, unexpected error: "+err.Error(),
+		)
+		return
+	}
+
+	// Map response body to schema and populate Computed attribute values.
+	plan.Uri = types.StringValue(record.Uri)
+	plan.Cid = types.StringValue(record.Cid)
+
+	// Set state to fully populated data.
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Read refreshes the Terraform state with the latest data.
+func (f *feedGeneratorResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	// Get current state.
+	var state feedGeneratorResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, diags := f.data.accountClient(ctx, state.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	generator, record, parsedUri, err := GetFeedGeneratorFromURI(ctx, client, state.Uri.ValueString())
+	if isRecordNotFound(err) {
+		// The feed was deleted outside of Terraform.
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error reading feed generator",
+			"Could not read Bluesky feed generator URI "+state.Uri.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+
+	// Overwrite with refreshed state using the repository record.
+	state.Cid = types.StringValue(*record.Cid)
+	state.Rkey = types.StringValue(parsedUri.RecordKey().String())
+	state.ServiceDid = types.StringValue(generator.Did)
+	state.DisplayName = types.StringValue(generator.DisplayName)
+	state.Description = types.StringPointerValue(generator.Description)
+	state.AcceptsInteractions = types.BoolPointerValue(generator.AcceptsInteractions)
+	state.ContentMode = types.StringPointerValue(generator.ContentMode)
+	// An avatar removed outside of Terraform is uploaded again.
+	if generator.Avatar == nil {
+		state.AvatarHash = types.StringNull()
+	}
+
+	// Set refreshed state.
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Update updates the resource and sets the updated Terraform state on success.
+func (f *feedGeneratorResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	// Retrieve values from a plan.
+	var plan feedGeneratorResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
+		return
+	}
+
+	var state feedGeneratorResourceModel
+	diags = req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, diags := f.data.accountClient(ctx, plan.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Get the current feed generator, record and parsed URI.
+	generator, _, parsedUri, err := GetFeedGeneratorFromURI(ctx, client, plan.Uri.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to retrieve feed generator",
+			"Could not retrieve the current state of the feed generator "+plan.Uri.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+
+	generator.Did = plan.ServiceDid.ValueString()
+	generator.DisplayName = plan.DisplayName.ValueString()
+	generator.Description = plan.Description.ValueStringPointer()
+	generator.AcceptsInteractions = plan.AcceptsInteractions.ValueBoolPointer()
+	generator.ContentMode = plan.ContentMode.ValueStringPointer()
+
+	avatar := blobField{file: plan.AvatarFile, hash: plan.AvatarHash}
+	generator.Avatar, plan.AvatarHash, err = avatar.update(ctx, client, generator.Avatar, blobField{file: state.AvatarFile, hash: state.AvatarHash}, imageConstraints)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("avatar_file"),
+			"Failed to upload feed avatar",
+			"Could not upload the avatar "+plan.AvatarFile.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+
+	// Update existing feed generator using the parsed URI
+	putRecordInput := &atproto.RepoPutRecord_Input{
+		Collection: parsedUri.Collection().String(),
+		Repo:       parsedUri.Authority().String(),
+		Rkey:       parsedUri.RecordKey().String(),
+		SwapRecord: swapRecord(state.Cid),
+		Record: &util.LexiconTypeDecoder{
+			Val: generator,
+		},
+	}
+	updatedRecord, err := atproto.RepoPutRecord(ctx, client, putRecordInput)
+	if isSwapConflict(err) {
+		resp.Diagnostics.AddError("Feed generator changed outside of Terraform", swapConflictDetail("The feed generator "+plan.Uri.ValueString(), err))
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to update feed generator",
+			"Could not update feed generator "+plan.Uri.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+
+	// Update resource state.
+	plan.Cid = types.StringValue(updatedRecord.Cid)
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Delete deletes the resource and removes the Terraform state on success.
+func (f *feedGeneratorResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	// Retrieve values from state.
+	var state feedGeneratorResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, diags := f.data.accountClient(ctx, state.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	uri, err := syntax.ParseATURI(state.Uri.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Invalid feed generator URI",
+			"Could not parse Bluesky feed generator URI "+state.Uri.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+	deleteRequest := &atproto.RepoDeleteRecord_Input{
+		Collection: uri.Collection().String(),
+		Repo:       uri.Authority().String(),
+		Rkey:       uri.RecordKey().String(),
+		SwapRecord: swapRecord(state.Cid),
+	}
+	_, err = atproto.RepoDeleteRecord(ctx, client, deleteRequest)
+	if isSwapConflict(err) {
+		resp.Diagnostics.AddError("Feed generator changed outside of Terraform", swapConflictDetail("The feed generator "+state.Uri.ValueString(), err))
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error deleting feed generator",
+			"Could not delete feed generator, error: "+err.Error(),
+		)
+	}
+}
+
+// Configure adds the provider configured client to the resource.
+func (f *feedGeneratorResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Add a nil check when handling ProviderData because Terraform
+	// sets that data after it calls the ConfigureProvider RPC.
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	resp.Diagnostics.Append(data.requireAccount("feed generators")...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	f.data = data
+}
+
+func (f *feedGeneratorResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	// Retrieve import ID and save to uri attribute.
+	resource.ImportStatePassthroughID(ctx, path.Root("uri"), req, resp)
+}
+
+func GetFeedGeneratorFromURI(ctx context.Context, client *xrpc.Client, uri string) (*bsky.FeedGenerator, *atproto.RepoGetRecord_Output, syntax.ATURI, error) {
+	record, parsedUri, err := getRecordAndURIFromString(ctx, client, uri)
+	if err != nil {
+		return nil, nil, parsedUri, fmt.Errorf("could not get record from URI %s: %w", uri, err)
+	}
+
+	// Extract the feed generator from the record
+	generator, ok := record.Value.Val.(*bsky.FeedGenerator)
+	if !ok {
+		return nil, record, parsedUri, fmt.Errorf("could not cast record to FeedGenerator")
+	}
+
+	return generator, record, parsedUri, nil
+}
+
+// recordKeyValidator checks that a string is a record key.
+type recordKeyValidator struct{}
+
+func (v recordKeyValidator) Description(_ context.Context) string {
+	return "value must be a record key of at most 512 letters, digits and the characters . - _ : ~"
+}
+
+func (v recordKeyValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v recordKeyValidator) ValidateString(_ context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	if _, err := syntax.ParseRecordKey(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid record key",
+			"Could not use "+req.ConfigValue.ValueString()+" as record key: "+err.Error(),
+		)
+	}
+}
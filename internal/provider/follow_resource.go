@@ -0,0 +1,33 @@
+package provider
+
+import (
+	"github.com/bluesky-social/indigo/api/bsky"
+	"github.com/bluesky-social/indigo/lex/util"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+)
+
+const followCollection = "app.bsky.graph.follow"
+
+// NewFollowResource is a helper function to simplify the provider implementation.
+func NewFollowResource() resource.Resource {
+	return &subjectRecordResource{record: subjectRecord{
+		typeName:           "_follow",
+		description:        "Follow a Bluesky account",
+		collection:         followCollection,
+		noun:               "follow",
+		subjectAttribute:   "subject_did",
+		subjectDescription: "The DID of the account to follow",
+		newModel:           func() subjectRecordModel { return &subjectDidRecordModel{} },
+		validate:           validateSubjectDid("follow"),
+		newRecord: func(subject string, createdAt string) *util.LexiconTypeDecoder {
+			return &util.LexiconTypeDecoder{Val: &bsky.GraphFollow{Subject: subject, CreatedAt: createdAt}}
+		},
+		subjectOf: func(record *util.LexiconTypeDecoder) (string, bool) {
+			follow, ok := record.Val.(*bsky.GraphFollow)
+			if !ok {
+				return "", false
+			}
+			return follow.Subject, true
+		},
+	}}
+}
@@ -0,0 +1,202 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/xrpc"
+)
+
+const defaultPLCURL = "https://plc.directory"
+
+// identityResolver resolves handles and DIDs to the PDS hosting the account.
+type identityResolver struct {
+	// plcURL is the base URL of the PLC directory used for did:plc documents.
+	plcURL string
+	// handleResolver is the host of an XRPC service resolving handles with
+	// com.atproto.identity.resolveHandle. When empty, handles are resolved
+	// with DNS TXT records and the /.well-known/atproto-did endpoint.
+	handleResolver string
+
+	httpClient *http.Client
+}
+
+// didDocument is the subset of a DID document needed to find the PDS of an
+// account.
+type didDocument struct {
+	ID          string       `json:"id"`
+	AlsoKnownAs []string     `json:"alsoKnownAs"`
+	Service     []didService `json:"service"`
+}
+
+type didService struct {
+	ID              string `json:"id"`
+	Type            string `json:"type"`
+	ServiceEndpoint string `json:"serviceEndpoint"`
+}
+
+// pdsEndpoint returns the #atproto_pds service endpoint of the document, or an
+// empty string if there is none.
+func (d *didDocument) pdsEndpoint() string {
+	return d.serviceEndpoint("#atproto_pds", "AtprotoPersonalDataServer")
+}
+
+// serviceEndpoint returns the endpoint of the service of the document with
+// the given fragment ID and type, or an empty string if there is none.
+func (d *didDocument) serviceEndpoint(id string, serviceType string) string {
+	for _, service := range d.Service {
+		if (service.ID == id || service.ID == d.ID+id) && service.Type == serviceType {
+			return service.ServiceEndpoint
+		}
+	}
+	return ""
+}
+
+// didDocumentFromSession decodes the didDoc returned by createSession and
+// refreshSession.
+func didDocumentFromSession(didDoc *interface{}) (*didDocument, error) {
+	if didDoc == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(*didDoc)
+	if err != nil {
+		return nil, err
+	}
+	var doc didDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("could not decode DID document: %w", err)
+	}
+	return &doc, nil
+}
+
+// resolvePDS returns the PDS endpoint of the account with the given handle or
+// DID.
+func (r *identityResolver) resolvePDS(ctx context.Context, identifier string) (string, error) {
+	did := identifier
+	if !strings.HasPrefix(identifier, "did:") {
+		resolved, err := r.resolveHandle(ctx, identifier)
+		if err != nil {
+			return "", err
+		}
+		did = resolved
+	}
+
+	doc, err := r.resolveDID(ctx, did)
+	if err != nil {
+		return "", err
+	}
+	endpoint := doc.pdsEndpoint()
+	if endpoint == "" {
+		return "", fmt.Errorf("DID document of %s has no #atproto_pds service", did)
+	}
+	return endpoint, nil
+}
+
+// resolveHandle returns the DID the handle points to.
+func (r *identityResolver) resolveHandle(ctx context.Context, handle string) (string, error) {
+	handle = strings.ToLower(strings.TrimPrefix(handle, "@"))
+
+	if r.handleResolver != "" {
+		client := &xrpc.Client{
+			Host:   r.handleResolver,
+			Client: r.httpClient,
+		}
+		out, err := atproto.IdentityResolveHandle(ctx, client, handle)
+		if err != nil {
+			return "", fmt.Errorf("could not resolve handle %s with %s: %w", handle, r.handleResolver, err)
+		}
+		return out.Did, nil
+	}
+
+	if did, err := r.resolveHandleDNS(ctx, handle); err == nil {
+		return did, nil
+	}
+
+	did, err := r.resolveHandleWellKnown(ctx, handle)
+	if err != nil {
+		return "", fmt.Errorf("could not resolve handle %s with DNS or HTTPS: %w", handle, err)
+	}
+	return did, nil
+}
+
+func (r *identityResolver) resolveHandleDNS(ctx context.Context, handle string) (string, error) {
+	records, err := net.DefaultResolver.LookupTXT(ctx, "_atproto."+handle)
+	if err != nil {
+		return "", err
+	}
+	for _, record := range records {
+		if did, found := strings.CutPrefix(record, "did="); found {
+			return strings.TrimSpace(did), nil
+		}
+	}
+	return "", fmt.Errorf("no did= TXT record found for _atproto.%s", handle)
+}
+
+func (r *identityResolver) resolveHandleWellKnown(ctx context.Context, handle string) (string, error) {
+	body, err := r.get(ctx, "https://"+handle+"/.well-known/atproto-did")
+	if err != nil {
+		return "", err
+	}
+	did := strings.TrimSpace(string(body))
+	if !strings.HasPrefix(did, "did:") {
+		return "", fmt.Errorf("https://%s/.well-known/atproto-did did not return a DID", handle)
+	}
+	return did, nil
+}
+
+// resolveDID fetches the DID document of a did:plc or did:web DID.
+func (r *identityResolver) resolveDID(ctx context.Context, did string) (*didDocument, error) {
+	var docURL string
+	switch {
+	case strings.HasPrefix(did, "did:plc:"):
+		docURL = strings.TrimSuffix(r.plcURL, "/") + "/" + did
+	case strings.HasPrefix(did, "did:web:"):
+		host, err := url.PathUnescape(strings.TrimPrefix(did, "did:web:"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid did:web %s: %w", did, err)
+		}
+		docURL = "https://" + host + "/.well-known/did.json"
+	default:
+		return nil, fmt.Errorf("unsupported DID method: %s", did)
+	}
+
+	body, err := r.get(ctx, docURL)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch DID document of %s: %w", did, err)
+	}
+	var doc didDocument
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("could not decode DID document of %s: %w", did, err)
+	}
+	if doc.ID != did {
+		return nil, fmt.Errorf("DID document of %s is for %s", did, doc.ID)
+	}
+	return &doc, nil
+}
+
+func (r *identityResolver) get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s returned HTTP %d", url, resp.StatusCode)
+	}
+	return body, nil
+}
@@ -0,0 +1,774 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/api/bsky"
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/bluesky-social/indigo/lex/util"
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/setvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	labelerServiceCollection = "app.bsky.labeler.service"
+	labelerServiceRkey       = "self"
+)
+
+var (
+	// labelIdentifierPattern matches the identifiers of custom label values.
+	labelIdentifierPattern = regexp.MustCompile(`^[a-z-]+$`)
+	// labelValuePattern matches custom label values and the global ones,
+	// some of which start with an exclamation mark like !hide.
+	labelValuePattern = regexp.MustCompile(`^!?[a-z-]+$`)
+	// reasonTypePattern matches report reason types, which are lexicon
+	// references such as com.atproto.moderation.defs#reasonSpam.
+	reasonTypePattern = regexp.MustCompile(`^[a-zA-Z]([a-zA-Z0-9-]*\.)+[a-zA-Z][a-zA-Z0-9]*#[a-zA-Z][a-zA-Z0-9]*$`)
+	// languagePattern matches BCP 47 language tags such as en or pt-BR.
+	languagePattern = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{1,8})*$`)
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ resource.Resource                   = &labelerServiceResource{}
+	_ resource.ResourceWithConfigure      = &labelerServiceResource{}
+	_ resource.ResourceWithImportState    = &labelerServiceResource{}
+	_ resource.ResourceWithValidateConfig = &labelerServiceResource{}
+)
+
+// NewLabelerServiceResource is a helper function to simplify the provider implementation.
+func NewLabelerServiceResource() resource.Resource {
+	return &labelerServiceResource{}
+}
+
+// labelerServiceResource is the resource implementation. It manages the
+// singleton service record which declares an account as a labeler.
+type labelerServiceResource struct {
+	data *providerData
+}
+
+type labelerServiceResourceModel struct {
+	Account               types.String `tfsdk:"account"`
+	Did                   types.String `tfsdk:"did"`
+	Uri                   types.String `tfsdk:"uri"`
+	Cid                   types.String `tfsdk:"cid"`
+	LabelValues           types.Set    `tfsdk:"label_values"`
+	LabelValueDefinitions types.List   `tfsdk:"label_value_definitions"`
+	ReasonTypes           types.Set    `tfsdk:"reason_types"`
+	SubjectTypes          types.Set    `tfsdk:"subject_types"`
+	SubjectCollections    types.Set    `tfsdk:"subject_collections"`
+}
+
+type labelValueDefinitionModel struct {
+	Identifier     types.String `tfsdk:"identifier"`
+	Severity       types.String `tfsdk:"severity"`
+	Blurs          types.String `tfsdk:"blurs"`
+	DefaultSetting types.String `tfsdk:"default_setting"`
+	AdultOnly      types.Bool   `tfsdk:"adult_only"`
+	Locales        types.List   `tfsdk:"locales"`
+}
+
+type labelValueLocaleModel struct {
+	Lang        types.String `tfsdk:"lang"`
+	Name        types.String `tfsdk:"name"`
+	Description types.String `tfsdk:"description"`
+}
+
+var labelValueLocaleType = types.ObjectType{AttrTypes: map[string]attr.Type{
+	"lang":        types.StringType,
+	"name":        types.StringType,
+	"description": types.StringType,
+}}
+
+var labelValueDefinitionType = types.ObjectType{AttrTypes: map[string]attr.Type{
+	"identifier":      types.StringType,
+	"severity":        types.StringType,
+	"blurs":           types.StringType,
+	"default_setting": types.StringType,
+	"adult_only":      types.BoolType,
+	"locales":         types.ListType{ElemType: labelValueLocaleType},
+}}
+
+// Metadata returns the resource type name.
+func (l *labelerServiceResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_labeler_service"
+}
+
+// Schema defines the schema for the resource.
+func (l *labelerServiceResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Declare a Bluesky account as a labeler, with the label values it publishes and the reports it accepts. " +
+			"The labeling itself is done by the labeler's own service.",
+		Attributes: map[string]schema.Attribute{
+			"account": accountResourceAttribute(),
+			"did": schema.StringAttribute{
+				MarkdownDescription: "DID of the labeler account",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"uri": schema.StringAttribute{
+				MarkdownDescription: "Atproto URI",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"cid": schema.StringAttribute{
+				MarkdownDescription: "Commit ID generated by Bluesky",
+				Computed:            true,
+			},
+			"label_values": schema.SetAttribute{
+				MarkdownDescription: "Label values published by the labeler, either global values such as `!hide` and `porn` or the identifiers of `label_value_definitions`",
+				ElementType:         types.StringType,
+				Required:            true,
+				Validators: []validator.Set{
+					setvalidator.ValueStringsAre(
+						stringvalidator.RegexMatches(labelValuePattern, "must only contain lowercase letters and hyphens, optionally after an exclamation mark"),
+					),
+				},
+			},
+			"label_value_definitions": schema.ListNestedAttribute{
+				MarkdownDescription: "Custom label values defined by the labeler, in the order apps show them. Every identifier must also be in `label_values`.",
+				Optional:            true,
+				Validators: []validator.List{
+					listvalidator.SizeAtLeast(1),
+				},
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"identifier": schema.StringAttribute{
+							MarkdownDescription: "Value of the label, made of lowercase letters and hyphens",
+							Required:            true,
+							Validators: []validator.String{
+								stringvalidator.LengthBetween(1, 100),
+								stringvalidator.RegexMatches(labelIdentifierPattern, "must only contain lowercase letters and hyphens"),
+							},
+						},
+						"severity": schema.StringAttribute{
+							MarkdownDescription: "How apps convey the label: `inform` for neutral information, `alert` for a warning, or `none`",
+							Required:            true,
+							Validators: []validator.String{
+								stringvalidator.OneOf("inform", "alert", "none"),
+							},
+						},
+						"blurs": schema.StringAttribute{
+							MarkdownDescription: "What apps hide when the label is applied: `content` hides all of the subject, `media` its images and videos, and `none` nothing",
+							Required:            true,
+							Validators: []validator.String{
+								stringvalidator.OneOf("content", "media", "none"),
+							},
+						},
+						"default_setting": schema.StringAttribute{
+							MarkdownDescription: "Setting of users who didn't configure the label, one of `ignore`, `warn` or `hide`. Defaults to `warn`.",
+							Optional:            true,
+							Computed:            true,
+							Default:             stringdefault.StaticString("warn"),
+							Validators: []validator.String{
+								stringvalidator.OneOf("ignore", "warn", "hide"),
+							},
+						},
+						"adult_only": schema.BoolAttribute{
+							MarkdownDescription: "Whether users must have enabled adult content to configure the label. Defaults to `false`.",
+							Optional:            true,
+							Computed:            true,
+							Default:             booldefault.StaticBool(false),
+						},
+						"locales": schema.ListNestedAttribute{
+							MarkdownDescription: "Name and description of the label in each language",
+							Required:            true,
+							Validators: []validator.List{
+								listvalidator.SizeAtLeast(1),
+							},
+							NestedObject: schema.NestedAttributeObject{
+								Attributes: map[string]schema.Attribute{
+									"lang": schema.StringAttribute{
+										MarkdownDescription: "Language of the strings, such as `en` or `pt-BR`",
+										Required:            true,
+										Validators: []validator.String{
+											stringvalidator.RegexMatches(languagePattern, "must be a language tag such as en or pt-BR"),
+										},
+									},
+									"name": schema.StringAttribute{
+										MarkdownDescription: "Short name of the label",
+										Required:            true,
+										Validators: []validator.String{
+											stringvalidator.LengthBetween(1, 640),
+										},
+									},
+									"description": schema.StringAttribute{
+										MarkdownDescription: "Longer description of what the label means and why it might be applied",
+										Required:            true,
+										Validators: []validator.String{
+											stringvalidator.LengthBetween(1, 100000),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			"reason_types": schema.SetAttribute{
+				MarkdownDescription: "Report reasons the labeler reviews, such as `com.atproto.moderation.defs#reasonSpam`. When not set, all reasons are accepted.",
+				ElementType:         types.StringType,
+				Optional:            true,
+				Validators: []validator.Set{
+					setvalidator.SizeAtLeast(1),
+					setvalidator.ValueStringsAre(
+						stringvalidator.RegexMatches(reasonTypePattern, "must be a reference to a reason type such as com.atproto.moderation.defs#reasonSpam"),
+					),
+				},
+			},
+			"subject_types": schema.SetAttribute{
+				MarkdownDescription: "Kinds of subjects the labeler accepts reports on, among `account`, `record` and `chat`",
+				ElementType:         types.StringType,
+				Optional:            true,
+				Validators: []validator.Set{
+					setvalidator.SizeAtLeast(1),
+					setvalidator.ValueStringsAre(
+						stringvalidator.OneOf("account", "record", "chat"),
+					),
+				},
+			},
+			"subject_collections": schema.SetAttribute{
+				MarkdownDescription: "Collections of the records the labeler accepts reports on, such as `app.bsky.feed.post`. When not set, records of any collection are accepted.",
+				ElementType:         types.StringType,
+				Optional:            true,
+				Validators: []validator.Set{
+					setvalidator.SizeAtLeast(1),
+					setvalidator.ValueStringsAre(nsidValidator{}),
+				},
+			},
+		},
+	}
+}
+
+// ValidateConfig checks that the label value definitions are consistent with
+// each other and with the published label values.
+func (l *labelerServiceResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config labelerServiceResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if config.LabelValueDefinitions.IsNull() || config.LabelValueDefinitions.IsUnknown() {
+		return
+	}
+
+	var definitions []labelValueDefinitionModel
+	resp.Diagnostics.Append(config.LabelValueDefinitions.ElementsAs(ctx, &definitions, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Definitions can't be checked against unknown label values, since any of
+	// them may be published by the unknown value.
+	var published map[string]bool
+	if !config.LabelValues.IsUnknown() {
+		var values []types.String
+		resp.Diagnostics.Append(config.LabelValues.ElementsAs(ctx, &values, false)...)
+		published = map[string]bool{}
+		for _, value := range values {
+			if value.IsUnknown() {
+				published = nil
+				break
+			}
+			published[value.ValueString()] = true
+		}
+	}
+
+	identifiers := map[string]bool{}
+	for i, definition := range definitions {
+		attribute := path.Root("label_value_definitions").AtListIndex(i)
+		if definition.Identifier.IsUnknown() {
+			continue
+		}
+		identifier := definition.Identifier.ValueString()
+		if identifiers[identifier] {
+			resp.Diagnostics.AddAttributeError(
+				attribute.AtName("identifier"),
+				"Duplicate label value definition",
+				"The label value "+identifier+" is defined more than once.",
+			)
+		}
+		identifiers[identifier] = true
+		if published != nil && !published[identifier] {
+			resp.Diagnostics.AddAttributeError(
+				attribute.AtName("identifier"),
+				"Unpublished label value definition",
+				"The label value "+identifier+" is defined but missing from label_values, so the labeler would never publish it.",
+			)
+		}
+
+		if definition.Locales.IsNull() || definition.Locales.IsUnknown() {
+			continue
+		}
+		var locales []labelValueLocaleModel
+		resp.Diagnostics.Append(definition.Locales.ElementsAs(ctx, &locales, false)...)
+		langs := map[string]bool{}
+		for j, locale := range locales {
+			if locale.Lang.IsUnknown() {
+				continue
+			}
+			if langs[locale.Lang.ValueString()] {
+				resp.Diagnostics.AddAttributeError(
+					attribute.AtName("locales").AtListIndex(j).AtName("lang"),
+					"Duplicate label value locale",
+					"The label value "+identifier+" has more than one locale for "+locale.Lang.ValueString()+".",
+				)
+			}
+			langs[locale.Lang.ValueString()] = true
+		}
+	}
+}
+
+func (l *labelerServiceResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	// Retrieve values from a plan.
+	var plan labelerServiceResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
+		return
+	}
+
+	client, diags := l.data.accountClient(ctx, plan.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Generate API request body from plan.
+	service := &bsky.LabelerService{
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	resp.Diagnostics.Append(updateLabelerService(ctx, service, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	rkey := labelerServiceRkey
+	createRecordInput := &atproto.RepoCreateRecord_Input{
+		Repo:       client.Auth.Did,
+		Collection: labelerServiceCollection,
+		Rkey:       &rkey,
+		Record:     &util.LexiconTypeDecoder{Val: service},
+	}
+
+	// Create the labeler service, which fails if the account is already a
+	// labeler. Existing labelers are imported instead.
+	record, err := atproto.RepoCreateRecord(ctx, client, createRecordInput)
+	if isSwapConflict(err) {
+		resp.Diagnostics.AddError("Repo changed outside of Terraform", swapConflictDetail("The repo "+client.Auth.Did, err))
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error creating labeler service",
+			"Could not declare "+client.Auth.Did+" as labeler, unexpected error: "+err.Error(),
+		)
+		return
+	}
+
+	// Map response body to schema and populate Computed attribute values.
+	plan.Did = types.StringValue(client.Auth.Did)
+	plan.Uri = types.StringValue(record.Uri)
+	plan.Cid = types.StringValue(record.Cid)
+
+	// Set state to fully populated data.
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Read refreshes the Terraform state with the latest data.
+func (l *labelerServiceResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	// Get current state.
+	var state labelerServiceResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, diags := l.data.accountClient(ctx, state.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	service, record, err := getLabelerService(ctx, client, state.Did.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error reading labeler service",
+			"Could not read the labeler service of "+state.Did.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+	if record == nil {
+		// The labeler service was deleted outside of Terraform.
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	// Overwrite with refreshed state using the repository record.
+	state.Uri = types.StringValue(record.Uri)
+	state.Cid = types.StringValue(*record.Cid)
+	resp.Diagnostics.Append(readLabelerService(ctx, service, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Set refreshed state.
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Update updates the resource and sets the updated Terraform state on success.
+func (l *labelerServiceResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	// Retrieve values from a plan.
+	var plan labelerServiceResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
+		return
+	}
+
+	var state labelerServiceResourceModel
+	diags = req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, diags := l.data.accountClient(ctx, plan.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	service, record, err := getLabelerService(ctx, client, plan.Did.ValueString())
+	if err == nil && record == nil {
+		err = fmt.Errorf("the account is not a labeler")
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to retrieve labeler service",
+			"Could not retrieve the current labeler service of "+plan.Did.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+
+	resp.Diagnostics.Append(updateLabelerService(ctx, service, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	putRecordInput := &atproto.RepoPutRecord_Input{
+		Collection: labelerServiceCollection,
+		Repo:       plan.Did.ValueString(),
+		Rkey:       labelerServiceRkey,
+		SwapRecord: swapRecord(state.Cid),
+		Record: &util.LexiconTypeDecoder{
+			Val: service,
+		},
+	}
+	updatedRecord, err := atproto.RepoPutRecord(ctx, client, putRecordInput)
+	if isSwapConflict(err) {
+		resp.Diagnostics.AddError("Labeler service changed outside of Terraform", swapConflictDetail("The labeler service of "+plan.Did.ValueString(), err))
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to update labeler service",
+			"Could not update the labeler service of "+plan.Did.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+
+	// Update resource state.
+	plan.Uri = types.StringValue(updatedRecord.Uri)
+	plan.Cid = types.StringValue(updatedRecord.Cid)
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Delete deletes the resource and removes the Terraform state on success.
+func (l *labelerServiceResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	// Retrieve values from state.
+	var state labelerServiceResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, diags := l.data.accountClient(ctx, state.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	deleteRequest := &atproto.RepoDeleteRecord_Input{
+		Collection: labelerServiceCollection,
+		Repo:       state.Did.ValueString(),
+		Rkey:       labelerServiceRkey,
+		SwapRecord: swapRecord(state.Cid),
+	}
+	_, err := atproto.RepoDeleteRecord(ctx, client, deleteRequest)
+	if isSwapConflict(err) {
+		resp.Diagnostics.AddError("Labeler service changed outside of Terraform", swapConflictDetail("The labeler service of "+state.Did.ValueString(), err))
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error deleting labeler service",
+			"Could not delete the labeler service of "+state.Did.ValueString()+", error: "+err.Error(),
+		)
+	}
+}
+
+// Configure adds the provider configured client to the resource.
+func (l *labelerServiceResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Add a nil check when handling ProviderData because Terraform
+	// sets that data after it calls the ConfigureProvider RPC.
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	resp.Diagnostics.Append(data.requireAccount("labeler services")...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	l.data = data
+}
+
+// ImportState imports the labeler service of the account with the DID given
+// as ID.
+func (l *labelerServiceResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	if _, err := syntax.ParseDID(req.ID); err != nil {
+		resp.Diagnostics.AddError(
+			"Invalid labeler service import ID",
+			"Labeler services are imported by the DID of their account, such as did:plc:7kkf4hujjl6wll6pewqahaex: "+err.Error(),
+		)
+		return
+	}
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("did"), req.ID)...)
+}
+
+// updateLabelerService sets the fields of service managed by the resource from
+// model, keeping its creation date.
+func updateLabelerService(ctx context.Context, service *bsky.LabelerService, model *labelerServiceResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	var values []string
+	diags.Append(model.LabelValues.ElementsAs(ctx, &values, false)...)
+	policies := &bsky.LabelerDefs_LabelerPolicies{
+		LabelValues: []*string{},
+	}
+	for _, value := range values {
+		policies.LabelValues = append(policies.LabelValues, &value)
+	}
+
+	if !model.LabelValueDefinitions.IsNull() {
+		var definitions []labelValueDefinitionModel
+		diags.Append(model.LabelValueDefinitions.ElementsAs(ctx, &definitions, false)...)
+		for _, definition := range definitions {
+			var locales []labelValueLocaleModel
+			diags.Append(definition.Locales.ElementsAs(ctx, &locales, false)...)
+			labelValueDefinition := &atproto.LabelDefs_LabelValueDefinition{
+				Identifier:     definition.Identifier.ValueString(),
+				Severity:       definition.Severity.ValueString(),
+				Blurs:          definition.Blurs.ValueString(),
+				DefaultSetting: definition.DefaultSetting.ValueStringPointer(),
+				AdultOnly:      definition.AdultOnly.ValueBoolPointer(),
+				Locales:        []*atproto.LabelDefs_LabelValueDefinitionStrings{},
+			}
+			for _, locale := range locales {
+				labelValueDefinition.Locales = append(labelValueDefinition.Locales, &atproto.LabelDefs_LabelValueDefinitionStrings{
+					Lang:        locale.Lang.ValueString(),
+					Name:        locale.Name.ValueString(),
+					Description: locale.Description.ValueString(),
+				})
+			}
+			policies.LabelValueDefinitions = append(policies.LabelValueDefinitions, labelValueDefinition)
+		}
+	}
+	service.Policies = policies
+
+	var reasonTypes, subjectTypes []string
+	service.ReasonTypes = nil
+	if !model.ReasonTypes.IsNull() {
+		diags.Append(model.ReasonTypes.ElementsAs(ctx, &reasonTypes, false)...)
+		for _, reasonType := range reasonTypes {
+			service.ReasonTypes = append(service.ReasonTypes, &reasonType)
+		}
+	}
+	service.SubjectTypes = nil
+	if !model.SubjectTypes.IsNull() {
+		diags.Append(model.SubjectTypes.ElementsAs(ctx, &subjectTypes, false)...)
+		for _, subjectType := range subjectTypes {
+			service.SubjectTypes = append(service.SubjectTypes, &subjectType)
+		}
+	}
+	service.SubjectCollections = nil
+	if !model.SubjectCollections.IsNull() {
+		diags.Append(model.SubjectCollections.ElementsAs(ctx, &service.SubjectCollections, false)...)
+	}
+	return diags
+}
+
+// readLabelerService sets the attributes of model from service, so that
+// changes of any field of the record outside of Terraform show up as drift.
+func readLabelerService(ctx context.Context, service *bsky.LabelerService, model *labelerServiceResourceModel) diag.Diagnostics {
+	var diags, d diag.Diagnostics
+
+	values := []string{}
+	definitions := []labelValueDefinitionModel{}
+	if service.Policies != nil {
+		values = stringValues(service.Policies.LabelValues)
+		for _, definition := range service.Policies.LabelValueDefinitions {
+			locales := []labelValueLocaleModel{}
+			for _, locale := range definition.Locales {
+				locales = append(locales, labelValueLocaleModel{
+					Lang:        types.StringValue(locale.Lang),
+					Name:        types.StringValue(locale.Name),
+					Description: types.StringValue(locale.Description),
+				})
+			}
+			item := labelValueDefinitionModel{
+				Identifier:     types.StringValue(definition.Identifier),
+				Severity:       types.StringValue(definition.Severity),
+				Blurs:          types.StringValue(definition.Blurs),
+				DefaultSetting: types.StringValue("warn"),
+				AdultOnly:      types.BoolValue(definition.AdultOnly != nil && *definition.AdultOnly),
+			}
+			if definition.DefaultSetting != nil {
+				item.DefaultSetting = types.StringValue(*definition.DefaultSetting)
+			}
+			item.Locales, d = types.ListValueFrom(ctx, labelValueLocaleType, locales)
+			diags.Append(d...)
+			definitions = append(definitions, item)
+		}
+	}
+
+	model.LabelValues, d = types.SetValueFrom(ctx, types.StringType, values)
+	diags.Append(d...)
+	model.LabelValueDefinitions = types.ListNull(labelValueDefinitionType)
+	if len(definitions) > 0 {
+		model.LabelValueDefinitions, d = types.ListValueFrom(ctx, labelValueDefinitionType, definitions)
+		diags.Append(d...)
+	}
+
+	model.ReasonTypes = types.SetNull(types.StringType)
+	if len(service.ReasonTypes) > 0 {
+		model.ReasonTypes, d = types.SetValueFrom(ctx, types.StringType, stringValues(service.ReasonTypes))
+		diags.Append(d...)
+	}
+	model.SubjectTypes = types.SetNull(types.StringType)
+	if len(service.SubjectTypes) > 0 {
+		model.SubjectTypes, d = types.SetValueFrom(ctx, types.StringType, stringValues(service.SubjectTypes))
+		diags.Append(d...)
+	}
+	model.SubjectCollections = types.SetNull(types.StringType)
+	if len(service.SubjectCollections) > 0 {
+		model.SubjectCollections, d = types.SetValueFrom(ctx, types.StringType, service.SubjectCollections)
+		diags.Append(d...)
+	}
+	return diags
+}
+
+// stringValues returns the values of a slice of string pointers, skipping the
+// nil ones.
+func stringValues(pointers []*string) []string {
+	values := []string{}
+	for _, pointer := range pointers {
+		if pointer != nil {
+			values = append(values, *pointer)
+		}
+	}
+	return values
+}
+
+// getLabelerService returns the labeler service record of did, or a nil record
+// if the account isn't a labeler.
+func getLabelerService(ctx context.Context, client *xrpc.Client, did string) (*bsky.LabelerService, *atproto.RepoGetRecord_Output, error) {
+	record, err := atproto.RepoGetRecord(ctx, client, "", labelerServiceCollection, did, labelerServiceRkey)
+	if isRecordNotFound(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not get record: %w", err)
+	}
+	if record.Cid == nil {
+		return nil, nil, fmt.Errorf("record.Cid is nil")
+	}
+
+	service, ok := record.Value.Val.(*bsky.LabelerService)
+	if !ok {
+		return nil, nil, fmt.Errorf("could not cast record to LabelerService")
+	}
+	return service, record, nil
+}
+
+// nsidValidator checks that a string is a namespaced identifier, such as the
+// name of a collection.
+type nsidValidator struct{}
+
+func (v nsidValidator) Description(_ context.Context) string {
+	return "value must be a namespaced identifier such as app.bsky.feed.post"
+}
+
+func (v nsidValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v nsidValidator) ValidateString(_ context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	if _, err := syntax.ParseNSID(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid NSID",
+			"Could not use "+req.ConfigValue.ValueString()+" as namespaced identifier: "+err.Error(),
+		)
+	}
+}
@@ -0,0 +1,77 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/bluesky-social/indigo/api/bsky"
+	"github.com/bluesky-social/indigo/lex/util"
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const listBlockCollection = "app.bsky.graph.listblock"
+
+// NewListBlockResource is a helper function to simplify the provider implementation.
+func NewListBlockResource() resource.Resource {
+	return &subjectRecordResource{record: subjectRecord{
+		typeName:           "_list_block",
+		description:        "Subscribe to a Bluesky moderation list, blocking every account on it",
+		collection:         listBlockCollection,
+		noun:               "list block",
+		subjectAttribute:   "list_uri",
+		subjectDescription: "The URI of the moderation list to block, whose purpose must be `app.bsky.graph.defs#modlist`",
+		newModel:           func() subjectRecordModel { return &listBlockResourceModel{} },
+		validate:           requireModList,
+		newRecord: func(subject string, createdAt string) *util.LexiconTypeDecoder {
+			return &util.LexiconTypeDecoder{Val: &bsky.GraphListblock{Subject: subject, CreatedAt: createdAt}}
+		},
+		subjectOf: func(record *util.LexiconTypeDecoder) (string, bool) {
+			listBlock, ok := record.Val.(*bsky.GraphListblock)
+			if !ok {
+				return "", false
+			}
+			return listBlock.Subject, true
+		},
+	}}
+}
+
+// listBlockResourceModel maps the list block schema data.
+type listBlockResourceModel struct {
+	subjectRecordResourceModel
+	ListUri types.String `tfsdk:"list_uri"`
+}
+
+func (m *listBlockResourceModel) subject() *types.String {
+	return &m.ListUri
+}
+
+// requireModList checks that the list at uri is a moderation list, which is
+// the only kind of list accounts can block or mute.
+func requireModList(ctx context.Context, client *xrpc.Client, uri string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	list, _, _, err := GetListFromURI(ctx, client, uri)
+	if err != nil {
+		diags.AddAttributeError(
+			path.Root("list_uri"),
+			"Failed to retrieve list",
+			"Could not retrieve the list "+uri+": "+err.Error(),
+		)
+		return diags
+	}
+	if list.Purpose == nil || *list.Purpose != "app.bsky.graph.defs#modlist" {
+		purpose := "no purpose"
+		if list.Purpose != nil {
+			purpose = *list.Purpose
+		}
+		diags.AddAttributeError(
+			path.Root("list_uri"),
+			"Not a moderation list",
+			"Only lists with the purpose app.bsky.graph.defs#modlist can be blocked or muted, "+uri+" has "+purpose,
+		)
+	}
+	return diags
+}
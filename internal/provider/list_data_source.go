@@ -3,9 +3,10 @@ package provider
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/bluesky-social/indigo/api/bsky"
-	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/bluesky-social/indigo/lex/util"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/types"
@@ -24,7 +25,7 @@ func NewListDataSource() datasource.DataSource {
 
 // listDataSource is the data source implementation.
 type listDataSource struct {
-	client *xrpc.Client
+	data *providerData
 }
 
 // listItemModel represents an item in a list.
@@ -35,6 +36,7 @@ type listItemModel struct {
 
 // listDataSourceModel maps the data source schema data.
 type listDataSourceModel struct {
+	Account       types.String `tfsdk:"account"`
 	Avatar        types.String `tfsdk:"avatar"`
 	Cid           types.String `tfsdk:"cid"`
 	Description   types.String `tfsdk:"description"`
@@ -56,8 +58,12 @@ func (d *listDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, r
 	resp.Schema = schema.Schema{
 		MarkdownDescription: "A datasource to retrieve lists and their contents",
 		Attributes: map[string]schema.Attribute{
+			"account": schema.StringAttribute{
+				MarkdownDescription: "Name of the account in the `accounts` provider attribute to read the list with. Defaults to the account the provider logs in to with `handle` and `password` or `oauth`.",
+				Optional:            true,
+			},
 			"avatar": schema.StringAttribute{
-				MarkdownDescription: "The CDN URL for the list's avatar image",
+				MarkdownDescription: "URL of the avatar image of the list on the PDS of its repository, or empty when the list has none",
 				Computed:            true,
 			},
 			"cid": schema.StringAttribute{
@@ -112,9 +118,15 @@ func (d *listDataSource) Read(ctx context.Context, req datasource.ReadRequest, r
 	// Read Terraform configuration data into the model.
 	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
 
+	client, diags := d.data.publicClient(ctx, data.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	uri := data.Uri.ValueString()
 
-	list, record, _, err := GetListFromURI(ctx, d.client, uri)
+	list, record, parsedUri, err := GetListFromURI(ctx, client, uri)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Unable to Read List",
@@ -126,7 +138,15 @@ func (d *listDataSource) Read(ctx context.Context, req datasource.ReadRequest, r
 	// Set all fields, using empty values for optional fields if nil
 	data.Avatar = types.StringValue("")
 	if list.Avatar != nil {
-		data.Avatar = types.StringValue(fmt.Sprintf("%v", list.Avatar))
+		avatar, err := d.avatarURL(ctx, parsedUri.Authority().String(), list.Avatar)
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Unable to Read List",
+				"Could not find the PDS serving the avatar of list "+uri+": "+err.Error(),
+			)
+			return
+		}
+		data.Avatar = types.StringValue(avatar)
 	}
 
 	// Handle nil pointer for Cid
@@ -158,7 +178,7 @@ func (d *listDataSource) Read(ctx context.Context, req datasource.ReadRequest, r
 	data.Items = []listItemModel{}
 
 	// Use GraphGetList to get the items
-	listWithItems, err := bsky.GraphGetList(ctx, d.client, "", 50, uri)
+	listWithItems, err := bsky.GraphGetList(ctx, client, "", 50, uri)
 	if err == nil && listWithItems != nil {
 		// Update the list item count if available
 		if listWithItems.List.ListItemCount != nil {
@@ -177,7 +197,7 @@ func (d *listDataSource) Read(ctx context.Context, req datasource.ReadRequest, r
 
 		// Paginate through all items
 		for listWithItems.Cursor != nil {
-			listWithItems, err = bsky.GraphGetList(ctx, d.client, *listWithItems.Cursor, 50, uri)
+			listWithItems, err = bsky.GraphGetList(ctx, client, *listWithItems.Cursor, 50, uri)
 			if err != nil {
 				resp.Diagnostics.AddError(
 					"Unable to Read List",
@@ -198,7 +218,7 @@ func (d *listDataSource) Read(ctx context.Context, req datasource.ReadRequest, r
 	}
 
 	// Set state
-	diags := resp.State.Set(ctx, &data)
+	diags = resp.State.Set(ctx, &data)
 	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
 		return
@@ -213,15 +233,33 @@ func (d *listDataSource) Configure(_ context.Context, req datasource.ConfigureRe
 		return
 	}
 
-	client, ok := req.ProviderData.(*xrpc.Client)
+	data, ok := req.ProviderData.(*providerData)
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Data Source Configure Type",
-			fmt.Sprintf("Expected *xrpc.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+			fmt.Sprintf("Expected *providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
 		)
 
 		return
 	}
 
-	d.client = client
+	d.data = data
+}
+
+// avatarURL returns the URL of the avatar blob of a list in the repository of
+// authority, a handle or DID, served by the PDS of the repository.
+func (d *listDataSource) avatarURL(ctx context.Context, authority string, avatar *util.LexBlob) (string, error) {
+	did := authority
+	if !strings.HasPrefix(authority, "did:") {
+		resolved, err := d.data.resolver.resolveHandle(ctx, authority)
+		if err != nil {
+			return "", err
+		}
+		did = resolved
+	}
+	pdsHost, err := d.data.resolver.resolvePDS(ctx, did)
+	if err != nil {
+		return "", err
+	}
+	return blobURL(pdsHost, did, avatar), nil
 }
@@ -10,12 +10,14 @@ import (
 	"github.com/bluesky-social/indigo/api/bsky"
 	"github.com/bluesky-social/indigo/atproto/syntax"
 	"github.com/bluesky-social/indigo/lex/util"
-	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
@@ -24,6 +26,7 @@ var (
 	_ resource.Resource                = &listItemResource{}
 	_ resource.ResourceWithConfigure   = &listItemResource{}
 	_ resource.ResourceWithImportState = &listItemResource{}
+	_ resource.ResourceWithModifyPlan  = &listItemResource{}
 )
 
 // NewListItemResource is a helper function to simplify the provider implementation.
@@ -33,13 +36,16 @@ func NewListItemResource() resource.Resource {
 
 // listItemResource is the resource implementation.
 type listItemResource struct {
-	client *xrpc.Client
+	data *providerData
 }
 
 type listItemResourceModel struct {
-	Uri        types.String `tfsdk:"uri"`
-	ListUri    types.String `tfsdk:"list_uri"`
-	SubjectDid types.String `tfsdk:"subject_did"`
+	Account       types.String `tfsdk:"account"`
+	Uri           types.String `tfsdk:"uri"`
+	Cid           types.String `tfsdk:"cid"`
+	ListUri       types.String `tfsdk:"list_uri"`
+	SubjectDid    types.String `tfsdk:"subject_did"`
+	SubjectHandle types.String `tfsdk:"subject_handle"`
 }
 
 // Metadata returns the resource type name.
@@ -52,13 +58,25 @@ func (r *listItemResource) Schema(_ context.Context, _ resource.SchemaRequest, r
 	resp.Schema = schema.Schema{
 		MarkdownDescription: "Manage users' membership on Bluesky lists",
 		Attributes: map[string]schema.Attribute{
+			"account": accountResourceAttribute(),
 			"subject_did": schema.StringAttribute{
-				MarkdownDescription: "The DID of the user to add to the list",
-				Required:            true,
+				MarkdownDescription: "The DID of the user to add to the list. Exactly one of `subject_did` and `subject_handle` must be set.",
+				Optional:            true,
+				Computed:            true,
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplace(),
+					// The DID resolved from subject_handle requires replacement
+					// in ModifyPlan instead.
+					stringplanmodifier.RequiresReplaceIfConfigured(),
+				},
+				Validators: []validator.String{
+					stringvalidator.ExactlyOneOf(path.MatchRoot("subject_handle")),
 				},
 			},
+			"subject_handle": schema.StringAttribute{
+				MarkdownDescription: "The handle of the user to add to the list, resolved to `subject_did` when planning, or when the list item is created if the handle is only known then. " +
+					"When the handle resolves to another DID than the one of the list item, the plan warns and replaces the list item.",
+				Optional: true,
+			},
 			"list_uri": schema.StringAttribute{
 				MarkdownDescription: "The URI of the list",
 				Required:            true,
@@ -72,10 +90,85 @@ func (r *listItemResource) Schema(_ context.Context, _ resource.SchemaRequest, r
 					stringplanmodifier.UseStateForUnknown(),
 				},
 			},
+			"cid": schema.StringAttribute{
+				MarkdownDescription: "Commit ID generated by Bluesky",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
 		},
 	}
 }
 
+// ModifyPlan resolves subject_handle to the DID of the list item, warning when
+// it no longer resolves to the DID in the state.
+func (l *listItemResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() || l.data == nil {
+		return
+	}
+
+	var plan listItemResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if plan.SubjectHandle.IsNull() || plan.SubjectHandle.IsUnknown() {
+		return
+	}
+
+	did, diags := l.resolveSubjectHandle(ctx, plan.SubjectHandle)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !req.State.Raw.IsNull() {
+		var state listItemResourceModel
+		resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		if state.SubjectDid.ValueString() != did {
+			resp.Diagnostics.AddAttributeWarning(
+				path.Root("subject_handle"),
+				"Handle resolves to a different DID",
+				"The handle "+plan.SubjectHandle.ValueString()+" now resolves to "+did+" instead of "+state.SubjectDid.ValueString()+
+					". The list item is replaced to add "+did+" to the list. Set subject_did instead to keep the current account.",
+			)
+			resp.RequiresReplace = append(resp.RequiresReplace, path.Root("subject_did"))
+		}
+	}
+
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("subject_did"), did)...)
+}
+
+// resolveSubjectHandle returns the DID subject_handle resolves to.
+func (l *listItemResource) resolveSubjectHandle(ctx context.Context, subjectHandle types.String) (string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	handle, err := syntax.ParseHandle(strings.TrimPrefix(subjectHandle.ValueString(), "@"))
+	if err != nil {
+		diags.AddAttributeError(
+			path.Root("subject_handle"),
+			"Invalid handle",
+			"Could not parse the handle "+subjectHandle.ValueString()+": "+err.Error(),
+		)
+		return "", diags
+	}
+
+	did, err := l.data.resolver.resolveHandle(ctx, handle.Normalize().String())
+	if err != nil {
+		diags.AddAttributeError(
+			path.Root("subject_handle"),
+			"Could not resolve handle",
+			"Could not resolve the handle "+handle.String()+" to a DID: "+err.Error(),
+		)
+		return "", diags
+	}
+	return did, diags
+}
+
 func (l *listItemResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
 	// Retrieve values from a plan.
 	var plan listItemResourceModel
@@ -85,6 +178,30 @@ func (l *listItemResource) Create(ctx context.Context, req resource.CreateReques
 		return
 	}
 
+	client, diags := l.data.accountClient(ctx, plan.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// A subject_handle only known when applying wasn't resolved by ModifyPlan.
+	if plan.SubjectDid.IsUnknown() && !plan.SubjectHandle.IsNull() && !plan.SubjectHandle.IsUnknown() {
+		did, diags := l.resolveSubjectHandle(ctx, plan.SubjectHandle)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		plan.SubjectDid = types.StringValue(did)
+	}
+	if plan.SubjectDid.IsUnknown() || plan.SubjectDid.ValueString() == "" {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("subject_did"),
+			"Missing subject DID",
+			"The DID of the account to add to the list is not known. Set subject_did or subject_handle.",
+		)
+		return
+	}
+
 	// Generate API request body from plan.
 	item := &bsky.GraphListitem{
 		List:      plan.ListUri.ValueString(),
@@ -92,13 +209,17 @@ func (l *listItemResource) Create(ctx context.Context, req resource.CreateReques
 		CreatedAt: time.Now().Format(time.RFC3339),
 	}
 	createRecordInput := &atproto.RepoCreateRecord_Input{
-		Repo:       l.client.Auth.Did,
-		Collection: "app.bsky.graph.listitem",
+		Repo:       client.Auth.Did,
+		Collection: listItemCollection,
 		Record:     &util.LexiconTypeDecoder{Val: item},
 	}
 
 	// Create new list.
-	record, err := atproto.RepoCreateRecord(ctx, l.client, createRecordInput)
+	record, err := atproto.RepoCreateRecord(ctx, client, createRecordInput)
+	if isSwapConflict(err) {
+		resp.Diagnostics.AddError("Repo changed outside of Terraform", swapConflictDetail("The repo "+client.Auth.Did, err))
+		return
+	}
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Error creating list item",
@@ -109,6 +230,7 @@ func (l *listItemResource) Create(ctx context.Context, req resource.CreateReques
 
 	// Map response body to schema and populate Computed attribute values.
 	plan.Uri = types.StringValue(record.Uri)
+	plan.Cid = types.StringValue(record.Cid)
 
 	// Set state to fully populated data.
 	diags = resp.State.Set(ctx, plan)
@@ -128,8 +250,19 @@ func (l *listItemResource) Read(ctx context.Context, req resource.ReadRequest, r
 		return
 	}
 
+	client, diags := l.data.accountClient(ctx, state.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	// Get refreshed list value from Bsky.
-	listItem, _, _, err := GetListItemFromURI(ctx, l.client, state.Uri.ValueString())
+	listItem, record, _, err := GetListItemFromURI(ctx, client, state.Uri.ValueString())
+	if isRecordNotFound(err) {
+		// The account was removed from the list outside of Terraform.
+		resp.State.RemoveResource(ctx)
+		return
+	}
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Error reading list item",
@@ -141,6 +274,7 @@ func (l *listItemResource) Read(ctx context.Context, req resource.ReadRequest, r
 	state.Uri = types.StringValue(state.Uri.ValueString())
 	state.ListUri = types.StringValue(listItem.List)
 	state.SubjectDid = types.StringValue(listItem.Subject)
+	state.Cid = types.StringValue(*record.Cid)
 
 	// Set refreshed state.
 	diags = resp.State.Set(ctx, &state)
@@ -151,8 +285,18 @@ func (l *listItemResource) Read(ctx context.Context, req resource.ReadRequest, r
 }
 
 // Update updates the resource and sets the updated Terraform state on success.
+// Every other attribute requires replacement, so only the account of an
+// imported list item can be set without replacing it.
 func (l *listItemResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
-	resp.Diagnostics.AddError("Not implemented", "List item must be replaced, not updated")
+	var plan listItemResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
 }
 
 // Delete deletes the resource and removes the Terraform state on success.
@@ -165,6 +309,12 @@ func (l *listItemResource) Delete(ctx context.Context, req resource.DeleteReques
 		return
 	}
 
+	client, diags := l.data.accountClient(ctx, state.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	// Delete existing list.
 	uri, err := syntax.ParseATURI(state.Uri.ValueString())
 	if err != nil {
@@ -178,8 +328,13 @@ func (l *listItemResource) Delete(ctx context.Context, req resource.DeleteReques
 		Collection: uri.Collection().String(),
 		Repo:       uri.Authority().String(),
 		Rkey:       uri.RecordKey().String(),
+		SwapRecord: swapRecord(state.Cid),
+	}
+	_, err = atproto.RepoDeleteRecord(ctx, client, deleteRequest)
+	if isSwapConflict(err) {
+		resp.Diagnostics.AddError("List item changed outside of Terraform", swapConflictDetail("The list item "+state.Uri.ValueString(), err))
+		return
 	}
-	_, err = atproto.RepoDeleteRecord(ctx, l.client, deleteRequest)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Error deleting list item",
@@ -196,18 +351,23 @@ func (l *listItemResource) Configure(_ context.Context, req resource.ConfigureRe
 		return
 	}
 
-	client, ok := req.ProviderData.(*xrpc.Client)
+	data, ok := req.ProviderData.(*providerData)
 
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Data Source Configure Type",
-			fmt.Sprintf("Expected *xrpc.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+			fmt.Sprintf("Expected *providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
 		)
 
 		return
 	}
 
-	l.client = client
+	resp.Diagnostics.Append(data.requireAccount("list items")...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	l.data = data
 }
 
 func (l *listItemResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
@@ -0,0 +1,608 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/api/bsky"
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/bluesky-social/indigo/lex/util"
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/setvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	listItemCollection = "app.bsky.graph.listitem"
+
+	// maxApplyWrites is the number of writes the PDS accepts in one
+	// applyWrites call.
+	maxApplyWrites = 200
+	// listRecordsLimit is the page size of listRecords.
+	listRecordsLimit = 100
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ resource.Resource                = &listMembersResource{}
+	_ resource.ResourceWithConfigure   = &listMembersResource{}
+	_ resource.ResourceWithImportState = &listMembersResource{}
+)
+
+// NewListMembersResource is a helper function to simplify the provider implementation.
+func NewListMembersResource() resource.Resource {
+	return &listMembersResource{}
+}
+
+// listMembersResource is the resource implementation. It manages the list
+// items of a set of accounts in one resource, writing them in batches. Only
+// the list items it tracks in members are ever deleted, so list items added
+// by other means are left alone.
+type listMembersResource struct {
+	data *providerData
+}
+
+type listMembersResourceModel struct {
+	Account     types.String `tfsdk:"account"`
+	ListUri     types.String `tfsdk:"list_uri"`
+	SubjectDids types.Set    `tfsdk:"subject_dids"`
+	BatchSize   types.Int64  `tfsdk:"batch_size"`
+	Members     types.Map    `tfsdk:"members"`
+}
+
+// listItemRecord is a list item of the repo, as returned by listRecords.
+type listItemRecord struct {
+	uri     string
+	subject string
+}
+
+// Metadata returns the resource type name.
+func (l *listMembersResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_list_members"
+}
+
+// Schema defines the schema for the resource.
+func (l *listMembersResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Manage the membership of many accounts on a Bluesky list at once. Changes are written in batches with `com.atproto.repo.applyWrites`, " +
+			"and list items of accounts the resource didn't add are left alone. Don't manage the same accounts with `bsky_list_item` too.",
+		Attributes: map[string]schema.Attribute{
+			"account": accountResourceAttribute(),
+			"list_uri": schema.StringAttribute{
+				MarkdownDescription: "The URI of the list, which must belong to the account",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"subject_dids": schema.SetAttribute{
+				MarkdownDescription: "The DIDs of the accounts on the list",
+				ElementType:         types.StringType,
+				Required:            true,
+				Validators: []validator.Set{
+					setvalidator.ValueStringsAre(didValidator{}),
+				},
+			},
+			"batch_size": schema.Int64Attribute{
+				MarkdownDescription: fmt.Sprintf("Number of list items written per `applyWrites` call, at most %d. Defaults to %d.", maxApplyWrites, maxApplyWrites),
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(maxApplyWrites),
+				Validators: []validator.Int64{
+					int64validator.Between(1, maxApplyWrites),
+				},
+			},
+			"members": schema.MapAttribute{
+				MarkdownDescription: "URI of the list item of each account, by DID",
+				ElementType:         types.StringType,
+				Computed:            true,
+				PlanModifiers: []planmodifier.Map{
+					listMembersPlanModifier{},
+				},
+			},
+		},
+	}
+}
+
+func (l *listMembersResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	// Retrieve values from a plan.
+	var plan listMembersResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
+		return
+	}
+
+	client, diags := l.data.accountClient(ctx, plan.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(checkOwnList(client, plan.ListUri)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(l.apply(ctx, client, &plan, map[string]string{})...)
+
+	// Set the state even when a batch failed, so that the list items written
+	// by the batches before it are tracked.
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Read refreshes the Terraform state with the latest data.
+func (l *listMembersResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	// Get current state.
+	var state listMembersResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, diags := l.data.accountClient(ctx, state.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	members, diags := readListMembers(ctx, client, state.ListUri.ValueString(), state.Members)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.Members, state.SubjectDids, diags = listMembersValues(ctx, members)
+	resp.Diagnostics.Append(diags...)
+	if state.BatchSize.IsNull() {
+		state.BatchSize = types.Int64Value(maxApplyWrites)
+	}
+
+	// Set refreshed state.
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Update adds and removes the list items of the accounts added to and removed
+// from subject_dids.
+func (l *listMembersResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	// Retrieve values from a plan.
+	var plan listMembersResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
+		return
+	}
+
+	var state listMembersResourceModel
+	diags = req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, diags := l.data.accountClient(ctx, plan.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var current map[string]string
+	resp.Diagnostics.Append(state.Members.ElementsAs(ctx, &current, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(l.apply(ctx, client, &plan, current)...)
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+// Delete deletes the list items tracked by the resource and removes the
+// Terraform state on success.
+func (l *listMembersResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	// Retrieve values from state.
+	var state listMembersResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, diags := l.data.accountClient(ctx, state.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var current map[string]string
+	resp.Diagnostics.Append(state.Members.ElementsAs(ctx, &current, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	state.SubjectDids = types.SetValueMust(types.StringType, nil)
+	resp.Diagnostics.Append(l.apply(ctx, client, &state, current)...)
+	if resp.Diagnostics.HasError() {
+		// Keep tracking the list items which weren't deleted.
+		diags = resp.State.Set(ctx, state)
+		resp.Diagnostics.Append(diags...)
+	}
+}
+
+// Configure adds the provider configured client to the resource.
+func (l *listMembersResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Add a nil check when handling ProviderData because Terraform
+	// sets that data after it calls the ConfigureProvider RPC.
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	resp.Diagnostics.Append(data.requireAccount("list members")...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	l.data = data
+}
+
+// ImportState imports every list item of the list with the URI given as ID.
+func (l *listMembersResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("list_uri"), req, resp)
+}
+
+// apply writes the difference between the current list items, by DID, and the
+// subject_dids of model, then sets the members and subject_dids of model to
+// the list items which exist afterwards.
+func (l *listMembersResource) apply(ctx context.Context, client *xrpc.Client, model *listMembersResourceModel, current map[string]string) diag.Diagnostics {
+	var desired []string
+	diags := model.SubjectDids.ElementsAs(ctx, &desired, false)
+	if diags.HasError() {
+		return diags
+	}
+
+	members, d := applyListMembers(ctx, client, model.ListUri.ValueString(), desired, model.BatchSize.ValueInt64(), current)
+	diags.Append(d...)
+	model.Members, model.SubjectDids, d = listMembersValues(ctx, members)
+	diags.Append(d...)
+	return diags
+}
+
+// checkOwnList reports an error unless listUri is a list in the repo of the
+// account of client, which is where its list items have to be written.
+func checkOwnList(client *xrpc.Client, listUri types.String) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	uri, err := syntax.ParseATURI(listUri.ValueString())
+	if err != nil || uri.Authority().String() != client.Auth.Did || uri.Collection().String() != "app.bsky.graph.list" {
+		diags.AddAttributeError(
+			path.Root("list_uri"),
+			"Invalid list URI",
+			listUri.ValueString()+" is not a list of "+client.Auth.Did+". List items are stored in the repo of the list.",
+		)
+	}
+	return diags
+}
+
+// readListMembers returns the list items by DID of the tracked members which
+// still exist. List items deleted outside of Terraform are dropped, so that the
+// plan adds their accounts again. When tracked is null, as after an import,
+// every list item of the list is adopted.
+func readListMembers(ctx context.Context, client *xrpc.Client, listUri string, tracked types.Map) (map[string]string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	items, err := listItemsOfList(ctx, client, listUri)
+	if err != nil {
+		diags.AddError(
+			"Error reading list members",
+			"Could not list the list items of "+listUri+": "+err.Error(),
+		)
+		return nil, diags
+	}
+
+	members := map[string]string{}
+	if tracked.IsNull() {
+		for _, item := range items {
+			if _, ok := members[item.subject]; !ok {
+				members[item.subject] = item.uri
+			}
+		}
+		return members, diags
+	}
+
+	var current map[string]string
+	diags.Append(tracked.ElementsAs(ctx, &current, false)...)
+	if diags.HasError() {
+		return nil, diags
+	}
+	existing := map[string]string{}
+	for _, item := range items {
+		existing[item.uri] = item.subject
+	}
+	for did, uri := range current {
+		if existing[uri] == did {
+			members[did] = uri
+		}
+	}
+	return members, diags
+}
+
+// applyListMembers writes the difference between the current list items, by
+// DID, and the desired DIDs in batches, and returns the list items which exist
+// afterwards, even when a batch failed. Accounts which already have a list
+// item on the list that isn't tracked in current adopt it instead of getting a
+// duplicate.
+func applyListMembers(ctx context.Context, client *xrpc.Client, listUri string, desired []string, batchSize int64, current map[string]string) (map[string]string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	wanted := map[string]bool{}
+	for _, did := range desired {
+		if _, err := syntax.ParseDID(did); err != nil {
+			diags.AddAttributeError(
+				path.Root("subject_dids"),
+				"Invalid subject DID",
+				"Could not parse the DID "+did+": "+err.Error(),
+			)
+		}
+		wanted[did] = true
+	}
+
+	members := map[string]string{}
+	for did, uri := range current {
+		members[did] = uri
+	}
+	if diags.HasError() {
+		return members, diags
+	}
+
+	var removed []string
+	for did := range current {
+		if !wanted[did] {
+			removed = append(removed, did)
+		}
+	}
+	var added []string
+	for _, did := range desired {
+		if _, ok := current[did]; !ok {
+			added = append(added, did)
+		}
+	}
+	sort.Strings(removed)
+	sort.Strings(added)
+
+	if len(added) > 0 {
+		items, err := listItemsOfList(ctx, client, listUri)
+		if err != nil {
+			diags.AddError(
+				"Failed to list list items",
+				"Could not list the list items of "+listUri+": "+err.Error(),
+			)
+			return members, diags
+		}
+		existing := map[string]string{}
+		for _, item := range items {
+			if _, ok := existing[item.subject]; !ok {
+				existing[item.subject] = item.uri
+			}
+		}
+		var created []string
+		for _, did := range added {
+			if uri, ok := existing[did]; ok {
+				members[did] = uri
+			} else {
+				created = append(created, did)
+			}
+		}
+		added = created
+	}
+
+	err := applyListItemWrites(ctx, client, listUri, removed, added, int(batchSize), members)
+	if err != nil {
+		diags.AddError(
+			"Failed to update list members",
+			"Could not write the list items of "+listUri+": "+err.Error(),
+		)
+	}
+	return members, diags
+}
+
+// applyListItemWrites deletes the list items of removed and creates list
+// items for added, at most batchSize per applyWrites call, and updates members
+// after each successful batch.
+func applyListItemWrites(ctx context.Context, client *xrpc.Client, listUri string, removed []string, added []string, batchSize int, members map[string]string) error {
+	if batchSize <= 0 || batchSize > maxApplyWrites {
+		batchSize = maxApplyWrites
+	}
+
+	for start := 0; start < len(removed); start += batchSize {
+		batch := removed[start:min(start+batchSize, len(removed))]
+		var writes []*atproto.RepoApplyWrites_Input_Writes_Elem
+		for _, did := range batch {
+			uri, err := syntax.ParseATURI(members[did])
+			if err != nil {
+				return fmt.Errorf("invalid list item URI %s: %w", members[did], err)
+			}
+			writes = append(writes, &atproto.RepoApplyWrites_Input_Writes_Elem{
+				RepoApplyWrites_Delete: &atproto.RepoApplyWrites_Delete{
+					Collection: listItemCollection,
+					Rkey:       uri.RecordKey().String(),
+				},
+			})
+		}
+		_, err := atproto.RepoApplyWrites(ctx, client, &atproto.RepoApplyWrites_Input{
+			Repo:   client.Auth.Did,
+			Writes: writes,
+		})
+		if err != nil {
+			return err
+		}
+		for _, did := range batch {
+			delete(members, did)
+		}
+	}
+
+	createdAt := time.Now().Format(time.RFC3339)
+	for start := 0; start < len(added); start += batchSize {
+		batch := added[start:min(start+batchSize, len(added))]
+		var writes []*atproto.RepoApplyWrites_Input_Writes_Elem
+		for _, did := range batch {
+			writes = append(writes, &atproto.RepoApplyWrites_Input_Writes_Elem{
+				RepoApplyWrites_Create: &atproto.RepoApplyWrites_Create{
+					Collection: listItemCollection,
+					Value: &util.LexiconTypeDecoder{Val: &bsky.GraphListitem{
+						List:      listUri,
+						Subject:   did,
+						CreatedAt: createdAt,
+					}},
+				},
+			})
+		}
+		out, err := atproto.RepoApplyWrites(ctx, client, &atproto.RepoApplyWrites_Input{
+			Repo:   client.Auth.Did,
+			Writes: writes,
+		})
+		if err != nil {
+			return err
+		}
+		// Results are in the order of the writes.
+		if len(out.Results) != len(batch) {
+			return fmt.Errorf("applyWrites returned %d results for %d writes", len(out.Results), len(batch))
+		}
+		for i, result := range out.Results {
+			if result.RepoApplyWrites_CreateResult == nil {
+				return fmt.Errorf("applyWrites returned no create result for %s", batch[i])
+			}
+			members[batch[i]] = result.RepoApplyWrites_CreateResult.Uri
+		}
+	}
+	return nil
+}
+
+// listItemsOfList returns the list items of the list in the repo of the list,
+// which is where apps look for them.
+func listItemsOfList(ctx context.Context, client *xrpc.Client, listUri string) ([]listItemRecord, error) {
+	uri, err := syntax.ParseATURI(listUri)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []listItemRecord
+	cursor := ""
+	for {
+		out, err := atproto.RepoListRecords(ctx, client, listItemCollection, cursor, listRecordsLimit, uri.Authority().String(), false)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range out.Records {
+			if record.Value == nil {
+				continue
+			}
+			item, ok := record.Value.Val.(*bsky.GraphListitem)
+			if ok && item.List == listUri {
+				items = append(items, listItemRecord{uri: record.Uri, subject: item.Subject})
+			}
+		}
+		if out.Cursor == nil || *out.Cursor == "" || len(out.Records) == 0 {
+			return items, nil
+		}
+		cursor = *out.Cursor
+	}
+}
+
+// listMembersValues returns the members and subject_dids attributes of the
+// list items by DID.
+func listMembersValues(ctx context.Context, members map[string]string) (types.Map, types.Set, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	dids := make([]string, 0, len(members))
+	for did := range members {
+		dids = append(dids, did)
+	}
+	membersValue, d := types.MapValueFrom(ctx, types.StringType, members)
+	diags.Append(d...)
+	didsValue, d := types.SetValueFrom(ctx, types.StringType, dids)
+	diags.Append(d...)
+	return membersValue, didsValue, diags
+}
+
+// listMembersPlanModifier keeps the members of the state unless subject_dids
+// changes, in which case they are only known after apply.
+type listMembersPlanModifier struct{}
+
+func (m listMembersPlanModifier) Description(_ context.Context) string {
+	return "The members are kept unless subject_dids changes."
+}
+
+func (m listMembersPlanModifier) MarkdownDescription(ctx context.Context) string {
+	return m.Description(ctx)
+}
+
+func (m listMembersPlanModifier) PlanModifyMap(ctx context.Context, req planmodifier.MapRequest, resp *planmodifier.MapResponse) {
+	if req.StateValue.IsNull() || req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var planned, prior types.Set
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("subject_dids"), &planned)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("subject_dids"), &prior)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if planned.Equal(prior) {
+		resp.PlanValue = req.StateValue
+	}
+}
+
+// didValidator checks that a string is a DID.
+type didValidator struct{}
+
+func (v didValidator) Description(_ context.Context) string {
+	return "value must be a DID such as did:plc:z72i7hdynmk6r22z27h6tvur"
+}
+
+func (v didValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v didValidator) ValidateString(_ context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	if _, err := syntax.ParseDID(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid DID",
+			"Could not use "+req.ConfigValue.ValueString()+" as DID: "+err.Error(),
+		)
+	}
+}
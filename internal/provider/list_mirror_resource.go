@@ -0,0 +1,627 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bluesky-social/indigo/api/bsky"
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/setvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ resource.Resource                   = &listMirrorResource{}
+	_ resource.ResourceWithConfigure      = &listMirrorResource{}
+	_ resource.ResourceWithImportState    = &listMirrorResource{}
+	_ resource.ResourceWithModifyPlan     = &listMirrorResource{}
+	_ resource.ResourceWithValidateConfig = &listMirrorResource{}
+)
+
+// NewListMirrorResource is a helper function to simplify the provider implementation.
+func NewListMirrorResource() resource.Resource {
+	return &listMirrorResource{}
+}
+
+// listMirrorResource is the resource implementation. It keeps the members of a
+// list in sync with a source list or file. The source is read when planning,
+// so that subject_dids shows the accounts an apply adds and removes, and the
+// list items are written like the ones of bsky_list_members.
+type listMirrorResource struct {
+	data *providerData
+}
+
+type listMirrorResourceModel struct {
+	Account       types.String `tfsdk:"account"`
+	ListUri       types.String `tfsdk:"list_uri"`
+	SourceListUri types.String `tfsdk:"source_list_uri"`
+	SourceFile    types.String `tfsdk:"source_file"`
+	Include       types.Set    `tfsdk:"include"`
+	Exclude       types.Set    `tfsdk:"exclude"`
+	MaxRemovals   types.Int64  `tfsdk:"max_removals"`
+	BatchSize     types.Int64  `tfsdk:"batch_size"`
+	SubjectDids   types.Set    `tfsdk:"subject_dids"`
+	Members       types.Map    `tfsdk:"members"`
+}
+
+// sourceAccount is an account of the source of a mirrored list. The handle is
+// empty for accounts of a source file listed by DID.
+type sourceAccount struct {
+	did    string
+	handle string
+}
+
+// Metadata returns the resource type name.
+func (l *listMirrorResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_list_mirror"
+}
+
+// Schema defines the schema for the resource.
+func (l *listMirrorResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Keep the members of a Bluesky list in sync with another list or a local file of DIDs and handles. " +
+			"The source is read on every plan, and list items of accounts the resource didn't add are left alone.",
+		Attributes: map[string]schema.Attribute{
+			"account": accountResourceAttribute(),
+			"list_uri": schema.StringAttribute{
+				MarkdownDescription: "The URI of the list to keep in sync, which must belong to the account",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"source_list_uri": schema.StringAttribute{
+				MarkdownDescription: "The URI of a list to copy the members of, read from the AppView with `app.bsky.graph.getList`. Exactly one of `source_list_uri` and `source_file` must be set.",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.ExactlyOneOf(path.MatchRoot("source_file")),
+				},
+			},
+			"source_file": schema.StringAttribute{
+				MarkdownDescription: "Path of a file with a DID or handle per line. Lines can be CSV rows, of which the first column is used. " +
+					"Blank lines, lines starting with `#` and a header row are skipped, and handles are resolved to DIDs.",
+				Optional: true,
+			},
+			"include": schema.SetAttribute{
+				MarkdownDescription: "Regular expressions of which the DID or handle of an account of the source must match one to be mirrored. " +
+					"Accounts of `source_file` listed by DID are only matched by DID. All accounts are mirrored by default.",
+				ElementType: types.StringType,
+				Optional:    true,
+				Validators: []validator.Set{
+					setvalidator.ValueStringsAre(regexpValidator{}),
+				},
+			},
+			"exclude": schema.SetAttribute{
+				MarkdownDescription: "Regular expressions matching the DID or handle of accounts of the source which aren't mirrored",
+				ElementType:         types.StringType,
+				Optional:            true,
+				Validators: []validator.Set{
+					setvalidator.ValueStringsAre(regexpValidator{}),
+				},
+			},
+			"max_removals": schema.Int64Attribute{
+				MarkdownDescription: "Maximum number of accounts a plan may remove from the list. Plans removing more fail, so that a truncated or wrong source doesn't empty the list. Unlimited by default.",
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(0),
+				},
+			},
+			"batch_size": schema.Int64Attribute{
+				MarkdownDescription: fmt.Sprintf("Number of list items written per `applyWrites` call, at most %d. Defaults to %d.", maxApplyWrites, maxApplyWrites),
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(maxApplyWrites),
+				Validators: []validator.Int64{
+					int64validator.Between(1, maxApplyWrites),
+				},
+			},
+			"subject_dids": schema.SetAttribute{
+				MarkdownDescription: "The DIDs of the accounts of the source which pass the filters",
+				ElementType:         types.StringType,
+				Computed:            true,
+			},
+			"members": schema.MapAttribute{
+				MarkdownDescription: "URI of the list item of each account, by DID",
+				ElementType:         types.StringType,
+				Computed:            true,
+			},
+		},
+	}
+}
+
+// ValidateConfig checks that the mirrored list isn't its own source.
+func (l *listMirrorResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config listMirrorResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !config.ListUri.IsUnknown() && !config.SourceListUri.IsUnknown() && config.ListUri.Equal(config.SourceListUri) {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("source_list_uri"),
+			"Invalid source list",
+			"A list can't mirror itself.",
+		)
+	}
+}
+
+// ModifyPlan reads the source to plan subject_dids, and fails the plan when it
+// removes more accounts than max_removals allows.
+func (l *listMirrorResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() || l.data == nil {
+		return
+	}
+
+	var plan listMirrorResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	var state *listMirrorResourceModel
+	if !req.State.Raw.IsNull() {
+		state = &listMirrorResourceModel{}
+		resp.Diagnostics.Append(req.State.Get(ctx, state)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	if plan.Account.IsUnknown() || plan.SourceListUri.IsUnknown() || plan.SourceFile.IsUnknown() || plan.Include.IsUnknown() || plan.Exclude.IsUnknown() || plan.MaxRemovals.IsUnknown() {
+		plan.SubjectDids = types.SetUnknown(types.StringType)
+		plan.Members = types.MapUnknown(types.StringType)
+		resp.Diagnostics.Append(resp.Plan.Set(ctx, plan)...)
+		return
+	}
+
+	client, diags := l.data.accountClient(ctx, plan.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	dids, diags := l.sourceDids(ctx, client, plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	plan.SubjectDids, diags = types.SetValueFrom(ctx, types.StringType, dids)
+	resp.Diagnostics.Append(diags...)
+
+	plan.Members = types.MapUnknown(types.StringType)
+	if state != nil && !state.Members.IsNull() {
+		var members map[string]string
+		resp.Diagnostics.Append(state.Members.ElementsAs(ctx, &members, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		wanted := map[string]bool{}
+		for _, did := range dids {
+			wanted[did] = true
+		}
+		var removed []string
+		for did := range members {
+			if !wanted[did] {
+				removed = append(removed, did)
+			}
+		}
+		sort.Strings(removed)
+		if !plan.MaxRemovals.IsNull() && int64(len(removed)) > plan.MaxRemovals.ValueInt64() {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("max_removals"),
+				"Too many removals",
+				fmt.Sprintf("The source removes %d accounts from %s, more than max_removals allows (%d): %s. "+
+					"Check the source, or raise max_removals to apply the change.",
+					len(removed), plan.ListUri.ValueString(), plan.MaxRemovals.ValueInt64(), strings.Join(removed, ", ")),
+			)
+			return
+		}
+
+		if plan.SubjectDids.Equal(state.SubjectDids) {
+			plan.Members = state.Members
+		}
+	}
+
+	resp.Diagnostics.Append(resp.Plan.Set(ctx, plan)...)
+}
+
+func (l *listMirrorResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	// Retrieve values from a plan.
+	var plan listMirrorResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
+		return
+	}
+
+	client, diags := l.data.accountClient(ctx, plan.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(checkOwnList(client, plan.ListUri)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(l.apply(ctx, client, &plan, map[string]string{})...)
+
+	// Set the state even when a batch failed, so that the list items written
+	// by the batches before it are tracked.
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Read refreshes the Terraform state with the list items which still exist.
+// Changes of the source show up in the next plan instead.
+func (l *listMirrorResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	// Get current state.
+	var state listMirrorResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, diags := l.data.accountClient(ctx, state.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	members, diags := readListMembers(ctx, client, state.ListUri.ValueString(), state.Members)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.Members, state.SubjectDids, diags = listMembersValues(ctx, members)
+	resp.Diagnostics.Append(diags...)
+	if state.BatchSize.IsNull() {
+		state.BatchSize = types.Int64Value(maxApplyWrites)
+	}
+
+	// Set refreshed state.
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Update adds and removes the list items of the accounts added to and removed
+// from the source.
+func (l *listMirrorResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	// Retrieve values from a plan.
+	var plan listMirrorResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
+		return
+	}
+
+	var state listMirrorResourceModel
+	diags = req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, diags := l.data.accountClient(ctx, plan.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var current map[string]string
+	resp.Diagnostics.Append(state.Members.ElementsAs(ctx, &current, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(l.apply(ctx, client, &plan, current)...)
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+// Delete deletes the list items tracked by the resource and removes the
+// Terraform state on success.
+func (l *listMirrorResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	// Retrieve values from state.
+	var state listMirrorResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, diags := l.data.accountClient(ctx, state.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var current map[string]string
+	resp.Diagnostics.Append(state.Members.ElementsAs(ctx, &current, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	state.SubjectDids = types.SetValueMust(types.StringType, nil)
+	resp.Diagnostics.Append(l.apply(ctx, client, &state, current)...)
+	if resp.Diagnostics.HasError() {
+		// Keep tracking the list items which weren't deleted.
+		diags = resp.State.Set(ctx, state)
+		resp.Diagnostics.Append(diags...)
+	}
+}
+
+// Configure adds the provider configured client to the resource.
+func (l *listMirrorResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Add a nil check when handling ProviderData because Terraform
+	// sets that data after it calls the ConfigureProvider RPC.
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	resp.Diagnostics.Append(data.requireAccount("list mirrors")...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	l.data = data
+}
+
+// ImportState imports every list item of the list with the URI given as ID.
+func (l *listMirrorResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("list_uri"), req, resp)
+}
+
+// apply writes the difference between the current list items, by DID, and the
+// planned subject_dids of model, then sets the members of model to the list
+// items which exist afterwards.
+func (l *listMirrorResource) apply(ctx context.Context, client *xrpc.Client, model *listMirrorResourceModel, current map[string]string) diag.Diagnostics {
+	var desired []string
+	diags := model.SubjectDids.ElementsAs(ctx, &desired, false)
+	if diags.HasError() {
+		return diags
+	}
+
+	members, d := applyListMembers(ctx, client, model.ListUri.ValueString(), desired, model.BatchSize.ValueInt64(), current)
+	diags.Append(d...)
+	var subjectDids types.Set
+	model.Members, subjectDids, d = listMembersValues(ctx, members)
+	diags.Append(d...)
+	if diags.HasError() {
+		// Only list the accounts which made it to the list, so that the next
+		// plan adds the others again.
+		model.SubjectDids = subjectDids
+	}
+	return diags
+}
+
+// sourceDids returns the sorted DIDs of the accounts of the source of model
+// which pass its filters.
+func (l *listMirrorResource) sourceDids(ctx context.Context, client *xrpc.Client, model listMirrorResourceModel) ([]string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	include, d := compilePatterns(ctx, model.Include)
+	diags.Append(d...)
+	exclude, d := compilePatterns(ctx, model.Exclude)
+	diags.Append(d...)
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	var accounts []sourceAccount
+	if !model.SourceListUri.IsNull() {
+		var err error
+		accounts, err = sourceListAccounts(ctx, client, model.SourceListUri.ValueString())
+		if err != nil {
+			diags.AddAttributeError(
+				path.Root("source_list_uri"),
+				"Error reading source list",
+				"Could not read the members of "+model.SourceListUri.ValueString()+": "+err.Error(),
+			)
+			return nil, diags
+		}
+	} else {
+		accounts, d = sourceFileAccounts(ctx, l.data.resolver, model.SourceFile.ValueString())
+		diags.Append(d...)
+		if diags.HasError() {
+			return nil, diags
+		}
+	}
+
+	seen := map[string]bool{}
+	var dids []string
+	for _, account := range accounts {
+		if seen[account.did] {
+			continue
+		}
+		if (len(include) > 0 && !account.matches(include)) || account.matches(exclude) {
+			continue
+		}
+		seen[account.did] = true
+		dids = append(dids, account.did)
+	}
+	sort.Strings(dids)
+	return dids, diags
+}
+
+// matches reports whether the DID or handle of the account matches one of the
+// patterns.
+func (a sourceAccount) matches(patterns []*regexp.Regexp) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(a.did) || a.handle != "" && pattern.MatchString(a.handle) {
+			return true
+		}
+	}
+	return false
+}
+
+// sourceListAccounts returns the accounts on the list, as seen by the AppView.
+func sourceListAccounts(ctx context.Context, client *xrpc.Client, listUri string) ([]sourceAccount, error) {
+	var accounts []sourceAccount
+	cursor := ""
+	for {
+		out, err := bsky.GraphGetList(ctx, client, cursor, listRecordsLimit, listUri)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range out.Items {
+			if item.Subject != nil {
+				accounts = append(accounts, sourceAccount{did: item.Subject.Did, handle: item.Subject.Handle})
+			}
+		}
+		if out.Cursor == nil || *out.Cursor == "" || len(out.Items) == 0 {
+			return accounts, nil
+		}
+		cursor = *out.Cursor
+	}
+}
+
+// sourceFileAccounts returns the accounts listed in the file, resolving
+// handles with resolver.
+func sourceFileAccounts(ctx context.Context, resolver *identityResolver, name string) ([]sourceAccount, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	content, err := os.ReadFile(name)
+	if err != nil {
+		diags.AddAttributeError(
+			path.Root("source_file"),
+			"Error reading source file",
+			"Could not read "+name+": "+err.Error(),
+		)
+		return nil, diags
+	}
+
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var accounts []sourceAccount
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return accounts, diags
+		}
+		if err != nil {
+			diags.AddAttributeError(
+				path.Root("source_file"),
+				"Error reading source file",
+				"Could not read "+name+": "+err.Error(),
+			)
+			return nil, diags
+		}
+
+		line, _ := reader.FieldPos(0)
+		entry := strings.TrimPrefix(strings.TrimSpace(record[0]), "@")
+		if _, err := syntax.ParseDID(entry); err == nil {
+			accounts = append(accounts, sourceAccount{did: entry})
+			continue
+		}
+		handle, err := syntax.ParseHandle(entry)
+		if err != nil {
+			if first {
+				// A header row.
+				continue
+			}
+			diags.AddAttributeError(
+				path.Root("source_file"),
+				"Invalid source file entry",
+				fmt.Sprintf("%s:%d: %q is neither a DID nor a handle.", name, line, entry),
+			)
+			continue
+		}
+		did, err := resolver.resolveHandle(ctx, handle.Normalize().String())
+		if err != nil {
+			diags.AddAttributeError(
+				path.Root("source_file"),
+				"Could not resolve handle",
+				fmt.Sprintf("%s:%d: could not resolve %s: %s", name, line, handle, err),
+			)
+			continue
+		}
+		accounts = append(accounts, sourceAccount{did: did, handle: handle.Normalize().String()})
+	}
+}
+
+// compilePatterns compiles the regular expressions of the set.
+func compilePatterns(ctx context.Context, set types.Set) ([]*regexp.Regexp, diag.Diagnostics) {
+	var expressions []string
+	diags := set.ElementsAs(ctx, &expressions, false)
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	var patterns []*regexp.Regexp
+	for _, expression := range expressions {
+		pattern, err := regexp.Compile(expression)
+		if err != nil {
+			diags.AddError("Invalid regular expression", "Could not compile "+expression+": "+err.Error())
+			continue
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, diags
+}
+
+type regexpValidator struct{}
+
+func (v regexpValidator) Description(_ context.Context) string {
+	return "value must be a valid regular expression"
+}
+
+func (v regexpValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v regexpValidator) ValidateString(_ context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	if _, err := regexp.Compile(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid regular expression",
+			"Could not compile "+req.ConfigValue.ValueString()+": "+err.Error(),
+		)
+	}
+}
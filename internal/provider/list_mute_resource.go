@@ -0,0 +1,204 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bluesky-social/indigo/api/bsky"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ resource.Resource                = &listMuteResource{}
+	_ resource.ResourceWithConfigure   = &listMuteResource{}
+	_ resource.ResourceWithImportState = &listMuteResource{}
+)
+
+// NewListMuteResource is a helper function to simplify the provider implementation.
+func NewListMuteResource() resource.Resource {
+	return &listMuteResource{}
+}
+
+// listMuteResource is the resource implementation. List mutes are private
+// preferences of the account kept by the AppView rather than records, so they
+// are read from the viewer state of the list.
+type listMuteResource struct {
+	data *providerData
+}
+
+type listMuteResourceModel struct {
+	Account types.String `tfsdk:"account"`
+	ListUri types.String `tfsdk:"list_uri"`
+}
+
+// Metadata returns the resource type name.
+func (l *listMuteResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_list_mute"
+}
+
+// Schema defines the schema for the resource.
+func (l *listMuteResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Mute every account on a Bluesky moderation list. Unlike blocks, mutes are private and aren't stored in the repo of the account.",
+		Attributes: map[string]schema.Attribute{
+			"account": accountResourceAttribute(),
+			"list_uri": schema.StringAttribute{
+				MarkdownDescription: "The URI of the moderation list to mute, whose purpose must be `app.bsky.graph.defs#modlist`",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+		},
+	}
+}
+
+func (l *listMuteResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	// Retrieve values from a plan.
+	var plan listMuteResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
+		return
+	}
+
+	client, diags := l.data.accountClient(ctx, plan.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(requireModList(ctx, client, plan.ListUri.ValueString())...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	err := bsky.GraphMuteActorList(ctx, client, &bsky.GraphMuteActorList_Input{List: plan.ListUri.ValueString()})
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error muting list",
+			"Could not mute list "+plan.ListUri.ValueString()+", unexpected error: "+err.Error(),
+		)
+		return
+	}
+
+	// Set state to fully populated data.
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Read refreshes the Terraform state with the latest data.
+func (l *listMuteResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	// Get current state.
+	var state listMuteResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, diags := l.data.accountClient(ctx, state.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	list, err := bsky.GraphGetList(ctx, client, "", 1, state.ListUri.ValueString())
+	if classifyXRPCError(err) == xrpcErrorListNotFound {
+		// The list was deleted, and with it the mute.
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error reading list mute",
+			"Could not read Bluesky list "+state.ListUri.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+	if list.List == nil || list.List.Viewer == nil || list.List.Viewer.Muted == nil || !*list.List.Viewer.Muted {
+		// The list was unmuted outside of Terraform.
+		resp.State.RemoveResource(ctx)
+		return
+	}
+}
+
+// Update only stores the new account of an imported list mute, since every
+// other change replaces the list mute.
+func (l *listMuteResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan listMuteResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+// Delete unmutes the list and removes the Terraform state on success.
+func (l *listMuteResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	// Retrieve values from state.
+	var state listMuteResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, diags := l.data.accountClient(ctx, state.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	err := bsky.GraphUnmuteActorList(ctx, client, &bsky.GraphUnmuteActorList_Input{List: state.ListUri.ValueString()})
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error unmuting list",
+			"Could not unmute list "+state.ListUri.ValueString()+", error: "+err.Error(),
+		)
+	}
+}
+
+// Configure adds the provider configured client to the resource.
+func (l *listMuteResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Add a nil check when handling ProviderData because Terraform
+	// sets that data after it calls the ConfigureProvider RPC.
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	resp.Diagnostics.Append(data.requireAccount("list mutes")...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	l.data = data
+}
+
+// ImportState imports the mute of the list with the URI given as ID.
+func (l *listMuteResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("list_uri"), req, resp)
+}
@@ -34,15 +34,18 @@ func NewListResource() resource.Resource {
 
 // listResource is the resource implementation.
 type listResource struct {
-	client *xrpc.Client
+	data *providerData
 }
 
 type listResourceModel struct {
+	Account     types.String `tfsdk:"account"`
 	Cid         types.String `tfsdk:"cid"`
 	Uri         types.String `tfsdk:"uri"`
 	Name        types.String `tfsdk:"name"`
 	Purpose     types.String `tfsdk:"purpose"`
 	Description types.String `tfsdk:"description"`
+	AvatarFile  types.String `tfsdk:"avatar_file"`
+	AvatarHash  types.String `tfsdk:"avatar_hash"`
 }
 
 // Metadata returns the resource type name.
@@ -55,9 +58,18 @@ func (r *listResource) Schema(_ context.Context, _ resource.SchemaRequest, resp
 	resp.Schema = schema.Schema{
 		MarkdownDescription: "Manage Bluesky moderation and curation lists",
 		Attributes: map[string]schema.Attribute{
+			"account": accountResourceAttribute(),
 			"cid": schema.StringAttribute{
 				Computed:            true,
 				MarkdownDescription: "Commit ID generated by Bluesky",
+				PlanModifiers: []planmodifier.String{
+					recordCidPlanModifier{
+						recordAttributes: []string{"name", "purpose", "description", "avatar_hash"},
+						blobHashes: map[string]blobHashPlanModifier{
+							"avatar_hash": {fileAttribute: "avatar_file", constraints: imageConstraints},
+						},
+					},
+				},
 			},
 			"uri": schema.StringAttribute{
 				Computed: true,
@@ -84,6 +96,8 @@ func (r *listResource) Schema(_ context.Context, _ resource.SchemaRequest, resp
 				Required:            true,
 				MarkdownDescription: "Description of the list",
 			},
+			"avatar_file": blobFileAttribute("Path to the image file uploaded as avatar of the list.", imageConstraints),
+			"avatar_hash": blobHashAttribute("avatar_file", imageConstraints),
 		},
 	}
 }
@@ -97,6 +111,12 @@ func (l *listResource) Create(ctx context.Context, req resource.CreateRequest, r
 		return
 	}
 
+	client, diags := l.data.accountClient(ctx, plan.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	// Generate API request body from plan.
 	list := &bsky.GraphList{
 		Name:        plan.Name.ValueString(),
@@ -104,14 +124,30 @@ func (l *listResource) Create(ctx context.Context, req resource.CreateRequest, r
 		Description: plan.Description.ValueStringPointer(),
 		CreatedAt:   time.Now().Format(time.RFC3339),
 	}
+	avatar := blobField{file: plan.AvatarFile, hash: plan.AvatarHash}
+	avatarBlob, avatarHash, err := avatar.update(ctx, client, nil, blobField{}, imageConstraints)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("avatar_file"),
+			"Failed to upload list avatar",
+			"Could not upload the avatar "+plan.AvatarFile.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+	list.Avatar = avatarBlob
+	plan.AvatarHash = avatarHash
 	createRecordInput := &atproto.RepoCreateRecord_Input{
-		Repo:       l.client.Auth.Did,
+		Repo:       client.Auth.Did,
 		Collection: "app.bsky.graph.list",
 		Record:     &util.LexiconTypeDecoder{Val: list},
 	}
 
 	// Create new list.
-	record, err := atproto.RepoCreateRecord(ctx, l.client, createRecordInput)
+	record, err := atproto.RepoCreateRecord(ctx, client, createRecordInput)
+	if isSwapConflict(err) {
+		resp.Diagnostics.AddError("Repo changed outside of Terraform", swapConflictDetail("The repo "+client.Auth.Did, err))
+		return
+	}
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Error creating list",
@@ -142,8 +178,19 @@ func (l *listResource) Read(ctx context.Context, req resource.ReadRequest, resp
 		return
 	}
 
+	client, diags := l.data.accountClient(ctx, state.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	// Get and parse the list directly using the combined utility function
-	list, record, _, err := GetListFromURI(ctx, l.client, state.Uri.ValueString())
+	list, record, _, err := GetListFromURI(ctx, client, state.Uri.ValueString())
+	if isRecordNotFound(err) {
+		// The list was deleted outside of Terraform.
+		resp.State.RemoveResource(ctx)
+		return
+	}
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Error reading list",
@@ -158,6 +205,10 @@ func (l *listResource) Read(ctx context.Context, req resource.ReadRequest, resp
 	state.Name = types.StringValue(list.Name)
 	state.Purpose = types.StringValue(*list.Purpose)
 	state.Description = types.StringValue(*list.Description)
+	// An avatar removed outside of Terraform is uploaded again.
+	if list.Avatar == nil {
+		state.AvatarHash = types.StringNull()
+	}
 
 	// Set refreshed state.
 	diags = resp.State.Set(ctx, &state)
@@ -177,8 +228,21 @@ func (l *listResource) Update(ctx context.Context, req resource.UpdateRequest, r
 		return
 	}
 
+	var state listResourceModel
+	diags = req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, diags := l.data.accountClient(ctx, plan.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	// Get the current list, record and parsed URI using the combined utility function
-	list, record, parsedUri, err := GetListFromURI(ctx, l.client, plan.Uri.ValueString())
+	list, _, parsedUri, err := GetListFromURI(ctx, client, plan.Uri.ValueString())
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Failed to retrieve list",
@@ -191,17 +255,32 @@ func (l *listResource) Update(ctx context.Context, req resource.UpdateRequest, r
 	list.Purpose = plan.Purpose.ValueStringPointer()
 	list.Description = plan.Description.ValueStringPointer()
 
+	avatar := blobField{file: plan.AvatarFile, hash: plan.AvatarHash}
+	list.Avatar, plan.AvatarHash, err = avatar.update(ctx, client, list.Avatar, blobField{file: state.AvatarFile, hash: state.AvatarHash}, imageConstraints)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("avatar_file"),
+			"Failed to upload list avatar",
+			"Could not upload the avatar "+plan.AvatarFile.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+
 	// Update existing list using the parsed URI
 	putRecordInput := &atproto.RepoPutRecord_Input{
 		Collection: parsedUri.Collection().String(),
 		Repo:       parsedUri.Authority().String(),
 		Rkey:       parsedUri.RecordKey().String(),
-		SwapRecord: record.Cid,
+		SwapRecord: swapRecord(state.Cid),
 		Record: &util.LexiconTypeDecoder{
 			Val: list,
 		},
 	}
-	updatedRecord, err := atproto.RepoPutRecord(ctx, l.client, putRecordInput)
+	updatedRecord, err := atproto.RepoPutRecord(ctx, client, putRecordInput)
+	if isSwapConflict(err) {
+		resp.Diagnostics.AddError("List changed outside of Terraform", swapConflictDetail("The list "+plan.Uri.ValueString(), err))
+		return
+	}
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Failed to update list",
@@ -230,6 +309,12 @@ func (l *listResource) Delete(ctx context.Context, req resource.DeleteRequest, r
 		return
 	}
 
+	client, diags := l.data.accountClient(ctx, state.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	// Parse the URI to extract components for the repository API
 	uri, err := syntax.ParseATURI(state.Uri.ValueString())
 	if err != nil {
@@ -243,8 +328,13 @@ func (l *listResource) Delete(ctx context.Context, req resource.DeleteRequest, r
 		Collection: uri.Collection().String(),
 		Repo:       uri.Authority().String(),
 		Rkey:       uri.RecordKey().String(),
+		SwapRecord: swapRecord(state.Cid),
+	}
+	_, err = atproto.RepoDeleteRecord(ctx, client, deleteRequest)
+	if isSwapConflict(err) {
+		resp.Diagnostics.AddError("List changed outside of Terraform", swapConflictDetail("The list "+state.Uri.ValueString(), err))
+		return
 	}
-	_, err = atproto.RepoDeleteRecord(ctx, l.client, deleteRequest)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Error deleting list",
@@ -261,18 +351,23 @@ func (l *listResource) Configure(_ context.Context, req resource.ConfigureReques
 		return
 	}
 
-	client, ok := req.ProviderData.(*xrpc.Client)
+	data, ok := req.ProviderData.(*providerData)
 
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Data Source Configure Type",
-			fmt.Sprintf("Expected *xrpc.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+			fmt.Sprintf("Expected *providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
 		)
 
 		return
 	}
 
-	l.client = client
+	resp.Diagnostics.Append(data.requireAccount("lists")...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	l.data = data
 }
 
 func (l *listResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
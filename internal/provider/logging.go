@@ -0,0 +1,244 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	// xrpcLogSubsystem is the tflog subsystem XRPC requests are traced in.
+	// Its level can be set separately with TF_LOG_PROVIDER_BSKY_XRPC.
+	xrpcLogSubsystem = "xrpc"
+
+	// maxLoggedBodySize is the size up to which bodies are logged.
+	maxLoggedBodySize = 64 << 10
+)
+
+// jwtPattern matches JWTs, such as access and refresh tokens and DPoP proofs.
+var jwtPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]*\.[A-Za-z0-9_-]*`)
+
+// redactedKeys are the keys of JSON and form bodies whose values are never
+// logged.
+var redactedKeys = map[string]bool{
+	"password":         true,
+	"accessJwt":        true,
+	"refreshJwt":       true,
+	"authFactorToken":  true,
+	"token":            true,
+	"access_token":     true,
+	"refresh_token":    true,
+	"client_assertion": true,
+	"plcOp":            true,
+	"recoveryKey":      true,
+	"inviteCode":       true,
+	"code":             true,
+	"code_verifier":    true,
+}
+
+// loggingTransport traces every attempt of an XRPC request in the xrpc tflog
+// subsystem: the method, status, latency and rate limit headers at debug
+// level, and the redacted bodies at trace level.
+type loggingTransport struct {
+	base http.RoundTripper
+
+	// secrets are masked wherever they show up in the log, like the
+	// bluesky_password field of Configure.
+	secrets []string
+}
+
+func newLoggingTransport(base http.RoundTripper, secrets []string) *loggingTransport {
+	var nonEmpty []string
+	for _, secret := range secrets {
+		if secret != "" {
+			nonEmpty = append(nonEmpty, secret)
+		}
+	}
+	return &loggingTransport{
+		base:    base,
+		secrets: nonEmpty,
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := t.logContext(req.Context())
+
+	// Requests which aren't XRPC calls, like the OAuth token requests, are
+	// logged by their path.
+	method := xrpcMethod(req)
+	if method == "" {
+		method = req.URL.Path
+	}
+	fields := map[string]any{
+		"xrpc_method": method,
+		"http_method": req.Method,
+		"url":         req.URL.Redacted(),
+	}
+
+	// Bodies are only read when they can show up in the log.
+	trace := xrpcTraceEnabled()
+	if trace {
+		body, err := readRequestBody(req)
+		if err != nil {
+			return nil, err
+		}
+		if body != nil {
+			req = req.Clone(req.Context())
+			req.Body = io.NopCloser(bytes.NewReader(body))
+			req.GetBody = func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(body)), nil
+			}
+			req.ContentLength = int64(len(body))
+		}
+		tflog.SubsystemTrace(ctx, xrpcLogSubsystem, "Sending XRPC request", mergeFields(fields, map[string]any{
+			"request_body": redactBody(req.Header.Get("Content-Type"), body),
+		}))
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	fields["latency"] = time.Since(start).String()
+	if err != nil {
+		tflog.SubsystemDebug(ctx, xrpcLogSubsystem, "XRPC request failed", mergeFields(fields, map[string]any{
+			"error": err.Error(),
+		}))
+		return nil, err
+	}
+
+	fields["status"] = resp.StatusCode
+	for _, header := range []string{"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "Retry-After"} {
+		if value := resp.Header.Get(header); value != "" {
+			fields[strings.ToLower(strings.ReplaceAll(header, "-", "_"))] = value
+		}
+	}
+	tflog.SubsystemDebug(ctx, xrpcLogSubsystem, "Received XRPC response", fields)
+
+	if trace && isLoggableBody(resp.Header.Get("Content-Type"), resp.ContentLength) {
+		// Bodies of unknown length are read up to one byte past the limit,
+		// which tells redactBody that they are too large to log.
+		respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxLoggedBodySize+1))
+		if err != nil {
+			_ = resp.Body.Close()
+			return nil, fmt.Errorf("could not read response body: %w", err)
+		}
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(respBody), resp.Body), resp.Body}
+		tflog.SubsystemTrace(ctx, xrpcLogSubsystem, "XRPC response body", mergeFields(fields, map[string]any{
+			"response_body": redactBody(resp.Header.Get("Content-Type"), respBody),
+		}))
+	}
+
+	return resp, nil
+}
+
+// logContext sets up the xrpc subsystem on the context of a request, masking
+// tokens and the configured secrets.
+func (t *loggingTransport) logContext(ctx context.Context) context.Context {
+	ctx = tflog.NewSubsystem(ctx, xrpcLogSubsystem, tflog.WithLevelFromEnv("TF_LOG_PROVIDER_BSKY_XRPC"))
+	ctx = tflog.SubsystemMaskAllFieldValuesRegexes(ctx, xrpcLogSubsystem, jwtPattern)
+	if len(t.secrets) > 0 {
+		ctx = tflog.SubsystemMaskAllFieldValuesStrings(ctx, xrpcLogSubsystem, t.secrets...)
+	}
+	return ctx
+}
+
+// xrpcTraceEnabled reports whether messages at trace level in the xrpc
+// subsystem can show up in the log. tflog doesn't expose the level of its
+// loggers, so it is derived from the environment variables the levels are set
+// from, the most specific first. Acceptance tests writing to TF_ACC_LOG_PATH
+// log at trace level by default.
+func xrpcTraceEnabled() bool {
+	for _, name := range []string{"TF_LOG_PROVIDER_BSKY_XRPC", "TF_LOG_PROVIDER_BSKY", "TF_LOG_PROVIDER", "TF_LOG"} {
+		if level := strings.ToUpper(os.Getenv(name)); level != "" {
+			return level == "TRACE" || level == "JSON"
+		}
+	}
+	return os.Getenv("TF_ACC_LOG_PATH") != ""
+}
+
+// isLoggableBody reports whether a response body is structured data worth
+// logging. Blobs and other binary content are never read, and neither are
+// bodies known to be too large to log.
+func isLoggableBody(contentType string, contentLength int64) bool {
+	if contentLength > maxLoggedBodySize {
+		return false
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "application/json" || mediaType == "application/x-www-form-urlencoded"
+}
+
+// redactBody returns the body for logging, with the values of redactedKeys
+// replaced. Bodies which aren't JSON or form data are only described.
+func redactBody(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	switch {
+	case len(body) > maxLoggedBodySize:
+		return fmt.Sprintf("<more than %d bytes of %s>", maxLoggedBodySize, contentType)
+	case mediaType == "application/json":
+		var value any
+		if err := json.Unmarshal(body, &value); err == nil {
+			redacted, err := json.Marshal(redactValue(value))
+			if err == nil {
+				return string(redacted)
+			}
+		}
+	case mediaType == "application/x-www-form-urlencoded":
+		if values, err := url.ParseQuery(string(body)); err == nil {
+			for key := range values {
+				if redactedKeys[key] {
+					values.Set(key, "***")
+				}
+			}
+			return values.Encode()
+		}
+	}
+
+	return fmt.Sprintf("<%d bytes of %s>", len(body), contentType)
+}
+
+func redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			if redactedKeys[key] {
+				v[key] = "***"
+			} else {
+				v[key] = redactValue(item)
+			}
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+	}
+	return value
+}
+
+func mergeFields(fields map[string]any, extra map[string]any) map[string]any {
+	merged := make(map[string]any, len(fields)+len(extra))
+	for key, value := range fields {
+		merged[key] = value
+	}
+	for key, value := range extra {
+		merged[key] = value
+	}
+	return merged
+}
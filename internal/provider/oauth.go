@@ -0,0 +1,656 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// oauthConfig holds the settings of the OAuth authentication mode.
+type oauthConfig struct {
+	// clientID is the URL of the client metadata document.
+	clientID string
+	// clientKey signs the client assertion of confidential clients. Public
+	// clients leave it nil.
+	clientKey   *ecdsa.PrivateKey
+	clientKeyID string
+	// authorizationServer is the issuer URL of the authorization server. When
+	// empty, it is discovered from the PDS.
+	authorizationServer string
+	// tokenFile is rewritten with the rotated refresh token after every
+	// refresh, when set.
+	tokenFile    string
+	refreshToken string
+	// dpopKey is the key the tokens are bound to.
+	dpopKey *ecdsa.PrivateKey
+}
+
+// oauthTokenFile is the format of the token file read and written by the
+// OAuth authentication mode.
+type oauthTokenFile struct {
+	RefreshToken string `json:"refresh_token"`
+	DPoPKey      string `json:"dpop_key"`
+}
+
+type oauthTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token"`
+	Sub          string `json:"sub"`
+	Scope        string `json:"scope"`
+}
+
+type oauthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// oauthSession authenticates the shared xrpc.Client with DPoP-bound OAuth
+// tokens. Like sessionManager it is installed as the transport of the
+// client's http.Client, and refreshes the tokens once under a lock when the
+// PDS rejects the access token.
+type oauthSession struct {
+	pdsHost string
+	config  oauthConfig
+	base    *http.Client
+
+	issuer        string
+	tokenEndpoint string
+
+	mu           sync.RWMutex
+	accessToken  string
+	refreshToken string
+	did          string
+	handle       string
+
+	// DPoP nonces handed out by the authorization server and the PDS, by
+	// origin.
+	nonceMu sync.Mutex
+	nonces  map[string]string
+}
+
+var _ authSession = &oauthSession{}
+
+func newOAuthSession(pdsHost string, config oauthConfig, base *http.Client) *oauthSession {
+	return &oauthSession{
+		pdsHost:      pdsHost,
+		config:       config,
+		base:         base,
+		refreshToken: config.refreshToken,
+		nonces:       map[string]string{},
+	}
+}
+
+// login exchanges the refresh token for a new access token, then looks up the
+// handle of the account the tokens were issued for.
+func (s *oauthSession) login(ctx context.Context) error {
+	if err := s.discover(ctx); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	err := s.requestTokens(ctx)
+	did := s.did
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	client := &xrpc.Client{
+		Host:   s.pdsHost,
+		Client: s.httpClient(),
+		Auth:   &xrpc.AuthInfo{Did: did},
+	}
+	session, err := atproto.ServerGetSession(ctx, client)
+	if err != nil {
+		return fmt.Errorf("could not get OAuth session: %w", err)
+	}
+	if session.Did != did {
+		return fmt.Errorf("OAuth session is for %s, but the tokens were issued for %s", session.Did, did)
+	}
+
+	s.mu.Lock()
+	s.handle = session.Handle
+	s.mu.Unlock()
+
+	return nil
+}
+
+// discover looks up the token endpoint of the authorization server protecting
+// the PDS.
+func (s *oauthSession) discover(ctx context.Context) error {
+	issuer := s.config.authorizationServer
+	if issuer == "" {
+		var resource struct {
+			AuthorizationServers []string `json:"authorization_servers"`
+		}
+		if err := s.getJSON(ctx, strings.TrimSuffix(s.pdsHost, "/")+"/.well-known/oauth-protected-resource", &resource); err != nil {
+			return fmt.Errorf("could not discover the authorization server of %s: %w", s.pdsHost, err)
+		}
+		if len(resource.AuthorizationServers) == 0 {
+			return fmt.Errorf("%s does not advertise an authorization server", s.pdsHost)
+		}
+		issuer = resource.AuthorizationServers[0]
+	}
+	issuer = strings.TrimSuffix(issuer, "/")
+
+	var metadata struct {
+		Issuer        string `json:"issuer"`
+		TokenEndpoint string `json:"token_endpoint"`
+	}
+	if err := s.getJSON(ctx, issuer+"/.well-known/oauth-authorization-server", &metadata); err != nil {
+		return fmt.Errorf("could not get the metadata of authorization server %s: %w", issuer, err)
+	}
+	if strings.TrimSuffix(metadata.Issuer, "/") != issuer {
+		return fmt.Errorf("authorization server metadata has issuer %s, expected %s", metadata.Issuer, issuer)
+	}
+	if metadata.TokenEndpoint == "" {
+		return fmt.Errorf("authorization server %s does not advertise a token endpoint", issuer)
+	}
+
+	s.issuer = metadata.Issuer
+	s.tokenEndpoint = metadata.TokenEndpoint
+	return nil
+}
+
+func (s *oauthSession) getJSON(ctx context.Context, url string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.base.Do(req)
+	if err != nil {
+		return err
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned HTTP %d", url, resp.StatusCode)
+	}
+	return json.Unmarshal(body, out)
+}
+
+// requestTokens redeems the refresh token at the token endpoint. The caller
+// must hold the write lock.
+func (s *oauthSession) requestTokens(ctx context.Context) error {
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {s.refreshToken},
+		"client_id":     {s.config.clientID},
+	}
+
+	// The first attempt may be rejected because the authorization server
+	// requires a DPoP nonce that we don't know yet.
+	for attempt := 0; ; attempt++ {
+		if s.config.clientKey != nil {
+			assertion, err := s.clientAssertion()
+			if err != nil {
+				return err
+			}
+			form.Set("client_assertion_type", "urn:ietf:params:oauth:client-assertion-type:jwt-bearer")
+			form.Set("client_assertion", assertion)
+		}
+		proof, err := s.dpopProof(http.MethodPost, s.tokenEndpoint, "")
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.tokenEndpoint, strings.NewReader(form.Encode()))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("DPoP", proof)
+
+		resp, err := s.base.Do(req)
+		if err != nil {
+			return fmt.Errorf("token request failed: %w", err)
+		}
+		s.storeNonce(req.URL, resp)
+		body, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("could not read token response: %w", err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			var oauthErr oauthErrorResponse
+			_ = json.Unmarshal(body, &oauthErr)
+			if oauthErr.Error == "use_dpop_nonce" && attempt == 0 {
+				continue
+			}
+			if oauthErr.Error != "" {
+				return fmt.Errorf("token request rejected with %s: %s", oauthErr.Error, oauthErr.ErrorDescription)
+			}
+			return fmt.Errorf("token request returned HTTP %d", resp.StatusCode)
+		}
+
+		var tokens oauthTokenResponse
+		if err := json.Unmarshal(body, &tokens); err != nil {
+			return fmt.Errorf("could not decode token response: %w", err)
+		}
+		if !strings.EqualFold(tokens.TokenType, "DPoP") {
+			return fmt.Errorf("expected a DPoP token, got token type %q", tokens.TokenType)
+		}
+		if !strings.HasPrefix(tokens.Sub, "did:") {
+			return fmt.Errorf("token response subject %q is not a DID", tokens.Sub)
+		}
+		if s.did != "" && tokens.Sub != s.did {
+			return fmt.Errorf("tokens were issued for %s instead of %s", tokens.Sub, s.did)
+		}
+
+		s.accessToken = tokens.AccessToken
+		s.did = tokens.Sub
+		if tokens.RefreshToken != "" {
+			s.refreshToken = tokens.RefreshToken
+			if err := s.saveTokenFile(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// saveTokenFile persists the rotated refresh token, since the one used for
+// this request can't be redeemed again. The caller must hold the write lock.
+func (s *oauthSession) saveTokenFile(ctx context.Context) error {
+	if s.config.tokenFile == "" {
+		tflog.Warn(ctx, "The OAuth refresh token was rotated but no token file is configured, the configured refresh token can't be used again")
+		return nil
+	}
+
+	dpopKey, err := encodeECPrivateKey(s.config.dpopKey)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(oauthTokenFile{
+		RefreshToken: s.refreshToken,
+		DPoPKey:      dpopKey,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(s.config.tokenFile, data); err != nil {
+		return fmt.Errorf("could not update OAuth token file: %w", err)
+	}
+	return nil
+}
+
+// refresh replaces the access token that was rejected by the PDS, unless
+// another request already did so while this one was waiting for the lock.
+func (s *oauthSession) refresh(ctx context.Context, rejectedAccessToken string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.accessToken != rejectedAccessToken {
+		return nil
+	}
+
+	tflog.Debug(ctx, "Refreshing Bluesky OAuth session")
+
+	return s.requestTokens(ctx)
+}
+
+func (s *oauthSession) authInfo() *xrpc.AuthInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return &xrpc.AuthInfo{
+		Did:    s.did,
+		Handle: s.handle,
+	}
+}
+
+func (s *oauthSession) pdsEndpoint() string {
+	return s.pdsHost
+}
+
+func (s *oauthSession) currentAccessToken() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.accessToken
+}
+
+func (s *oauthSession) httpClient() *http.Client {
+	httpClient := *s.base
+	httpClient.Transport = s
+	return &httpClient
+}
+
+// RoundTrip implements http.RoundTripper. Requests authenticated with a
+// bearer token by the xrpc client are sent with the DPoP access token and a
+// proof instead. Requests with any other authentication are passed through
+// untouched.
+func (s *oauthSession) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !strings.HasPrefix(req.Header.Get("Authorization"), "Bearer ") {
+		return s.transport().RoundTrip(req)
+	}
+
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken := s.currentAccessToken()
+	refreshed := false
+	for nonceRetries := 0; ; {
+		resp, err := s.send(req, body, accessToken)
+		if err != nil {
+			return nil, err
+		}
+
+		switch dpopErrorFromResponse(resp) {
+		case "use_dpop_nonce":
+			// send stored the nonce handed out with the response. The nonce
+			// can also change when the authorization server shares its origin
+			// with the PDS and the tokens were refreshed in the meantime.
+			if nonceRetries < 2 {
+				nonceRetries++
+				_ = resp.Body.Close()
+				continue
+			}
+		case "invalid_token", "InvalidToken", "ExpiredToken":
+			if !refreshed {
+				refreshed = true
+				_ = resp.Body.Close()
+				if err := s.refresh(req.Context(), accessToken); err != nil {
+					return nil, fmt.Errorf("could not refresh Bluesky OAuth session: %w", err)
+				}
+				accessToken = s.currentAccessToken()
+				continue
+			}
+		}
+		return resp, nil
+	}
+}
+
+func (s *oauthSession) send(req *http.Request, body []byte, accessToken string) (*http.Response, error) {
+	proof, err := s.dpopProof(req.Method, req.URL.String(), accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	r := req.Clone(req.Context())
+	r.Header.Set("Authorization", "DPoP "+accessToken)
+	r.Header.Set("DPoP", proof)
+	if body != nil {
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+		r.ContentLength = int64(len(body))
+	}
+
+	resp, err := s.transport().RoundTrip(r)
+	if err != nil {
+		return nil, err
+	}
+	s.storeNonce(r.URL, resp)
+	return resp, nil
+}
+
+func (s *oauthSession) transport() http.RoundTripper {
+	if s.base.Transport == nil {
+		return http.DefaultTransport
+	}
+	return s.base.Transport
+}
+
+func (s *oauthSession) storeNonce(u *url.URL, resp *http.Response) {
+	nonce := resp.Header.Get("DPoP-Nonce")
+	if nonce == "" {
+		return
+	}
+
+	s.nonceMu.Lock()
+	defer s.nonceMu.Unlock()
+	s.nonces[u.Scheme+"://"+u.Host] = nonce
+}
+
+func (s *oauthSession) nonce(u *url.URL) string {
+	s.nonceMu.Lock()
+	defer s.nonceMu.Unlock()
+	return s.nonces[u.Scheme+"://"+u.Host]
+}
+
+// dpopProof creates the DPoP proof for a request. The access token hash is
+// only included for requests to the PDS.
+func (s *oauthSession) dpopProof(method string, target string, accessToken string) (string, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", err
+	}
+
+	claims := map[string]any{
+		"jti": randomJTI(),
+		"htm": method,
+		"htu": u.Scheme + "://" + u.Host + u.Path,
+		"iat": time.Now().Unix(),
+	}
+	if nonce := s.nonce(u); nonce != "" {
+		claims["nonce"] = nonce
+	}
+	if accessToken != "" {
+		hash := sha256.Sum256([]byte(accessToken))
+		claims["ath"] = base64.RawURLEncoding.EncodeToString(hash[:])
+	}
+
+	jwk, err := ecPublicJWK(s.config.dpopKey)
+	if err != nil {
+		return "", err
+	}
+	return signES256JWT(s.config.dpopKey, map[string]any{
+		"typ": "dpop+jwt",
+		"alg": "ES256",
+		"jwk": jwk,
+	}, claims)
+}
+
+// clientAssertion authenticates a confidential client at the token endpoint
+// (private_key_jwt).
+func (s *oauthSession) clientAssertion() (string, error) {
+	header := map[string]any{
+		"alg": "ES256",
+	}
+	if s.config.clientKeyID != "" {
+		header["kid"] = s.config.clientKeyID
+	}
+	now := time.Now()
+	return signES256JWT(s.config.clientKey, header, map[string]any{
+		"iss": s.config.clientID,
+		"sub": s.config.clientID,
+		"aud": s.issuer,
+		"jti": randomJTI(),
+		"iat": now.Unix(),
+		"exp": now.Add(time.Minute).Unix(),
+	})
+}
+
+// dpopErrorFromResponse returns the error code of a 401 response from the
+// PDS, from the WWW-Authenticate header or the XRPC error body. The response
+// body is restored so that it can still be decoded by the xrpc client.
+func dpopErrorFromResponse(resp *http.Response) string {
+	if resp.StatusCode != http.StatusUnauthorized && resp.StatusCode != http.StatusBadRequest {
+		return ""
+	}
+
+	if challenge := resp.Header.Get("WWW-Authenticate"); challenge != "" {
+		if _, rest, found := strings.Cut(challenge, `error="`); found {
+			if code, _, found := strings.Cut(rest, `"`); found {
+				return code
+			}
+		}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+	var xrpcErr xrpc.XRPCError
+	if err := json.Unmarshal(body, &xrpcErr); err != nil {
+		return ""
+	}
+	return xrpcErr.ErrStr
+}
+
+// loadOAuthTokenFile reads the refresh token and DPoP key from a token file.
+// A missing file is not an error, so that the file can be created from a
+// refresh token configured inline.
+func loadOAuthTokenFile(path string) (*oauthTokenFile, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &oauthTokenFile{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var tokenFile oauthTokenFile
+	if err := json.Unmarshal(data, &tokenFile); err != nil {
+		return nil, fmt.Errorf("could not decode %s: %w", path, err)
+	}
+	return &tokenFile, nil
+}
+
+// parseECPrivateKey parses a PEM encoded P-256 private key in SEC 1 or PKCS #8
+// form.
+func parseECPrivateKey(data string) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found")
+	}
+
+	var key *ecdsa.PrivateKey
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		parsed, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key = parsed
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		ecKey, ok := parsed.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("expected an EC private key, got %T", parsed)
+		}
+		key = ecKey
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+
+	if key.Curve != elliptic.P256() {
+		return nil, fmt.Errorf("expected a P-256 key, got %s", key.Curve.Params().Name)
+	}
+	return key, nil
+}
+
+func encodeECPrivateKey(key *ecdsa.PrivateKey) (string, error) {
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})), nil
+}
+
+func ecPublicJWK(key *ecdsa.PrivateKey) (map[string]any, error) {
+	publicKey, err := key.PublicKey.ECDH()
+	if err != nil {
+		return nil, err
+	}
+	// Uncompressed point: 0x04 || X || Y
+	point := publicKey.Bytes()
+	return map[string]any{
+		"kty": "EC",
+		"crv": "P-256",
+		"x":   base64.RawURLEncoding.EncodeToString(point[1:33]),
+		"y":   base64.RawURLEncoding.EncodeToString(point[33:]),
+	}, nil
+}
+
+func signES256JWT(key *ecdsa.PrivateKey, header map[string]any, claims map[string]any) (string, error) {
+	encodedHeader, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	encodedClaims, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(encodedHeader) + "." + base64.RawURLEncoding.EncodeToString(encodedClaims)
+
+	hash := sha256.Sum256([]byte(signingInput))
+	r, sig, err := ecdsa.Sign(rand.Reader, key, hash[:])
+	if err != nil {
+		return "", err
+	}
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	sig.FillBytes(signature[32:])
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func randomJTI() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// writeFileAtomic replaces the file at path with data, readable only by the
+// current user.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o600); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,223 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"strings"
+
+	"github.com/bluesky-social/indigo/api/bsky"
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/objectvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/objectplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// maxPostImages is the number of images a post can embed.
+const maxPostImages = 4
+
+type postImageModel struct {
+	File types.String `tfsdk:"file"`
+	Hash types.String `tfsdk:"hash"`
+	Alt  types.String `tfsdk:"alt"`
+}
+
+type postExternalModel struct {
+	Uri           types.String `tfsdk:"uri"`
+	Title         types.String `tfsdk:"title"`
+	Description   types.String `tfsdk:"description"`
+	ThumbnailFile types.String `tfsdk:"thumbnail_file"`
+	ThumbnailHash types.String `tfsdk:"thumbnail_hash"`
+}
+
+// postEmbedAttributes returns the attributes of the embeds of a post. Like the
+// rest of the post they can't be changed, and changing the contents of a file
+// replaces the post as well.
+func postEmbedAttributes() map[string]schema.Attribute {
+	return map[string]schema.Attribute{
+		"images": schema.ListNestedAttribute{
+			MarkdownDescription: fmt.Sprintf("Images embedded in the post, at most %d. Conflicts with `external`.", maxPostImages),
+			Optional:            true,
+			Validators: []validator.List{
+				listvalidator.SizeBetween(1, maxPostImages),
+				listvalidator.ConflictsWith(path.MatchRoot("external")),
+			},
+			PlanModifiers: []planmodifier.List{
+				listplanmodifier.RequiresReplace(),
+			},
+			NestedObject: schema.NestedAttributeObject{
+				Attributes: map[string]schema.Attribute{
+					"file": schema.StringAttribute{
+						MarkdownDescription: fmt.Sprintf("Path to the image file. Must be %s of at most %d bytes. The aspect ratio is taken from the image.",
+							strings.Join(postImageConstraints.accept, ", "), postImageConstraints.maxSize),
+						Required: true,
+					},
+					"hash": blobHashAttribute("file", postImageConstraints, stringplanmodifier.RequiresReplace()),
+					"alt": schema.StringAttribute{
+						MarkdownDescription: "Alt text describing the image for people who can't see it",
+						Required:            true,
+						Validators: []validator.String{
+							stringvalidator.LengthAtLeast(1),
+						},
+					},
+				},
+			},
+		},
+		"external": schema.SingleNestedAttribute{
+			MarkdownDescription: "Link card embedded in the post. Conflicts with `images`.",
+			Optional:            true,
+			Validators: []validator.Object{
+				objectvalidator.ConflictsWith(path.MatchRoot("images")),
+			},
+			PlanModifiers: []planmodifier.Object{
+				objectplanmodifier.RequiresReplace(),
+			},
+			Attributes: map[string]schema.Attribute{
+				"uri": schema.StringAttribute{
+					MarkdownDescription: "URL the card links to",
+					Required:            true,
+				},
+				"title": schema.StringAttribute{
+					MarkdownDescription: "Title of the card",
+					Required:            true,
+				},
+				"description": schema.StringAttribute{
+					MarkdownDescription: "Description of the card",
+					Optional:            true,
+				},
+				"thumbnail_file": schema.StringAttribute{
+					MarkdownDescription: fmt.Sprintf("Path to the thumbnail image of the card. Must be %s of at most %d bytes.",
+						strings.Join(imageConstraints.accept, " or "), imageConstraints.maxSize),
+					Optional: true,
+				},
+				"thumbnail_hash": blobHashAttribute("thumbnail_file", imageConstraints, stringplanmodifier.RequiresReplace()),
+			},
+		},
+		"quote_uri": schema.StringAttribute{
+			MarkdownDescription: "Atproto URI of the post, or other record, quoted by the post. Can be combined with `images` or `external`.",
+			Optional:            true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.RequiresReplace(),
+			},
+		},
+	}
+}
+
+// buildPostEmbed uploads the files of the embeds of plan and returns the embed
+// of the post, or nil if it has none. The hashes of the uploaded files are set
+// in plan.
+func buildPostEmbed(ctx context.Context, client *xrpc.Client, plan *postResourceModel) (*bsky.FeedPost_Embed, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	var media bsky.EmbedRecordWithMedia_Media
+	if !plan.Images.IsNull() {
+		var images []postImageModel
+		diags.Append(plan.Images.ElementsAs(ctx, &images, false)...)
+		if diags.HasError() {
+			return nil, diags
+		}
+
+		embed := &bsky.EmbedImages{}
+		for i := range images {
+			attribute := path.Root("images").AtListIndex(i).AtName("file")
+			file, err := readBlobFile(images[i].File.ValueString(), postImageConstraints)
+			if err != nil {
+				diags.AddAttributeError(attribute, "Invalid image file", "Could not use "+images[i].File.ValueString()+" as image: "+err.Error())
+				return nil, diags
+			}
+			blob, err := file.upload(ctx, client)
+			if err != nil {
+				diags.AddAttributeError(attribute, "Failed to upload image", "Could not upload the image "+images[i].File.ValueString()+": "+err.Error())
+				return nil, diags
+			}
+			images[i].Hash = types.StringValue(file.hash)
+			embed.Images = append(embed.Images, &bsky.EmbedImages_Image{
+				Alt:         images[i].Alt.ValueString(),
+				Image:       blob,
+				AspectRatio: imageAspectRatio(file.data),
+			})
+		}
+		media.EmbedImages = embed
+
+		var d diag.Diagnostics
+		plan.Images, d = types.ListValueFrom(ctx, plan.Images.ElementType(ctx), images)
+		diags.Append(d...)
+	}
+
+	if plan.External != nil {
+		external := &bsky.EmbedExternal_External{
+			Uri:         plan.External.Uri.ValueString(),
+			Title:       plan.External.Title.ValueString(),
+			Description: plan.External.Description.ValueString(),
+		}
+		thumbnail := blobField{file: plan.External.ThumbnailFile, hash: plan.External.ThumbnailHash}
+		var err error
+		external.Thumb, plan.External.ThumbnailHash, err = thumbnail.update(ctx, client, nil, blobField{}, imageConstraints)
+		if err != nil {
+			diags.AddAttributeError(
+				path.Root("external").AtName("thumbnail_file"),
+				"Failed to upload thumbnail",
+				"Could not upload the thumbnail "+plan.External.ThumbnailFile.ValueString()+": "+err.Error(),
+			)
+			return nil, diags
+		}
+		media.EmbedExternal = &bsky.EmbedExternal{External: external}
+	}
+
+	hasMedia := media.EmbedImages != nil || media.EmbedExternal != nil
+	if plan.QuoteUri.IsNull() {
+		if !hasMedia {
+			return nil, diags
+		}
+		return &bsky.FeedPost_Embed{
+			EmbedImages:   media.EmbedImages,
+			EmbedExternal: media.EmbedExternal,
+		}, diags
+	}
+
+	quoted, err := getStrongRef(ctx, client, plan.QuoteUri.ValueString())
+	if err != nil {
+		diags.AddAttributeError(
+			path.Root("quote_uri"),
+			"Invalid quoted post",
+			"Could not get the quoted record "+plan.QuoteUri.ValueString()+": "+err.Error(),
+		)
+		return nil, diags
+	}
+	record := &bsky.EmbedRecord{Record: quoted}
+	if !hasMedia {
+		return &bsky.FeedPost_Embed{EmbedRecord: record}, diags
+	}
+	return &bsky.FeedPost_Embed{
+		EmbedRecordWithMedia: &bsky.EmbedRecordWithMedia{
+			Media:  &media,
+			Record: record,
+		},
+	}, diags
+}
+
+// imageAspectRatio returns the aspect ratio of an image, or nil if the format
+// can't be decoded.
+func imageAspectRatio(data []byte) *bsky.EmbedDefs_AspectRatio {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || config.Width == 0 || config.Height == 0 {
+		return nil
+	}
+	return &bsky.EmbedDefs_AspectRatio{
+		Width:  int64(config.Width),
+		Height: int64(config.Height),
+	}
+}
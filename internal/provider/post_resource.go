@@ -0,0 +1,441 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/apparentlymart/go-textseg/v15/textseg"
+	"github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/api/bsky"
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/bluesky-social/indigo/lex/util"
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/setvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/setplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const postCollection = "app.bsky.feed.post"
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ resource.Resource                = &postResource{}
+	_ resource.ResourceWithConfigure   = &postResource{}
+	_ resource.ResourceWithImportState = &postResource{}
+)
+
+// NewPostResource is a helper function to simplify the provider implementation.
+func NewPostResource() resource.Resource {
+	return &postResource{}
+}
+
+// postResource is the resource implementation. Posts aren't meant to be
+// edited, so any change replaces the post.
+type postResource struct {
+	data *providerData
+}
+
+type postResourceModel struct {
+	Account   types.String `tfsdk:"account"`
+	Uri       types.String `tfsdk:"uri"`
+	Cid       types.String `tfsdk:"cid"`
+	Text      types.String `tfsdk:"text"`
+	Langs     types.List   `tfsdk:"langs"`
+	Labels    types.Set    `tfsdk:"labels"`
+	CreatedAt types.String `tfsdk:"created_at"`
+
+	Images   types.List         `tfsdk:"images"`
+	External *postExternalModel `tfsdk:"external"`
+	QuoteUri types.String       `tfsdk:"quote_uri"`
+}
+
+// Metadata returns the resource type name.
+func (p *postResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_post"
+}
+
+// Schema defines the schema for the resource.
+func (p *postResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	attributes := map[string]schema.Attribute{
+		"account": accountResourceAttribute(),
+		"uri": schema.StringAttribute{
+			MarkdownDescription: "Atproto URI",
+			Computed:            true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.UseStateForUnknown(),
+			},
+		},
+		"cid": schema.StringAttribute{
+			MarkdownDescription: "Commit ID generated by Bluesky",
+			Computed:            true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.UseStateForUnknown(),
+			},
+		},
+		"text": schema.StringAttribute{
+			MarkdownDescription: "Text of the post. `@handle` mentions, `http://` and `https://` links, and `#hashtags` are detected automatically; " +
+				"mentioned handles are resolved to DIDs when the post is created.",
+			Required: true,
+			Validators: []validator.String{
+				postTextValidator{},
+			},
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.RequiresReplace(),
+			},
+		},
+		"langs": schema.ListAttribute{
+			MarkdownDescription: "Languages of the text, as BCP-47 language tags such as `en` or `pt-BR`",
+			ElementType:         types.StringType,
+			Optional:            true,
+			Validators: []validator.List{
+				listvalidator.SizeBetween(1, 3),
+			},
+			PlanModifiers: []planmodifier.List{
+				listplanmodifier.RequiresReplace(),
+			},
+		},
+		"labels": schema.SetAttribute{
+			MarkdownDescription: "Self-label values of the post, content warnings such as `sexual`, `nudity`, `porn` or `graphic-media`",
+			ElementType:         types.StringType,
+			Optional:            true,
+			Validators: []validator.Set{
+				setvalidator.SizeAtLeast(1),
+			},
+			PlanModifiers: []planmodifier.Set{
+				setplanmodifier.RequiresReplace(),
+			},
+		},
+		"created_at": schema.StringAttribute{
+			MarkdownDescription: "Timestamp of the post in RFC 3339 format. Defaults to the time the post is created.",
+			Optional:            true,
+			Computed:            true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.UseStateForUnknown(),
+				stringplanmodifier.RequiresReplace(),
+			},
+		},
+	}
+	for name, attribute := range postEmbedAttributes() {
+		attributes[name] = attribute
+	}
+
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Manage Bluesky posts. Mentions, links and hashtags in the text are turned into rich text facets, and images, a link card or a quoted post can be embedded. " +
+			"Posts aren't meant to be edited, so changing any attribute replaces the post.",
+		Attributes: attributes,
+	}
+}
+
+func (p *postResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	// Retrieve values from a plan.
+	var plan postResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
+		return
+	}
+
+	client, diags := p.data.accountClient(ctx, plan.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	createdAt := time.Now().UTC().Format(time.RFC3339)
+	if !plan.CreatedAt.IsUnknown() && !plan.CreatedAt.IsNull() {
+		createdAt = plan.CreatedAt.ValueString()
+		if _, err := time.Parse(time.RFC3339, createdAt); err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("created_at"),
+				"Invalid post timestamp",
+				"The created_at attribute must be an RFC 3339 timestamp such as 2024-11-20T15:04:05Z, got: "+createdAt,
+			)
+			return
+		}
+	}
+
+	// Generate API request body from plan.
+	post := &bsky.FeedPost{
+		Text:      plan.Text.ValueString(),
+		CreatedAt: createdAt,
+		Facets:    detectFacets(ctx, p.data.resolver, plan.Text.ValueString()),
+	}
+	resp.Diagnostics.Append(plan.Langs.ElementsAs(ctx, &post.Langs, false)...)
+	if !plan.Labels.IsNull() {
+		var labels []string
+		resp.Diagnostics.Append(plan.Labels.ElementsAs(ctx, &labels, false)...)
+		post.Labels = &bsky.FeedPost_Labels{LabelDefs_SelfLabels: newSelfLabels(labels)}
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	post.Embed, diags = buildPostEmbed(ctx, client, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	createRecordInput := &atproto.RepoCreateRecord_Input{
+		Repo:       client.Auth.Did,
+		Collection: postCollection,
+		Record:     &util.LexiconTypeDecoder{Val: post},
+	}
+
+	// Create new post.
+	record, err := atproto.RepoCreateRecord(ctx, client, createRecordInput)
+	if isSwapConflict(err) {
+		resp.Diagnostics.AddError("Repo changed outside of Terraform", swapConflictDetail("The repo "+client.Auth.Did, err))
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error creating post",
+			"Could not create post, unexpected error: "+err.Error(),
+		)
+		return
+	}
+
+	// Map response body to schema and populate Computed attribute values.
+	plan.Cid = types.StringValue(record.Cid)
+	plan.Uri = types.StringValue(record.Uri)
+	plan.CreatedAt = types.StringValue(createdAt)
+
+	// Set state to fully populated data.
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Read refreshes the Terraform state with the latest data.
+func (p *postResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	// Get current state.
+	var state postResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, diags := p.data.accountClient(ctx, state.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	post, record, _, err := GetPostFromURI(ctx, client, state.Uri.ValueString())
+	if isRecordNotFound(err) {
+		// The post was deleted outside of Terraform.
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error reading post",
+			"Could not read Bsky post URI "+state.Uri.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+
+	// Overwrite with refreshed state using the repository record. Embeds
+	// are kept as they are, since the files they were uploaded from can't be
+	// read back.
+	state.Cid = types.StringValue(*record.Cid)
+	state.Text = types.StringValue(post.Text)
+	state.CreatedAt = types.StringValue(post.CreatedAt)
+	state.Langs = types.ListNull(types.StringType)
+	if len(post.Langs) > 0 {
+		state.Langs, diags = types.ListValueFrom(ctx, types.StringType, post.Langs)
+		resp.Diagnostics.Append(diags...)
+	}
+	state.Labels = types.SetNull(types.StringType)
+	if post.Labels != nil && post.Labels.LabelDefs_SelfLabels != nil && len(post.Labels.LabelDefs_SelfLabels.Values) > 0 {
+		state.Labels, diags = types.SetValueFrom(ctx, types.StringType, selfLabelValues(post.Labels.LabelDefs_SelfLabels))
+		resp.Diagnostics.Append(diags...)
+	}
+
+	// Set refreshed state.
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Update only stores the new account of an imported post, since every other
+// change replaces the post.
+func (p *postResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan postResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+// Delete deletes the resource and removes the Terraform state on success.
+func (p *postResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	// Retrieve values from state.
+	var state postResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, diags := p.data.accountClient(ctx, state.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Parse the URI to extract components for the repository API
+	uri, err := syntax.ParseATURI(state.Uri.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Invalid post URI",
+			"Could not parse Bluesky post URI "+state.Uri.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+	deleteRequest := &atproto.RepoDeleteRecord_Input{
+		Collection: uri.Collection().String(),
+		Repo:       uri.Authority().String(),
+		Rkey:       uri.RecordKey().String(),
+		SwapRecord: swapRecord(state.Cid),
+	}
+	_, err = atproto.RepoDeleteRecord(ctx, client, deleteRequest)
+	if isSwapConflict(err) {
+		resp.Diagnostics.AddError("Post changed outside of Terraform", swapConflictDetail("The post "+state.Uri.ValueString(), err))
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error deleting post",
+			"Could not delete post, error: "+err.Error(),
+		)
+	}
+}
+
+// Configure adds the provider configured client to the resource.
+func (p *postResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Add a nil check when handling ProviderData because Terraform
+	// sets that data after it calls the ConfigureProvider RPC.
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	resp.Diagnostics.Append(data.requireAccount("posts")...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	p.data = data
+}
+
+func (p *postResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	// Retrieve import ID and save to id attribute.
+	resource.ImportStatePassthroughID(ctx, path.Root("uri"), req, resp)
+}
+
+func GetPostFromURI(ctx context.Context, client *xrpc.Client, uri string) (*bsky.FeedPost, *atproto.RepoGetRecord_Output, syntax.ATURI, error) {
+	record, parsedUri, err := getRecordAndURIFromString(ctx, client, uri)
+	if err != nil {
+		return nil, nil, parsedUri, fmt.Errorf("could not get record from URI %s: %w", uri, err)
+	}
+
+	// Extract the post from the record
+	post, ok := record.Value.Val.(*bsky.FeedPost)
+	if !ok {
+		return nil, record, parsedUri, fmt.Errorf("could not cast record to FeedPost")
+	}
+
+	return post, record, parsedUri, nil
+}
+
+// newSelfLabels returns the self-labels with the given values.
+func newSelfLabels(values []string) *atproto.LabelDefs_SelfLabels {
+	labels := &atproto.LabelDefs_SelfLabels{}
+	for _, value := range values {
+		labels.Values = append(labels.Values, &atproto.LabelDefs_SelfLabel{Val: value})
+	}
+	return labels
+}
+
+// selfLabelValues returns the values of self-labels.
+func selfLabelValues(labels *atproto.LabelDefs_SelfLabels) []string {
+	var values []string
+	for _, label := range labels.Values {
+		values = append(values, label.Val)
+	}
+	return values
+}
+
+// postTextMaxGraphemes and postTextMaxBytes are the limits of the lexicon of
+// app.bsky.feed.post on the length of the text.
+const (
+	postTextMaxGraphemes = 300
+	postTextMaxBytes     = 3000
+)
+
+// postTextValidator checks that the text of a post is at most 300 graphemes
+// and 3000 bytes of UTF-8 long, as counted by the AppView.
+type postTextValidator struct{}
+
+func (v postTextValidator) Description(_ context.Context) string {
+	return fmt.Sprintf("value must be at most %d graphemes and %d bytes long", postTextMaxGraphemes, postTextMaxBytes)
+}
+
+func (v postTextValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v postTextValidator) ValidateString(_ context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	text := req.ConfigValue.ValueString()
+	if len(text) > postTextMaxBytes {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Post text too long",
+			fmt.Sprintf("The text is %d bytes long, but posts can be at most %d bytes long.", len(text), postTextMaxBytes),
+		)
+		return
+	}
+	graphemes, err := textseg.TokenCount([]byte(text), textseg.ScanGraphemeClusters)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid post text", "Could not count the graphemes of the text: "+err.Error())
+		return
+	}
+	if graphemes > postTextMaxGraphemes {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Post text too long",
+			fmt.Sprintf("The text is %d graphemes long, but posts can be at most %d graphemes long.", graphemes, postTextMaxGraphemes),
+		)
+	}
+}
@@ -0,0 +1,378 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/api/bsky"
+	"github.com/bluesky-social/indigo/lex/util"
+	"github.com/hashicorp/terraform-plugin-framework-validators/setvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const postgateCollection = "app.bsky.feed.postgate"
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ resource.Resource                = &postgateResource{}
+	_ resource.ResourceWithConfigure   = &postgateResource{}
+	_ resource.ResourceWithImportState = &postgateResource{}
+	_ resource.ResourceWithModifyPlan  = &postgateResource{}
+)
+
+// NewPostgateResource is a helper function to simplify the provider implementation.
+func NewPostgateResource() resource.Resource {
+	return &postgateResource{}
+}
+
+// postgateResource is the resource implementation. The postgate of a post has
+// the same record key as the post.
+type postgateResource struct {
+	data *providerData
+}
+
+type postgateResourceModel struct {
+	Account               types.String `tfsdk:"account"`
+	PostUri               types.String `tfsdk:"post_uri"`
+	Uri                   types.String `tfsdk:"uri"`
+	Cid                   types.String `tfsdk:"cid"`
+	DetachedEmbeddingUris types.Set    `tfsdk:"detached_embedding_uris"`
+	DisableEmbedding      types.Bool   `tfsdk:"disable_embedding"`
+}
+
+// Metadata returns the resource type name.
+func (p *postgateResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_postgate"
+}
+
+// Schema defines the schema for the resource.
+func (p *postgateResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Manage whether a Bluesky post can be quoted, and detach it from posts quoting it.",
+		Attributes: map[string]schema.Attribute{
+			"account":  accountResourceAttribute(),
+			"post_uri": gatedPostURIAttribute("postgate"),
+			"uri": schema.StringAttribute{
+				MarkdownDescription: "Atproto URI",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"cid": schema.StringAttribute{
+				MarkdownDescription: "Commit ID generated by Bluesky",
+				Computed:            true,
+			},
+			"detached_embedding_uris": schema.SetAttribute{
+				MarkdownDescription: "Atproto URIs of the posts quoting the post which are detached from it, so that they show the quote as removed",
+				ElementType:         types.StringType,
+				Optional:            true,
+				Validators: []validator.Set{
+					setvalidator.SizeBetween(1, 50),
+				},
+			},
+			"disable_embedding": schema.BoolAttribute{
+				MarkdownDescription: "Whether other posts are prevented from quoting the post. Defaults to `false`.",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+		},
+	}
+}
+
+func (p *postgateResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	// Retrieve values from a plan.
+	var plan postgateResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
+		return
+	}
+
+	client, diags := p.data.accountClient(ctx, plan.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	post, err := parseGatedPostURI(client, plan.PostUri.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("post_uri"),
+			"Invalid post URI",
+			err.Error(),
+		)
+		return
+	}
+
+	// Generate API request body from plan.
+	postgate, diags := newPostgate(ctx, &plan, time.Now().Format(time.RFC3339))
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	rkey := post.RecordKey().String()
+	createRecordInput := &atproto.RepoCreateRecord_Input{
+		Repo:       client.Auth.Did,
+		Collection: postgateCollection,
+		Rkey:       &rkey,
+		Record:     &util.LexiconTypeDecoder{Val: postgate},
+	}
+
+	// Create new postgate.
+	record, err := atproto.RepoCreateRecord(ctx, client, createRecordInput)
+	if isSwapConflict(err) {
+		resp.Diagnostics.AddError("Repo changed outside of Terraform", swapConflictDetail("The repo "+client.Auth.Did, err))
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error creating postgate",
+			"Could not create the postgate of "+plan.PostUri.ValueString()+", unexpected error: "+err.Error(),
+		)
+		return
+	}
+
+	// Map response body to schema and populate Computed attribute values.
+	plan.Uri = types.StringValue(record.Uri)
+	plan.Cid = types.StringValue(record.Cid)
+
+	// Set state to fully populated data.
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Read refreshes the Terraform state with the latest data.
+func (p *postgateResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	// Get current state.
+	var state postgateResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, diags := p.data.accountClient(ctx, state.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	post, err := parseGatedPostURI(client, state.PostUri.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("post_uri"),
+			"Invalid post URI",
+			err.Error(),
+		)
+		return
+	}
+	record, err := getGate(ctx, client, postgateCollection, post)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error reading postgate",
+			"Could not read the postgate of "+state.PostUri.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+	if record == nil {
+		// The postgate was deleted outside of Terraform.
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	postgate, ok := record.Value.Val.(*bsky.FeedPostgate)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Error reading postgate",
+			"Could not cast record to FeedPostgate",
+		)
+		return
+	}
+
+	// Overwrite with refreshed state using the repository record.
+	state.Uri = types.StringValue(record.Uri)
+	state.Cid = types.StringValue(*record.Cid)
+	state.DisableEmbedding = types.BoolValue(false)
+	for _, rule := range postgate.EmbeddingRules {
+		if rule.FeedPostgate_DisableRule != nil {
+			state.DisableEmbedding = types.BoolValue(true)
+		}
+	}
+	state.DetachedEmbeddingUris = types.SetNull(types.StringType)
+	if len(postgate.DetachedEmbeddingUris) > 0 {
+		state.DetachedEmbeddingUris, diags = types.SetValueFrom(ctx, types.StringType, postgate.DetachedEmbeddingUris)
+		resp.Diagnostics.Append(diags...)
+	}
+
+	// Set refreshed state.
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Update updates the resource and sets the updated Terraform state on success.
+func (p *postgateResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	// Retrieve values from a plan.
+	var plan postgateResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
+		return
+	}
+
+	var state postgateResourceModel
+	diags = req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, diags := p.data.accountClient(ctx, plan.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Get the current postgate to keep its creation time.
+	record, parsedUri, err := getRecordAndURIFromString(ctx, client, plan.Uri.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to retrieve postgate",
+			"Could not retrieve the current state of the postgate "+plan.Uri.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+	current, ok := record.Value.Val.(*bsky.FeedPostgate)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Failed to retrieve postgate",
+			"Could not cast record to FeedPostgate",
+		)
+		return
+	}
+
+	postgate, diags := newPostgate(ctx, &plan, current.CreatedAt)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	putRecordInput := &atproto.RepoPutRecord_Input{
+		Collection: parsedUri.Collection().String(),
+		Repo:       parsedUri.Authority().String(),
+		Rkey:       parsedUri.RecordKey().String(),
+		SwapRecord: swapRecord(state.Cid),
+		Record: &util.LexiconTypeDecoder{
+			Val: postgate,
+		},
+	}
+	updatedRecord, err := atproto.RepoPutRecord(ctx, client, putRecordInput)
+	if isSwapConflict(err) {
+		resp.Diagnostics.AddError("Postgate changed outside of Terraform", swapConflictDetail("The postgate "+plan.Uri.ValueString(), err))
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to update postgate",
+			"Could not update postgate "+plan.Uri.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+
+	// Update resource state.
+	plan.Cid = types.StringValue(updatedRecord.Cid)
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Delete deletes the resource and removes the Terraform state on success.
+func (p *postgateResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	// Retrieve values from state.
+	var state postgateResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, diags := p.data.accountClient(ctx, state.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(deleteGate(ctx, client, "postgate", state.Uri.ValueString(), state.Cid)...)
+}
+
+// Configure adds the provider configured client to the resource.
+func (p *postgateResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Add a nil check when handling ProviderData because Terraform
+	// sets that data after it calls the ConfigureProvider RPC.
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	resp.Diagnostics.Append(data.requireAccount("postgates")...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	p.data = data
+}
+
+// ImportState imports the postgate of the post with the URI given as ID.
+// ModifyPlan fails the plan when post_uri is a post of another account.
+func (p *postgateResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	resp.Diagnostics.Append(checkGatedPostPlan(ctx, p.data, req.Plan)...)
+}
+
+func (p *postgateResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("post_uri"), req, resp)
+}
+
+// newPostgate returns the postgate record of model.
+func newPostgate(ctx context.Context, model *postgateResourceModel, createdAt string) (*bsky.FeedPostgate, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	postgate := &bsky.FeedPostgate{
+		Post:      model.PostUri.ValueString(),
+		CreatedAt: createdAt,
+	}
+	diags.Append(model.DetachedEmbeddingUris.ElementsAs(ctx, &postgate.DetachedEmbeddingUris, false)...)
+	if model.DisableEmbedding.ValueBool() {
+		postgate.EmbeddingRules = []*bsky.FeedPostgate_EmbeddingRules_Elem{
+			{FeedPostgate_DisableRule: &bsky.FeedPostgate_DisableRule{}},
+		}
+	}
+	return postgate, diags
+}
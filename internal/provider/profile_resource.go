@@ -0,0 +1,500 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/api/bsky"
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/bluesky-social/indigo/lex/util"
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/hashicorp/terraform-plugin-framework-validators/setvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	profileCollection = "app.bsky.actor.profile"
+	profileRkey       = "self"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ resource.Resource                = &profileResource{}
+	_ resource.ResourceWithConfigure   = &profileResource{}
+	_ resource.ResourceWithImportState = &profileResource{}
+)
+
+// NewProfileResource is a helper function to simplify the provider implementation.
+func NewProfileResource() resource.Resource {
+	return &profileResource{}
+}
+
+// profileResource is the resource implementation. It manages the singleton
+// profile record of an account, leaving the fields it doesn't manage as they
+// are.
+type profileResource struct {
+	data *providerData
+}
+
+type profileResourceModel struct {
+	Account              types.String `tfsdk:"account"`
+	Did                  types.String `tfsdk:"did"`
+	Uri                  types.String `tfsdk:"uri"`
+	Cid                  types.String `tfsdk:"cid"`
+	DisplayName          types.String `tfsdk:"display_name"`
+	Description          types.String `tfsdk:"description"`
+	PinnedPost           types.String `tfsdk:"pinned_post"`
+	Labels               types.Set    `tfsdk:"labels"`
+	JoinedViaStarterPack types.String `tfsdk:"joined_via_starter_pack"`
+	AvatarFile           types.String `tfsdk:"avatar_file"`
+	AvatarHash           types.String `tfsdk:"avatar_hash"`
+	BannerFile           types.String `tfsdk:"banner_file"`
+	BannerHash           types.String `tfsdk:"banner_hash"`
+}
+
+// Metadata returns the resource type name.
+func (p *profileResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_profile"
+}
+
+// Schema defines the schema for the resource.
+func (p *profileResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Manage the profile of a Bluesky account. Fields of the profile the resource doesn't manage are left as they are. " +
+			"Destroying the resource clears the fields it manages without deleting the profile.",
+		Attributes: map[string]schema.Attribute{
+			"account": accountResourceAttribute(),
+			"did": schema.StringAttribute{
+				MarkdownDescription: "DID of the account the profile belongs to",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"uri": schema.StringAttribute{
+				MarkdownDescription: "Atproto URI",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"cid": schema.StringAttribute{
+				MarkdownDescription: "Commit ID generated by Bluesky",
+				Computed:            true,
+			},
+			"display_name": schema.StringAttribute{
+				MarkdownDescription: "Display name of the account",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtMost(640),
+				},
+			},
+			"description": schema.StringAttribute{
+				MarkdownDescription: "Free-form profile description text, the bio of the account",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtMost(2560),
+				},
+			},
+			"pinned_post": schema.StringAttribute{
+				MarkdownDescription: "Atproto URI of the post pinned to the top of the profile",
+				Optional:            true,
+			},
+			"labels": schema.SetAttribute{
+				MarkdownDescription: "Self-label values of the account, such as `!no-unauthenticated` to ask apps not to show the account to logged-out users",
+				ElementType:         types.StringType,
+				Optional:            true,
+				Validators: []validator.Set{
+					setvalidator.SizeAtLeast(1),
+				},
+			},
+			"joined_via_starter_pack": schema.StringAttribute{
+				MarkdownDescription: "Atproto URI of the starter pack the account joined Bluesky with",
+				Optional:            true,
+			},
+			"avatar_file": blobFileAttribute("Path to the image file uploaded as avatar of the account.", imageConstraints),
+			"avatar_hash": blobHashAttribute("avatar_file", imageConstraints),
+			"banner_file": blobFileAttribute("Path to the image file uploaded as banner of the profile.", imageConstraints),
+			"banner_hash": blobHashAttribute("banner_file", imageConstraints),
+		},
+	}
+}
+
+func (p *profileResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	// Retrieve values from a plan.
+	var plan profileResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
+		return
+	}
+
+	client, diags := p.data.accountClient(ctx, plan.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The profile usually exists already, since apps create it when signing
+	// up. It is updated in place.
+	plan.Did = types.StringValue(client.Auth.Did)
+	resp.Diagnostics.Append(p.putProfile(ctx, client, &plan, &profileResourceModel{})...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Set state to fully populated data.
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Read refreshes the Terraform state with the latest data.
+func (p *profileResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	// Get current state.
+	var state profileResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, diags := p.data.accountClient(ctx, state.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	profile, record, err := getProfile(ctx, client, state.Did.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error reading profile",
+			"Could not read the profile of "+state.Did.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+	if record == nil {
+		// The profile was deleted outside of Terraform.
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	// Overwrite with refreshed state using the repository record
+	state.Uri = types.StringValue(record.Uri)
+	state.Cid = types.StringValue(*record.Cid)
+	state.DisplayName = types.StringPointerValue(profile.DisplayName)
+	state.Description = types.StringPointerValue(profile.Description)
+	state.PinnedPost = strongRefURI(profile.PinnedPost)
+	state.JoinedViaStarterPack = strongRefURI(profile.JoinedViaStarterPack)
+	// Images removed outside of Terraform are uploaded again.
+	if profile.Avatar == nil {
+		state.AvatarHash = types.StringNull()
+	}
+	if profile.Banner == nil {
+		state.BannerHash = types.StringNull()
+	}
+	state.Labels = types.SetNull(types.StringType)
+	if profile.Labels != nil && profile.Labels.LabelDefs_SelfLabels != nil && len(profile.Labels.LabelDefs_SelfLabels.Values) > 0 {
+		state.Labels, diags = types.SetValueFrom(ctx, types.StringType, selfLabelValues(profile.Labels.LabelDefs_SelfLabels))
+		resp.Diagnostics.Append(diags...)
+	}
+
+	// Set refreshed state.
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Update updates the resource and sets the updated Terraform state on success.
+func (p *profileResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	// Retrieve values from a plan.
+	var plan profileResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
+		return
+	}
+
+	var state profileResourceModel
+	diags = req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, diags := p.data.accountClient(ctx, plan.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(p.putProfile(ctx, client, &plan, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Delete clears the fields of the profile managed by the resource and removes
+// the Terraform state on success.
+func (p *profileResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	// Retrieve values from state.
+	var state profileResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, diags := p.data.accountClient(ctx, state.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	profile, record, err := getProfile(ctx, client, state.Did.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error deleting profile",
+			"Could not read the profile of "+state.Did.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+	if record == nil {
+		return
+	}
+
+	cleared := profileResourceModel{
+		Did:    state.Did,
+		Labels: types.SetNull(types.StringType),
+	}
+	resp.Diagnostics.Append(p.writeProfile(ctx, client, &cleared, &state, profile, record)...)
+}
+
+// Configure adds the provider configured client to the resource.
+func (p *profileResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Add a nil check when handling ProviderData because Terraform
+	// sets that data after it calls the ConfigureProvider RPC.
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	resp.Diagnostics.Append(data.requireAccount("profiles")...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	p.data = data
+}
+
+// ImportState imports the profile of the account with the DID given as ID.
+func (p *profileResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	if _, err := syntax.ParseDID(req.ID); err != nil {
+		resp.Diagnostics.AddError(
+			"Invalid profile import ID",
+			"Profiles are imported by the DID of their account, such as did:plc:7kkf4hujjl6wll6pewqahaex: "+err.Error(),
+		)
+		return
+	}
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("did"), req.ID)...)
+}
+
+// putProfile writes the fields managed by the resource to the profile of
+// model.Did, keeping the other fields of the existing profile. The write is
+// swapped against the profile it read, so that concurrent changes aren't
+// overwritten. The uri, cid and image hashes of model are set from the written
+// record. prior is the state before the change, which is empty on create.
+func (p *profileResource) putProfile(ctx context.Context, client *xrpc.Client, model *profileResourceModel, prior *profileResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	profile, record, err := getProfile(ctx, client, model.Did.ValueString())
+	if err != nil {
+		diags.AddError(
+			"Failed to retrieve profile",
+			"Could not retrieve the current profile of "+model.Did.ValueString()+": "+err.Error(),
+		)
+		return diags
+	}
+	return p.writeProfile(ctx, client, model, prior, profile, record)
+}
+
+// writeProfile writes the fields managed by the resource to profile, the
+// existing profile read as record, which is nil when there is none yet.
+func (p *profileResource) writeProfile(ctx context.Context, client *xrpc.Client, model *profileResourceModel, prior *profileResourceModel, profile *bsky.ActorProfile, record *atproto.RepoGetRecord_Output) diag.Diagnostics {
+	var diags diag.Diagnostics
+	var err error
+
+	// The write is swapped against the profile that was read. Without a
+	// profile there is no record to swap against, since an omitted SwapRecord
+	// doesn't check anything, so it is swapped against the latest commit of
+	// the repo instead. That way a profile created in the meantime isn't
+	// overwritten.
+	var swapRecord, swapCommit *string
+	if record != nil {
+		swapRecord = record.Cid
+	} else {
+		commit, err := atproto.SyncGetLatestCommit(ctx, client, model.Did.ValueString())
+		if err != nil {
+			diags.AddError(
+				"Failed to retrieve profile",
+				"Could not get the latest commit of "+model.Did.ValueString()+" to create its profile: "+err.Error(),
+			)
+			return diags
+		}
+		swapCommit = &commit.Cid
+
+		createdAt := time.Now().Format(time.RFC3339)
+		profile = &bsky.ActorProfile{CreatedAt: &createdAt}
+	}
+
+	profile.DisplayName = model.DisplayName.ValueStringPointer()
+	profile.Description = model.Description.ValueStringPointer()
+
+	profile.PinnedPost, err = getStrongRef(ctx, client, model.PinnedPost.ValueString())
+	if err != nil {
+		diags.AddAttributeError(
+			path.Root("pinned_post"),
+			"Invalid pinned post",
+			"Could not get the pinned post "+model.PinnedPost.ValueString()+": "+err.Error(),
+		)
+	}
+	profile.JoinedViaStarterPack, err = getStrongRef(ctx, client, model.JoinedViaStarterPack.ValueString())
+	if err != nil {
+		diags.AddAttributeError(
+			path.Root("joined_via_starter_pack"),
+			"Invalid starter pack",
+			"Could not get the starter pack "+model.JoinedViaStarterPack.ValueString()+": "+err.Error(),
+		)
+	}
+
+	avatar := blobField{file: model.AvatarFile, hash: model.AvatarHash}
+	profile.Avatar, model.AvatarHash, err = avatar.update(ctx, client, profile.Avatar, blobField{file: prior.AvatarFile, hash: prior.AvatarHash}, imageConstraints)
+	if err != nil {
+		diags.AddAttributeError(
+			path.Root("avatar_file"),
+			"Failed to upload avatar",
+			"Could not upload the avatar "+model.AvatarFile.ValueString()+": "+err.Error(),
+		)
+	}
+	banner := blobField{file: model.BannerFile, hash: model.BannerHash}
+	profile.Banner, model.BannerHash, err = banner.update(ctx, client, profile.Banner, blobField{file: prior.BannerFile, hash: prior.BannerHash}, imageConstraints)
+	if err != nil {
+		diags.AddAttributeError(
+			path.Root("banner_file"),
+			"Failed to upload banner",
+			"Could not upload the banner "+model.BannerFile.ValueString()+": "+err.Error(),
+		)
+	}
+
+	profile.Labels = nil
+	if !model.Labels.IsNull() {
+		var labels []string
+		diags.Append(model.Labels.ElementsAs(ctx, &labels, false)...)
+		profile.Labels = &bsky.ActorProfile_Labels{LabelDefs_SelfLabels: newSelfLabels(labels)}
+	}
+	if diags.HasError() {
+		return diags
+	}
+
+	putRecordInput := &atproto.RepoPutRecord_Input{
+		Collection: profileCollection,
+		Repo:       model.Did.ValueString(),
+		Rkey:       profileRkey,
+		SwapRecord: swapRecord,
+		SwapCommit: swapCommit,
+		Record: &util.LexiconTypeDecoder{
+			Val: profile,
+		},
+	}
+	updatedRecord, err := atproto.RepoPutRecord(ctx, client, putRecordInput)
+	if isSwapConflict(err) {
+		diags.AddError("Profile changed outside of Terraform", swapConflictDetail("The profile of "+model.Did.ValueString(), err))
+		return diags
+	}
+	if err != nil {
+		diags.AddError(
+			"Failed to update profile",
+			"Could not update the profile of "+model.Did.ValueString()+": "+err.Error(),
+		)
+		return diags
+	}
+
+	model.Uri = types.StringValue(updatedRecord.Uri)
+	model.Cid = types.StringValue(updatedRecord.Cid)
+	return diags
+}
+
+// getProfile returns the profile record of did, or a nil record if the account
+// has no profile.
+func getProfile(ctx context.Context, client *xrpc.Client, did string) (*bsky.ActorProfile, *atproto.RepoGetRecord_Output, error) {
+	record, err := atproto.RepoGetRecord(ctx, client, "", profileCollection, did, profileRkey)
+	if isRecordNotFound(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not get record: %w", err)
+	}
+	if record.Cid == nil {
+		return nil, nil, fmt.Errorf("record.Cid is nil")
+	}
+
+	profile, ok := record.Value.Val.(*bsky.ActorProfile)
+	if !ok {
+		return nil, nil, fmt.Errorf("could not cast record to ActorProfile")
+	}
+	return profile, record, nil
+}
+
+// getStrongRef returns a reference to the current version of the record at
+// uri, or nil when uri is empty.
+func getStrongRef(ctx context.Context, client *xrpc.Client, uri string) (*atproto.RepoStrongRef, error) {
+	if uri == "" {
+		return nil, nil
+	}
+	record, _, err := getRecordAndURIFromString(ctx, client, uri)
+	if err != nil {
+		return nil, err
+	}
+	return &atproto.RepoStrongRef{
+		Uri: record.Uri,
+		Cid: *record.Cid,
+	}, nil
+}
+
+// strongRefURI returns the URI of a strong reference, or null.
+func strongRefURI(ref *atproto.RepoStrongRef) types.String {
+	if ref == nil {
+		return types.StringNull()
+	}
+	return types.StringValue(ref.Uri)
+}
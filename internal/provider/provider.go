@@ -5,16 +5,21 @@ package provider
 
 import (
 	"context"
+	"net/url"
 	"os"
+	"strconv"
+	"time"
 
-	"github.com/bluesky-social/indigo/api/atproto"
 	"github.com/bluesky-social/indigo/xrpc"
 
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
@@ -34,7 +39,46 @@ type bskyProviderModel struct {
 	PDSHost          types.String `tfsdk:"pds_host"`
 	Handle           types.String `tfsdk:"handle"`
 	Password         types.String `tfsdk:"password"`
+	AuthFactorToken  types.String `tfsdk:"auth_factor_token"`
 	PDSAdminPassword types.String `tfsdk:"pds_admin_password"`
+	PLCURL           types.String `tfsdk:"plc_url"`
+	HandleResolver   types.String `tfsdk:"handle_resolver"`
+	MaxRetries       types.Int64  `tfsdk:"max_retries"`
+	WritesPerHour    types.Int64  `tfsdk:"writes_per_hour"`
+	SwapCommit       types.Bool   `tfsdk:"swap_commit"`
+	SessionCacheFile types.String `tfsdk:"session_cache_file"`
+	SessionCacheKey  types.String `tfsdk:"session_cache_key"`
+
+	RequestTimeout        types.String `tfsdk:"request_timeout"`
+	Proxy                 types.String `tfsdk:"proxy"`
+	CABundleFile          types.String `tfsdk:"ca_bundle_file"`
+	ClientCertificateFile types.String `tfsdk:"client_certificate_file"`
+	ClientKeyFile         types.String `tfsdk:"client_key_file"`
+	UserAgent             types.String `tfsdk:"user_agent"`
+	LogXRPCRequests       types.Bool   `tfsdk:"log_xrpc_requests"`
+
+	OAuth    *bskyProviderOAuthModel             `tfsdk:"oauth"`
+	Accounts map[string]bskyProviderAccountModel `tfsdk:"accounts"`
+}
+
+// bskyProviderAccountModel maps a named account of the accounts attribute of
+// the provider schema.
+type bskyProviderAccountModel struct {
+	PDSHost         types.String `tfsdk:"pds_host"`
+	Handle          types.String `tfsdk:"handle"`
+	Password        types.String `tfsdk:"password"`
+	AuthFactorToken types.String `tfsdk:"auth_factor_token"`
+}
+
+// bskyProviderOAuthModel maps the oauth attribute of the provider schema.
+type bskyProviderOAuthModel struct {
+	ClientID            types.String `tfsdk:"client_id"`
+	ClientKey           types.String `tfsdk:"client_key"`
+	ClientKeyID         types.String `tfsdk:"client_key_id"`
+	AuthorizationServer types.String `tfsdk:"authorization_server"`
+	TokenFile           types.String `tfsdk:"token_file"`
+	RefreshToken        types.String `tfsdk:"refresh_token"`
+	DPoPKey             types.String `tfsdk:"dpop_key"`
 }
 
 func (p *bskyProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
@@ -47,10 +91,21 @@ func (p *bskyProvider) Schema(ctx context.Context, req provider.SchemaRequest, r
 		MarkdownDescription: "Manage Bluesky PDS",
 		Attributes: map[string]schema.Attribute{
 			"pds_host": schema.StringAttribute{
-				MarkdownDescription: "Base URL of your Personal Data Server (PDS). For most people, this is `https://bsky.social/`." +
+				MarkdownDescription: "Base URL of your Personal Data Server (PDS). When not set, the PDS is discovered from the DID document of the `handle`." +
 					"\nCan also be set via the BSKY_PDS_HOST environment variable.",
 				Optional: true,
 			},
+			"plc_url": schema.StringAttribute{
+				MarkdownDescription: "Base URL of the PLC directory used to resolve `did:plc` DIDs when discovering the PDS. Defaults to `https://plc.directory`." +
+					"\nCan also be set via the BSKY_PLC_URL environment variable.",
+				Optional: true,
+			},
+			"handle_resolver": schema.StringAttribute{
+				MarkdownDescription: "Host of a service resolving handles with `com.atproto.identity.resolveHandle`, such as `https://public.api.bsky.app`. " +
+					"Handles are resolved when discovering the PDS, and for list items, list mirrors and mentions in posts. When not set, handles are resolved with DNS and HTTPS." +
+					"\nCan also be set via the BSKY_HANDLE_RESOLVER environment variable.",
+				Optional: true,
+			},
 			"handle": schema.StringAttribute{
 				MarkdownDescription: "Your Bluesky handle, without the `@`." +
 					"\nCan also be set via the BSKY_HANDLE environment variable.",
@@ -61,11 +116,156 @@ func (p *bskyProvider) Schema(ctx context.Context, req provider.SchemaRequest, r
 					"\nCan also be set via the BSKY_PASSWORD environment variable.",
 				Optional: true,
 			},
+			"auth_factor_token": schema.StringAttribute{
+				MarkdownDescription: "Sign-in code emailed to accounts with email two-factor authentication enabled. " +
+					"Codes expire after a few minutes and can only be used once, so set `session_cache_file` as well to resume the session on later runs instead of needing a new code every time." +
+					"\nCan also be set via the BSKY_AUTH_FACTOR_TOKEN environment variable.",
+				Optional:  true,
+				Sensitive: true,
+			},
 			"pds_admin_password": schema.StringAttribute{
-				MarkdownDescription: "Admin password used when setting up the PDS. Used to manage account resources." +
+				MarkdownDescription: "Admin password used when setting up the PDS. Used to manage account resources. When set without `handle` and `password`, the provider only administers the PDS and doesn't log in to an account; resources managing records of an account are then unavailable." +
 					"\nCan also be set via the BSKY_ADMIN_PASSWORD environment variable.",
 				Optional: true,
 			},
+			"max_retries": schema.Int64Attribute{
+				MarkdownDescription: "Number of times a request rejected with a 429 or 5xx response is retried, with jittered exponential backoff. Record writes which may have been committed are only retried when they pass `swapRecord` or `swapCommit`, so that a lost response never creates a duplicate record. Defaults to `3`." +
+					"\nCan also be set via the BSKY_MAX_RETRIES environment variable.",
+				Optional: true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(0),
+				},
+			},
+			"writes_per_hour": schema.Int64Attribute{
+				MarkdownDescription: "Maximum number of record writes per hour, shared by all resources. " +
+					"Use it to stay within the write budget of your PDS during large applies. Defaults to `0`, which only honors the rate limit headers of the PDS." +
+					"\nCan also be set via the BSKY_WRITES_PER_HOUR environment variable.",
+				Optional: true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(0),
+				},
+			},
+			"swap_commit": schema.BoolAttribute{
+				MarkdownDescription: "Pass the latest commit of the repo as `swapCommit` with every record write, so that a write fails with a conflict instead of being applied when anything else wrote to the repo in the meantime. " +
+					"Writes to the same repo are serialized, and each costs an additional request. Defaults to `false`, which only checks the CID of the record on updates and deletes." +
+					"\nCan also be set via the BSKY_SWAP_COMMIT environment variable.",
+				Optional: true,
+			},
+			"session_cache_file": schema.StringAttribute{
+				MarkdownDescription: "Path to a file caching the session between runs, so that the provider resumes it instead of logging in again every time. " +
+					"A new session is only created when the cached refresh token is rejected. Sessions are stored per `pds_host` and `handle`, so one file can be shared by several accounts. " +
+					"Requires `session_cache_key`. Not used with `oauth`, which keeps its tokens in `oauth.token_file`." +
+					"\nCan also be set via the BSKY_SESSION_CACHE_FILE environment variable.",
+				Optional: true,
+			},
+			"session_cache_key": schema.StringAttribute{
+				MarkdownDescription: "Passphrase the session cache is encrypted with. A cache encrypted with another passphrase is replaced." +
+					"\nCan also be set via the BSKY_SESSION_CACHE_KEY environment variable.",
+				Optional:  true,
+				Sensitive: true,
+			},
+			"request_timeout": schema.StringAttribute{
+				MarkdownDescription: "Maximum duration of a single attempt of a request, such as `30s` or `2m`. Attempts running into the timeout are retried like other network errors. Defaults to `60s`, `0` disables the timeout." +
+					"\nCan also be set via the BSKY_REQUEST_TIMEOUT environment variable.",
+				Optional: true,
+			},
+			"proxy": schema.StringAttribute{
+				MarkdownDescription: "URL of the HTTP(S) proxy to send requests through. When not set, the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables are honored." +
+					"\nCan also be set via the BSKY_PROXY environment variable.",
+				Optional: true,
+			},
+			"ca_bundle_file": schema.StringAttribute{
+				MarkdownDescription: "Path to a PEM file of CA certificates to trust in addition to the system roots, such as the internal CA of a self-hosted PDS." +
+					"\nCan also be set via the BSKY_CA_BUNDLE_FILE environment variable.",
+				Optional: true,
+			},
+			"client_certificate_file": schema.StringAttribute{
+				MarkdownDescription: "Path to a PEM encoded client certificate presented to servers requiring mutual TLS. Requires `client_key_file`." +
+					"\nCan also be set via the BSKY_CLIENT_CERTIFICATE_FILE environment variable.",
+				Optional: true,
+			},
+			"client_key_file": schema.StringAttribute{
+				MarkdownDescription: "Path to the PEM encoded private key of `client_certificate_file`." +
+					"\nCan also be set via the BSKY_CLIENT_KEY_FILE environment variable.",
+				Optional: true,
+			},
+			"user_agent": schema.StringAttribute{
+				MarkdownDescription: "Product token appended to the user agent of every request, which otherwise names the Terraform and provider versions." +
+					"\nCan also be set via the BSKY_USER_AGENT environment variable.",
+				Optional: true,
+			},
+			"log_xrpc_requests": schema.BoolAttribute{
+				MarkdownDescription: "Log every XRPC request in the `xrpc` subsystem of the provider log: the method, status, latency and rate limit headers at `DEBUG`, and the request and response bodies at `TRACE`. " +
+					"Passwords, sign-in codes and tokens are masked. The level of the subsystem can be set separately with the TF_LOG_PROVIDER_BSKY_XRPC environment variable. Defaults to `false`." +
+					"\nCan also be set via the BSKY_LOG_XRPC_REQUESTS environment variable.",
+				Optional: true,
+			},
+			"accounts": schema.MapNestedAttribute{
+				MarkdownDescription: "Additional accounts, by name, which resources select with their `account` attribute. " +
+					"Each account only logs in when a resource first uses it. The session cache and rate limits are shared with the default account.",
+				Optional: true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"pds_host": schema.StringAttribute{
+							MarkdownDescription: "Base URL of the PDS of the account. When not set, the PDS is discovered from the DID document of the `handle`.",
+							Optional:            true,
+						},
+						"handle": schema.StringAttribute{
+							MarkdownDescription: "Bluesky handle of the account, without the `@`.",
+							Required:            true,
+						},
+						"password": schema.StringAttribute{
+							MarkdownDescription: "Password or [app password](https://bsky.app/settings/app-passwords) of the account.",
+							Required:            true,
+							Sensitive:           true,
+						},
+						"auth_factor_token": schema.StringAttribute{
+							MarkdownDescription: "Sign-in code emailed to the account when it has email two-factor authentication enabled.",
+							Optional:            true,
+							Sensitive:           true,
+						},
+					},
+				},
+			},
+			"oauth": schema.SingleNestedAttribute{
+				MarkdownDescription: "Authenticate with DPoP-bound OAuth tokens instead of a handle and password. " +
+					"The provider redeems the refresh token at the authorization server of the PDS and never needs an app password.",
+				Optional: true,
+				Attributes: map[string]schema.Attribute{
+					"client_id": schema.StringAttribute{
+						MarkdownDescription: "URL of the OAuth client metadata document the tokens were issued to.",
+						Required:            true,
+					},
+					"client_key": schema.StringAttribute{
+						MarkdownDescription: "PEM encoded P-256 private key of a confidential client, used to sign the client assertion. Leave unset for public clients.",
+						Optional:            true,
+						Sensitive:           true,
+					},
+					"client_key_id": schema.StringAttribute{
+						MarkdownDescription: "Key ID (`kid`) of `client_key` in the JWKS of the client metadata.",
+						Optional:            true,
+					},
+					"authorization_server": schema.StringAttribute{
+						MarkdownDescription: "Issuer URL of the authorization server. Discovered from the PDS when not set.",
+						Optional:            true,
+					},
+					"token_file": schema.StringAttribute{
+						MarkdownDescription: "Path to a JSON file holding the `refresh_token` and `dpop_key`. " +
+							"The file is rewritten with the new refresh token every time the tokens are refreshed, since refresh tokens can only be used once.",
+						Optional: true,
+					},
+					"refresh_token": schema.StringAttribute{
+						MarkdownDescription: "OAuth refresh token. With `token_file`, it is only used until the file holds a refresh token, since the file is rewritten with the rotated refresh token after every refresh.",
+						Optional:            true,
+						Sensitive:           true,
+					},
+					"dpop_key": schema.StringAttribute{
+						MarkdownDescription: "PEM encoded P-256 private key the tokens are bound to. Takes precedence over the key in `token_file`.",
+						Optional:            true,
+						Sensitive:           true,
+					},
+				},
+			},
 		},
 	}
 }
@@ -116,7 +316,62 @@ func (p *bskyProvider) Configure(ctx context.Context, req provider.ConfigureRequ
 	pdsHost := os.Getenv("BSKY_PDS_HOST")
 	handle := os.Getenv("BSKY_HANDLE")
 	password := os.Getenv("BSKY_PASSWORD")
+	authFactorToken := os.Getenv("BSKY_AUTH_FACTOR_TOKEN")
 	pdsAdminpassword := os.Getenv("BSKY_ADMIN_PASSWORD")
+	plcURL := os.Getenv("BSKY_PLC_URL")
+	handleResolver := os.Getenv("BSKY_HANDLE_RESOLVER")
+	sessionCacheFile := os.Getenv("BSKY_SESSION_CACHE_FILE")
+	sessionCacheKey := os.Getenv("BSKY_SESSION_CACHE_KEY")
+	requestTimeout := os.Getenv("BSKY_REQUEST_TIMEOUT")
+	proxy := os.Getenv("BSKY_PROXY")
+	caBundleFile := os.Getenv("BSKY_CA_BUNDLE_FILE")
+	clientCertificateFile := os.Getenv("BSKY_CLIENT_CERTIFICATE_FILE")
+	clientKeyFile := os.Getenv("BSKY_CLIENT_KEY_FILE")
+	userAgent := os.Getenv("BSKY_USER_AGENT")
+	maxRetries := int64(defaultMaxRetries)
+	writesPerHour := int64(0)
+	swapCommit := false
+	logXRPCRequests := false
+
+	for _, env := range []struct {
+		name  string
+		value *bool
+	}{
+		{"BSKY_SWAP_COMMIT", &swapCommit},
+		{"BSKY_LOG_XRPC_REQUESTS", &logXRPCRequests},
+	} {
+		if v := os.Getenv(env.name); v != "" {
+			parsed, err := strconv.ParseBool(v)
+			if err != nil {
+				resp.Diagnostics.AddError(
+					"Invalid "+env.name+" environment variable",
+					"The "+env.name+" environment variable must be true or false, got: "+v,
+				)
+				return
+			}
+			*env.value = parsed
+		}
+	}
+
+	for _, env := range []struct {
+		name  string
+		value *int64
+	}{
+		{"BSKY_MAX_RETRIES", &maxRetries},
+		{"BSKY_WRITES_PER_HOUR", &writesPerHour},
+	} {
+		if v := os.Getenv(env.name); v != "" {
+			parsed, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || parsed < 0 {
+				resp.Diagnostics.AddError(
+					"Invalid "+env.name+" environment variable",
+					"The "+env.name+" environment variable must be a non-negative integer, got: "+v,
+				)
+				return
+			}
+			*env.value = parsed
+		}
+	}
 
 	if !config.PDSHost.IsNull() {
 		pdsHost = config.PDSHost.ValueString()
@@ -130,20 +385,91 @@ func (p *bskyProvider) Configure(ctx context.Context, req provider.ConfigureRequ
 		password = config.Password.ValueString()
 	}
 
+	if !config.AuthFactorToken.IsNull() {
+		authFactorToken = config.AuthFactorToken.ValueString()
+	}
+
 	if !config.PDSAdminPassword.IsNull() {
 		pdsAdminpassword = config.PDSAdminPassword.ValueString()
 	}
 
-	if pdsHost == "" {
+	if !config.PLCURL.IsNull() {
+		plcURL = config.PLCURL.ValueString()
+	}
+	if plcURL == "" {
+		plcURL = defaultPLCURL
+	}
+
+	if !config.HandleResolver.IsNull() {
+		handleResolver = config.HandleResolver.ValueString()
+	}
+
+	if !config.MaxRetries.IsNull() {
+		maxRetries = config.MaxRetries.ValueInt64()
+	}
+
+	if !config.WritesPerHour.IsNull() {
+		writesPerHour = config.WritesPerHour.ValueInt64()
+	}
+
+	if !config.SwapCommit.IsNull() {
+		swapCommit = config.SwapCommit.ValueBool()
+	}
+
+	if !config.LogXRPCRequests.IsNull() {
+		logXRPCRequests = config.LogXRPCRequests.ValueBool()
+	}
+
+	if !config.SessionCacheFile.IsNull() {
+		sessionCacheFile = config.SessionCacheFile.ValueString()
+	}
+
+	if !config.SessionCacheKey.IsNull() {
+		sessionCacheKey = config.SessionCacheKey.ValueString()
+	}
+
+	for _, attribute := range []struct {
+		value  types.String
+		target *string
+	}{
+		{config.RequestTimeout, &requestTimeout},
+		{config.Proxy, &proxy},
+		{config.CABundleFile, &caBundleFile},
+		{config.ClientCertificateFile, &clientCertificateFile},
+		{config.ClientKeyFile, &clientKeyFile},
+		{config.UserAgent, &userAgent},
+	} {
+		if !attribute.value.IsNull() {
+			*attribute.target = attribute.value.ValueString()
+		}
+	}
+
+	// Operators only managing accounts can configure the PDS admin password
+	// alone, and teams managing several accounts can configure only named
+	// accounts. The provider then doesn't log in to a default account.
+	adminOnly := config.OAuth == nil && handle == "" && password == "" && (pdsAdminpassword != "" || len(config.Accounts) > 0)
+
+	if pdsHost == "" && adminOnly && pdsAdminpassword != "" {
 		resp.Diagnostics.AddAttributeError(
 			path.Root("pds_host"),
 			"Missing Bluesky PDS host",
 			"The provider cannot create the Bluesky API client as there is a missing or empty value for the Bluesky PDS host. "+
+				"The PDS can't be discovered when only the PDS admin password is configured. "+
 				"Set the value in the configuration or use the BSKY_PDS_HOST environment variable."+
 				"If either is already set, ensure the value is not empty.",
 		)
 	}
-	if handle == "" {
+	if pdsHost == "" && config.OAuth != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("pds_host"),
+			"Missing Bluesky PDS host",
+			"The provider cannot create the Bluesky API client as there is a missing or empty value for the Bluesky PDS host. "+
+				"The PDS can't be discovered when authenticating with OAuth. "+
+				"Set the value in the configuration or use the BSKY_PDS_HOST environment variable."+
+				"If either is already set, ensure the value is not empty.",
+		)
+	}
+	if handle == "" && config.OAuth == nil && !adminOnly {
 		resp.Diagnostics.AddAttributeError(
 			path.Root("handle"),
 			"Missing Bluesky handle",
@@ -152,7 +478,7 @@ func (p *bskyProvider) Configure(ctx context.Context, req provider.ConfigureRequ
 				"If either is already set, ensure the value is not empty.",
 		)
 	}
-	if password == "" {
+	if password == "" && config.OAuth == nil && !adminOnly {
 		resp.Diagnostics.AddAttributeError(
 			path.Root("password"),
 			"Missing Bluesky password",
@@ -162,10 +488,88 @@ func (p *bskyProvider) Configure(ctx context.Context, req provider.ConfigureRequ
 		)
 	}
 
+	if sessionCacheFile != "" && sessionCacheKey == "" {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("session_cache_key"),
+			"Missing Bluesky session cache key",
+			"The provider cannot use the session cache as there is a missing or empty value for the session cache key. "+
+				"The cache holds refresh tokens and is always encrypted. "+
+				"Set the value in the configuration or use the BSKY_SESSION_CACHE_KEY environment variable."+
+				"If either is already set, ensure the value is not empty.",
+		)
+	}
+
+	transport := transportConfig{
+		maxRetries:     int(maxRetries),
+		writesPerHour:  int(writesPerHour),
+		swapCommit:     swapCommit,
+		requestTimeout: defaultRequestTimeout,
+		userAgent:      "Terraform/" + req.TerraformVersion + " terraform-provider-bsky/" + p.version,
+	}
+	if userAgent != "" {
+		transport.userAgent += " " + userAgent
+	}
+	if logXRPCRequests {
+		transport.logRequests = true
+		transport.secrets = []string{password, authFactorToken, pdsAdminpassword, sessionCacheKey}
+		for _, account := range config.Accounts {
+			transport.secrets = append(transport.secrets, account.Password.ValueString(), account.AuthFactorToken.ValueString())
+		}
+		if config.OAuth != nil {
+			transport.secrets = append(transport.secrets, config.OAuth.RefreshToken.ValueString())
+		}
+	}
+	if requestTimeout != "" {
+		timeout, err := time.ParseDuration(requestTimeout)
+		if err != nil || timeout < 0 {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("request_timeout"),
+				"Invalid request timeout",
+				"The request timeout must be a non-negative duration such as 30s or 2m, got: "+requestTimeout,
+			)
+		}
+		transport.requestTimeout = timeout
+	}
+	if proxy != "" {
+		proxyURL, err := url.Parse(proxy)
+		if err != nil || proxyURL.Scheme == "" || proxyURL.Host == "" {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("proxy"),
+				"Invalid proxy URL",
+				"The proxy must be a URL such as http://proxy.example.com:3128, got: "+proxy,
+			)
+		}
+		transport.proxy = proxyURL
+	}
+	tlsConfig, err := newTLSConfig(caBundleFile, clientCertificateFile, clientKeyFile)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Invalid TLS configuration",
+			"The provider cannot use the configured CA bundle or client certificate: "+err.Error(),
+		)
+	}
+	transport.tlsConfig = tlsConfig
+
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
+	// All requests of this provider instance share one http.Client, so that
+	// its rate limiting applies across concurrent resource operations.
+	httpClient := newHTTPClient(transport)
+
+	connector := &accountConnector{
+		httpClient: httpClient,
+		resolver: &identityResolver{
+			plcURL:         plcURL,
+			handleResolver: handleResolver,
+			httpClient:     httpClient,
+		},
+	}
+	if sessionCacheFile != "" {
+		connector.sessionCache = newSessionCache(sessionCacheFile, sessionCacheKey)
+	}
+
 	ctx = tflog.SetField(ctx, "bluesky_pds_host", pdsHost)
 	ctx = tflog.SetField(ctx, "bluesky_handle", handle)
 	ctx = tflog.SetField(ctx, "bluesky_password", password)
@@ -173,57 +577,185 @@ func (p *bskyProvider) Configure(ctx context.Context, req provider.ConfigureRequ
 	ctx = tflog.MaskFieldValuesWithFieldKeys(ctx, "bluesky_password")
 	ctx = tflog.MaskFieldValuesWithFieldKeys(ctx, "bluesky_pds_admin_password")
 
-	tflog.Debug(ctx, "Creating Bluesky client")
+	// Create a new Bluesky client with the configuration values, and log in.
+	// The session refreshes its tokens whenever the access token expires, so
+	// long running applies don't fail midway.
+	var client *xrpc.Client
+	switch {
+	case adminOnly:
+		tflog.Debug(ctx, "Creating Bluesky client without a default account")
+
+		// Without a session, Auth stays nil. Resources acting on behalf of an
+		// account check for it.
+		client = &xrpc.Client{
+			Host:   pdsHost,
+			Client: httpClient,
+		}
+	case config.OAuth != nil:
+		tflog.Debug(ctx, "Creating Bluesky client")
+
+		oauth, diags := config.OAuth.oauthConfig()
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		session := newOAuthSession(pdsHost, oauth, httpClient)
+		if err := session.login(ctx); err != nil {
+			resp.Diagnostics.AddError(
+				"Unable to create Bluesky API client",
+				"An unexpected error occurred when creating the Bluesky API client. "+
+					"If the error is not clear, please contact the provider developers.\n\n"+
+					"XRPC client error: "+err.Error(),
+			)
+			return
+		}
+		client = newSessionClient(session)
+	default:
+		tflog.Debug(ctx, "Creating Bluesky client")
 
-	// Create a new Bluesky client with the configuration values, and log in
-	client := &xrpc.Client{
-		Host: pdsHost,
+		var diags diag.Diagnostics
+		client, diags = connector.connect(ctx, path.Empty(), accountCredentials{
+			pdsHost:         pdsHost,
+			handle:          handle,
+			password:        password,
+			authFactorToken: authFactorToken,
+		})
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
 	}
 	if pdsAdminpassword != "" {
 		// used by com.atproto.server.createInviteCode
 		client.AdminToken = &pdsAdminpassword
 	}
-	authInfo, err := atproto.ServerCreateSession(ctx, client, &atproto.ServerCreateSession_Input{
-		Identifier: handle,
-		Password:   password,
-	})
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Unable to create Bluesky API client",
-			"An unexpected error occurred when creating the Bluesky API client. "+
-				"If the error is not clear, please contact the provider developers.\n\n"+
-				"XRPC client error: "+err.Error(),
-		)
-		return
-	}
 
-	client.Auth = &xrpc.AuthInfo{
-		AccessJwt:  authInfo.AccessJwt,
-		RefreshJwt: authInfo.RefreshJwt,
-		Did:        authInfo.Did,
-		Handle:     authInfo.Handle,
+	data := &providerData{
+		client:   client,
+		accounts: map[string]*namedAccount{},
+		resolver: connector.resolver,
+	}
+	for name, account := range config.Accounts {
+		data.accounts[name] = &namedAccount{
+			name: name,
+			credentials: accountCredentials{
+				pdsHost:         account.PDSHost.ValueString(),
+				handle:          account.Handle.ValueString(),
+				password:        account.Password.ValueString(),
+				authFactorToken: account.AuthFactorToken.ValueString(),
+			},
+			connector: connector,
+		}
 	}
 
-	// Make the Bluesky client available during DataSource and Resource
+	// Make the Bluesky clients available during DataSource and Resource
 	// type Configure methods.
-	resp.DataSourceData = client
-	resp.ResourceData = client
+	resp.DataSourceData = data
+	resp.ResourceData = data
 
 	tflog.Info(ctx, "Configured Bluesky client", map[string]any{"success": true})
 }
 
+// oauthConfig validates the oauth attribute and loads the token file.
+func (m *bskyProviderOAuthModel) oauthConfig() (oauthConfig, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	config := oauthConfig{
+		clientID:            m.ClientID.ValueString(),
+		clientKeyID:         m.ClientKeyID.ValueString(),
+		authorizationServer: m.AuthorizationServer.ValueString(),
+		tokenFile:           m.TokenFile.ValueString(),
+		refreshToken:        m.RefreshToken.ValueString(),
+	}
+	dpopKey := m.DPoPKey.ValueString()
+
+	if config.tokenFile != "" {
+		tokenFile, err := loadOAuthTokenFile(config.tokenFile)
+		if err != nil {
+			diags.AddAttributeError(
+				path.Root("oauth").AtName("token_file"),
+				"Invalid OAuth token file",
+				"Could not read the OAuth token file "+config.tokenFile+": "+err.Error(),
+			)
+			return config, diags
+		}
+		// The configured refresh token only seeds the token file: once the
+		// tokens were refreshed, the file holds the rotated refresh token and
+		// the configured one was already redeemed.
+		if tokenFile.RefreshToken != "" {
+			config.refreshToken = tokenFile.RefreshToken
+		}
+		if dpopKey == "" {
+			dpopKey = tokenFile.DPoPKey
+		}
+	}
+
+	if config.refreshToken == "" {
+		diags.AddAttributeError(
+			path.Root("oauth").AtName("refresh_token"),
+			"Missing OAuth refresh token",
+			"The provider cannot create the Bluesky API client as there is no OAuth refresh token. "+
+				"Set oauth.refresh_token in the configuration or point oauth.token_file to a file containing one.",
+		)
+	}
+	if dpopKey == "" {
+		diags.AddAttributeError(
+			path.Root("oauth").AtName("dpop_key"),
+			"Missing OAuth DPoP key",
+			"The provider cannot create the Bluesky API client as there is no DPoP key. "+
+				"OAuth tokens are bound to the DPoP key they were issued for, set oauth.dpop_key in the configuration or point oauth.token_file to a file containing it.",
+		)
+	} else {
+		key, err := parseECPrivateKey(dpopKey)
+		if err != nil {
+			diags.AddAttributeError(
+				path.Root("oauth").AtName("dpop_key"),
+				"Invalid OAuth DPoP key",
+				"Could not parse the DPoP key: "+err.Error(),
+			)
+		}
+		config.dpopKey = key
+	}
+	if !m.ClientKey.IsNull() {
+		key, err := parseECPrivateKey(m.ClientKey.ValueString())
+		if err != nil {
+			diags.AddAttributeError(
+				path.Root("oauth").AtName("client_key"),
+				"Invalid OAuth client key",
+				"Could not parse the client key: "+err.Error(),
+			)
+		}
+		config.clientKey = key
+	}
+
+	return config, diags
+}
+
 func (p *bskyProvider) Resources(ctx context.Context) []func() resource.Resource {
 	return []func() resource.Resource{
 		NewAccountResource,
 		NewListResource,
 		NewListItemResource,
 		NewStarterPackResource,
+		NewProfileResource,
+		NewPostResource,
+		NewThreadgateResource,
+		NewPostgateResource,
+		NewFollowResource,
+		NewBlockResource,
+		NewListBlockResource,
+		NewListMuteResource,
+		NewFeedGeneratorResource,
+		NewLabelerServiceResource,
+		NewRecordResource,
+		NewListMembersResource,
+		NewListMirrorResource,
 	}
 }
 
 func (p *bskyProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
 	return []func() datasource.DataSource{
 		NewListDataSource,
+		NewFeedGeneratorDataSource,
 	}
 }
 
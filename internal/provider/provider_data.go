@@ -0,0 +1,233 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// providerData is made available to resources and data sources by Configure.
+type providerData struct {
+	// client is the client of the account configured at the top level of the
+	// provider. Its Auth is nil when the provider doesn't log in to a default
+	// account, because it only administers the PDS or only uses named
+	// accounts.
+	client *xrpc.Client
+
+	// accounts are the named accounts of the accounts provider attribute.
+	accounts map[string]*namedAccount
+
+	// resolver resolves the DIDs of services other than the PDS, such as
+	// feed generators.
+	resolver *identityResolver
+}
+
+// namedAccount is an account of the accounts provider attribute. It only logs
+// in the first time a resource uses it, so that a configuration with many
+// accounts doesn't create sessions it doesn't need.
+type namedAccount struct {
+	name        string
+	credentials accountCredentials
+	connector   *accountConnector
+
+	mu     sync.Mutex
+	client *xrpc.Client
+	diags  diag.Diagnostics
+}
+
+// requireAccount reports a configuration error when the provider has no
+// account at all to manage records of what with.
+func (d *providerData) requireAccount(what string) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if d.client.Auth == nil && len(d.accounts) == 0 {
+		diags.AddError(
+			"Bluesky account required",
+			"Managing "+what+" requires the provider to log in to a Bluesky account, but it is configured with only the PDS admin password. "+
+				"Configure the provider with a handle and password, with oauth, or with accounts.",
+		)
+	}
+	return diags
+}
+
+// accountClient returns the client of the named account, or the client of the
+// default account when name is null or empty. Named accounts log in the first
+// time they are used.
+func (d *providerData) accountClient(ctx context.Context, name types.String) (*xrpc.Client, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	if name.ValueString() == "" {
+		if d.client.Auth == nil {
+			diags.AddAttributeError(
+				path.Root("account"),
+				"Bluesky account required",
+				"The provider doesn't log in to a default account. Set account to one of the accounts configured in the provider.",
+			)
+			return nil, diags
+		}
+		return d.client, diags
+	}
+
+	account, ok := d.accounts[name.ValueString()]
+	if !ok {
+		diags.AddAttributeError(
+			path.Root("account"),
+			"Unknown Bluesky account",
+			"The account "+name.ValueString()+" is not configured in the accounts attribute of the provider.",
+		)
+		return nil, diags
+	}
+	return account.login(ctx)
+}
+
+// publicClient returns the client to read public records with. It is the
+// client of the selected account, or the default client when no account is
+// selected, even if it isn't logged in.
+func (d *providerData) publicClient(ctx context.Context, name types.String) (*xrpc.Client, diag.Diagnostics) {
+	if name.ValueString() == "" {
+		return d.client, nil
+	}
+	return d.accountClient(ctx, name)
+}
+
+// login returns the client of the account, logging in on first use. A failed
+// login is not retried, since creating sessions is rate limited.
+func (a *namedAccount) login(ctx context.Context) (*xrpc.Client, diag.Diagnostics) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.client == nil && !a.diags.HasError() {
+		ctx = tflog.SetField(ctx, "bluesky_account", a.name)
+		tflog.Debug(ctx, "Logging in to Bluesky account")
+		a.client, a.diags = a.connector.connect(ctx, path.Root("accounts").AtMapKey(a.name), a.credentials)
+	}
+	return a.client, a.diags
+}
+
+// accountCredentials are the credentials of an account logging in with a
+// handle and password.
+type accountCredentials struct {
+	pdsHost         string
+	handle          string
+	password        string
+	authFactorToken string
+}
+
+// accountConnector logs in to accounts with a handle and password. It is
+// shared by the default account and the named accounts, so that they all use
+// the same http.Client and session cache.
+type accountConnector struct {
+	httpClient   *http.Client
+	resolver     *identityResolver
+	sessionCache *sessionCache
+}
+
+// connect discovers the PDS of the account if needed, logs in, and returns
+// the client of the account. Diagnostics are reported on the attributes below
+// attributes, which is empty for the default account.
+func (c *accountConnector) connect(ctx context.Context, attributes path.Path, credentials accountCredentials) (*xrpc.Client, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	pdsHost := credentials.pdsHost
+	if pdsHost == "" {
+		discovered, err := c.resolver.resolvePDS(ctx, credentials.handle)
+		if err != nil {
+			diags.AddAttributeError(
+				attributes.AtName("pds_host"),
+				"Unable to discover Bluesky PDS host",
+				"The provider could not discover the PDS of "+credentials.handle+" from its DID document. "+
+					"Set the PDS host in the configuration"+envHint(attributes, "BSKY_PDS_HOST")+".\n\n"+
+					"Error: "+err.Error(),
+			)
+			return nil, diags
+		}
+		tflog.Info(ctx, "Discovered Bluesky PDS host", map[string]any{"bluesky_pds_host": discovered})
+		pdsHost = discovered
+	}
+
+	sessionManager := newSessionManager(pdsHost, credentials.handle, credentials.password, credentials.authFactorToken, c.httpClient)
+	if c.sessionCache != nil {
+		sessionManager.useCache(c.sessionCache)
+	}
+	if err := sessionManager.login(ctx); err != nil {
+		switch classifyXRPCError(err) {
+		case xrpcErrorAuthFactorRequired:
+			detail := "The account " + credentials.handle + " has email two-factor authentication enabled, and a sign-in code has been emailed to it. " +
+				"Set the code in the configuration" + envHint(attributes, "BSKY_AUTH_FACTOR_TOKEN") + ", then run Terraform again."
+			if credentials.authFactorToken != "" {
+				detail = "The sign-in code for " + credentials.handle + " was rejected. Codes expire after a few minutes and can only be used once. " +
+					"Request a new code by running Terraform without one, then set it in the configuration" + envHint(attributes, "BSKY_AUTH_FACTOR_TOKEN") + "."
+			}
+			diags.AddAttributeError(
+				attributes.AtName("auth_factor_token"),
+				"Bluesky sign-in code required",
+				detail+" Set session_cache_file to resume the session on later runs without a new code.",
+			)
+			return nil, diags
+		case xrpcErrorTransport:
+			diags.AddAttributeError(
+				attributes.AtName("pds_host"),
+				"Unable to reach Bluesky PDS",
+				"Could not connect to "+pdsHost+" to sign in as "+credentials.handle+". "+
+					"Check the PDS host in the configuration"+envHint(attributes, "BSKY_PDS_HOST")+".\n\n"+
+					"Error: "+err.Error(),
+			)
+			return nil, diags
+		}
+		diags.AddError(
+			"Unable to create Bluesky API client",
+			"An unexpected error occurred when creating the Bluesky API client for "+credentials.handle+". "+
+				"If the error is not clear, please contact the provider developers.\n\n"+
+				"XRPC client error: "+err.Error(),
+		)
+		return nil, diags
+	}
+
+	return newSessionClient(sessionManager), diags
+}
+
+// newSessionClient returns a client authenticated by the session.
+func newSessionClient(session authSession) *xrpc.Client {
+	return &xrpc.Client{
+		Host:   session.pdsEndpoint(),
+		Client: session.httpClient(),
+		Auth:   session.authInfo(),
+	}
+}
+
+// envHint mentions the environment variable that can be used instead of an
+// attribute of the default account.
+func envHint(attributes path.Path, name string) string {
+	if !attributes.Equal(path.Empty()) {
+		return ""
+	}
+	return " or use the " + name + " environment variable"
+}
+
+// accountResourceAttribute returns the account attribute of the resources
+// managing records of an account.
+func accountResourceAttribute() schema.StringAttribute {
+	return schema.StringAttribute{
+		MarkdownDescription: "Name of the account in the `accounts` provider attribute the record belongs to. Defaults to the account the provider logs in to with `handle` and `password` or `oauth`.",
+		Optional:            true,
+		PlanModifiers: []planmodifier.String{
+			// Imported resources start out without an account, which the
+			// configuration then sets without replacing the record.
+			stringplanmodifier.RequiresReplaceIf(
+				func(_ context.Context, req planmodifier.StringRequest, resp *stringplanmodifier.RequiresReplaceIfFuncResponse) {
+					resp.RequiresReplace = !req.StateValue.IsNull()
+				},
+				"The record is replaced when the account changes.",
+				"The record is replaced when the account changes.",
+			),
+		},
+	}
+}
@@ -0,0 +1,557 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ resource.Resource                = &recordResource{}
+	_ resource.ResourceWithConfigure   = &recordResource{}
+	_ resource.ResourceWithImportState = &recordResource{}
+)
+
+// NewRecordResource is a helper function to simplify the provider implementation.
+func NewRecordResource() resource.Resource {
+	return &recordResource{}
+}
+
+// recordResource is the resource implementation. It manages records of any
+// collection as JSON, so it calls the com.atproto.repo endpoints directly
+// instead of decoding the records into the lexicon types known to indigo.
+type recordResource struct {
+	data *providerData
+}
+
+type recordResourceModel struct {
+	Account    types.String `tfsdk:"account"`
+	Uri        types.String `tfsdk:"uri"`
+	Cid        types.String `tfsdk:"cid"`
+	Collection types.String `tfsdk:"collection"`
+	Rkey       types.String `tfsdk:"rkey"`
+	Record     types.String `tfsdk:"record"`
+}
+
+// rawRecordOutput is the output of com.atproto.repo.getRecord, keeping the
+// record as JSON.
+type rawRecordOutput struct {
+	Uri   string          `json:"uri"`
+	Cid   *string         `json:"cid,omitempty"`
+	Value json.RawMessage `json:"value"`
+}
+
+// rawWriteOutput is the output of com.atproto.repo.createRecord and putRecord.
+type rawWriteOutput struct {
+	Uri string `json:"uri"`
+	Cid string `json:"cid"`
+}
+
+// Metadata returns the resource type name.
+func (r *recordResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_record"
+}
+
+// Schema defines the schema for the resource.
+func (r *recordResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Manage a record of any collection, such as records of custom lexicons or of Bluesky record types the provider has no resource for. " +
+			"The PDS only validates records of the lexicons it knows.",
+		Attributes: map[string]schema.Attribute{
+			"account": accountResourceAttribute(),
+			"collection": schema.StringAttribute{
+				MarkdownDescription: "NSID of the collection of the record, such as `com.example.config`",
+				Required:            true,
+				Validators: []validator.String{
+					nsidValidator{},
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"rkey": schema.StringAttribute{
+				MarkdownDescription: "Record key of the record. Generated by the PDS when not set.",
+				Optional:            true,
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"record": schema.StringAttribute{
+				MarkdownDescription: "JSON object of the record, usually written with `jsonencode`. The `$type` field defaults to `collection`. " +
+					"Records changed outside of Terraform are only reported as different when their contents changed, regardless of formatting and key order.",
+				Required: true,
+				Validators: []validator.String{
+					jsonObjectValidator{},
+				},
+			},
+			"uri": schema.StringAttribute{
+				MarkdownDescription: "Atproto URI",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"cid": schema.StringAttribute{
+				MarkdownDescription: "Commit ID generated by Bluesky",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+func (r *recordResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	// Retrieve values from a plan.
+	var plan recordResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
+		return
+	}
+
+	client, diags := r.data.accountClient(ctx, plan.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	record, err := recordBody(plan.Record.ValueString(), plan.Collection.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("record"),
+			"Invalid record",
+			"Could not use the record: "+err.Error(),
+		)
+		return
+	}
+	input := map[string]any{
+		"repo":       client.Auth.Did,
+		"collection": plan.Collection.ValueString(),
+		"record":     record,
+	}
+	if !plan.Rkey.IsUnknown() && !plan.Rkey.IsNull() {
+		if _, err := syntax.ParseRecordKey(plan.Rkey.ValueString()); err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("rkey"),
+				"Invalid record key",
+				"Could not use "+plan.Rkey.ValueString()+" as record key: "+err.Error(),
+			)
+			return
+		}
+		input["rkey"] = plan.Rkey.ValueString()
+	}
+
+	// Create new record.
+	var out rawWriteOutput
+	err = client.Do(ctx, xrpc.Procedure, "application/json", "com.atproto.repo.createRecord", nil, input, &out)
+	if isSwapConflict(err) {
+		resp.Diagnostics.AddError("Repo changed outside of Terraform", swapConflictDetail("The repo "+client.Auth.Did, err))
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error creating record",
+			"Could not create "+plan.Collection.ValueString()+" record, unexpected error: "+err.Error(),
+		)
+		return
+	}
+	uri, err := syntax.ParseATURI(out.Uri)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error creating record",
+			"The PDS returned an invalid record URI "+out.Uri+": "+err.Error(),
+		)
+		return
+	}
+
+	// Map response body to schema and populate Computed attribute values.
+	plan.Uri = types.StringValue(out.Uri)
+	plan.Cid = types.StringValue(out.Cid)
+	plan.Rkey = types.StringValue(uri.RecordKey().String())
+
+	// Set state to fully populated data.
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Read refreshes the Terraform state with the latest data.
+func (r *recordResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	// Get current state.
+	var state recordResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, diags := r.data.accountClient(ctx, state.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	uri, err := syntax.ParseATURI(state.Uri.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Invalid record URI",
+			"Could not parse record URI "+state.Uri.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+	params := map[string]any{
+		"repo":       uri.Authority().String(),
+		"collection": uri.Collection().String(),
+		"rkey":       uri.RecordKey().String(),
+	}
+	var out rawRecordOutput
+	err = client.Do(ctx, xrpc.Query, "", "com.atproto.repo.getRecord", params, nil, &out)
+	if isRecordNotFound(err) {
+		// The record was deleted outside of Terraform.
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error reading record",
+			"Could not read record URI "+state.Uri.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+
+	// Overwrite with refreshed state using the repository record. The JSON of
+	// the state is kept as written when the record didn't change, so that
+	// only actual changes show up as drift.
+	state.Collection = types.StringValue(uri.Collection().String())
+	state.Rkey = types.StringValue(uri.RecordKey().String())
+	if out.Cid != nil {
+		state.Cid = types.StringValue(*out.Cid)
+	}
+	equal, err := recordJSONEqual(state.Record.ValueString(), string(out.Value), state.Collection.ValueString())
+	if err != nil || !equal {
+		record, err := normalizeRecordJSON(string(out.Value), state.Collection.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Error reading record",
+				"Could not decode record "+state.Uri.ValueString()+": "+err.Error(),
+			)
+			return
+		}
+		state.Record = types.StringValue(record)
+	}
+
+	// Set refreshed state.
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Update puts the new record, swapped against the record of the prior state so
+// that changes made outside of Terraform since the last refresh aren't
+// overwritten.
+func (r *recordResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	// Retrieve values from a plan.
+	var plan recordResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
+		return
+	}
+
+	var state recordResourceModel
+	diags = req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, diags := r.data.accountClient(ctx, plan.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	record, err := recordBody(plan.Record.ValueString(), plan.Collection.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("record"),
+			"Invalid record",
+			"Could not use the record: "+err.Error(),
+		)
+		return
+	}
+	uri, err := syntax.ParseATURI(plan.Uri.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Invalid record URI",
+			"Could not parse record URI "+plan.Uri.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+	input := map[string]any{
+		"repo":       uri.Authority().String(),
+		"collection": uri.Collection().String(),
+		"rkey":       uri.RecordKey().String(),
+		"record":     record,
+		"swapRecord": state.Cid.ValueString(),
+	}
+	var out rawWriteOutput
+	err = client.Do(ctx, xrpc.Procedure, "application/json", "com.atproto.repo.putRecord", nil, input, &out)
+	if isSwapConflict(err) {
+		resp.Diagnostics.AddError("Record changed outside of Terraform", swapConflictDetail("The record "+plan.Uri.ValueString(), err))
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to update record",
+			"Could not update record "+plan.Uri.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+
+	// Update resource state.
+	plan.Cid = types.StringValue(out.Cid)
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Delete deletes the resource and removes the Terraform state on success.
+func (r *recordResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	// Retrieve values from state.
+	var state recordResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, diags := r.data.accountClient(ctx, state.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	uri, err := syntax.ParseATURI(state.Uri.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Invalid record URI",
+			"Could not parse record URI "+state.Uri.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+	input := map[string]any{
+		"repo":       uri.Authority().String(),
+		"collection": uri.Collection().String(),
+		"rkey":       uri.RecordKey().String(),
+	}
+	if cid := swapRecord(state.Cid); cid != nil {
+		input["swapRecord"] = *cid
+	}
+	err = client.Do(ctx, xrpc.Procedure, "application/json", "com.atproto.repo.deleteRecord", nil, input, nil)
+	if isSwapConflict(err) {
+		resp.Diagnostics.AddError("Record changed outside of Terraform", swapConflictDetail("The record "+state.Uri.ValueString(), err))
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error deleting record",
+			"Could not delete record, error: "+err.Error(),
+		)
+	}
+}
+
+// Configure adds the provider configured client to the resource.
+func (r *recordResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Add a nil check when handling ProviderData because Terraform
+	// sets that data after it calls the ConfigureProvider RPC.
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	resp.Diagnostics.Append(data.requireAccount("records")...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r.data = data
+}
+
+func (r *recordResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	// Retrieve import ID and save to uri attribute.
+	resource.ImportStatePassthroughID(ctx, path.Root("uri"), req, resp)
+}
+
+// decodeRecordJSON decodes the JSON object of a record, keeping numbers as
+// they are written.
+func decodeRecordJSON(data string) (map[string]any, error) {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(data)))
+	decoder.UseNumber()
+	var record map[string]any
+	if err := decoder.Decode(&record); err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, fmt.Errorf("the record must be a JSON object")
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("unexpected data after the JSON object")
+	}
+	return record, nil
+}
+
+// recordBody returns the record to write from its JSON, with the $type of
+// collection unless it has one already. A $type of another collection is an
+// error, since the PDS would reject the record.
+func recordBody(data string, collection string) (map[string]any, error) {
+	record, err := decodeRecordJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	switch recordType := record["$type"].(type) {
+	case nil:
+		record["$type"] = collection
+	case string:
+		if recordType != collection {
+			return nil, fmt.Errorf("$type %s doesn't match the collection %s", recordType, collection)
+		}
+	default:
+		return nil, fmt.Errorf("$type must be a string")
+	}
+	return record, nil
+}
+
+// recordJSONEqual reports whether two JSON records of collection are the same
+// once written, regardless of formatting, key order, the notation of numbers
+// and an omitted $type.
+func recordJSONEqual(a string, b string, collection string) (bool, error) {
+	recordA, err := recordBody(a, collection)
+	if err != nil {
+		return false, err
+	}
+	recordB, err := recordBody(b, collection)
+	if err != nil {
+		return false, err
+	}
+	return jsonValuesEqual(recordA, recordB), nil
+}
+
+// jsonValuesEqual reports whether two values decoded by decodeRecordJSON are
+// the same, comparing numbers by value so that 1 and 1.0 or 100 and 1e2 are
+// equal.
+func jsonValuesEqual(a any, b any) bool {
+	switch a := a.(type) {
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for key, value := range a {
+			other, ok := b[key]
+			if !ok || !jsonValuesEqual(value, other) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !jsonValuesEqual(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		numberA, _, errA := big.ParseFloat(a.String(), 10, jsonNumberPrecision, big.ToNearestEven)
+		numberB, _, errB := big.ParseFloat(b.String(), 10, jsonNumberPrecision, big.ToNearestEven)
+		if errA != nil || errB != nil {
+			return a == b
+		}
+		return numberA.Cmp(numberB) == 0
+	default:
+		return a == b
+	}
+}
+
+// jsonNumberPrecision is the precision in bits numbers are compared with,
+// which holds any integer of the data model and more digits of floats than
+// JSON encoders write.
+const jsonNumberPrecision = 256
+
+// normalizeRecordJSON returns the JSON of a record of collection as written by
+// jsonencode, with sorted keys and without the default $type.
+func normalizeRecordJSON(data string, collection string) (string, error) {
+	record, err := recordBody(data, collection)
+	if err != nil {
+		return "", err
+	}
+	delete(record, "$type")
+	normalized, err := json.Marshal(record)
+	if err != nil {
+		return "", err
+	}
+	return string(normalized), nil
+}
+
+// jsonObjectValidator checks that a string is a JSON object.
+type jsonObjectValidator struct{}
+
+func (v jsonObjectValidator) Description(_ context.Context) string {
+	return "value must be a JSON object"
+}
+
+func (v jsonObjectValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v jsonObjectValidator) ValidateString(_ context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	if _, err := decodeRecordJSON(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid JSON object",
+			"Could not decode the value as JSON object: "+err.Error(),
+		)
+	}
+}
@@ -0,0 +1,350 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// authSession is implemented by the supported authentication modes. A session
+// is installed as the transport of the shared xrpc.Client's http.Client and
+// authenticates the requests the client makes.
+type authSession interface {
+	http.RoundTripper
+
+	// login creates the session.
+	login(ctx context.Context) error
+	// authInfo returns the account the session is for.
+	authInfo() *xrpc.AuthInfo
+	// pdsEndpoint returns the PDS the session is established with.
+	pdsEndpoint() string
+	// httpClient returns an http.Client that routes its requests through the
+	// session.
+	httpClient() *http.Client
+}
+
+var _ authSession = &sessionManager{}
+
+// sessionManager keeps the session of the shared xrpc.Client alive for the
+// lifetime of the provider. It is installed as the transport of the client's
+// http.Client so that every resource and data source benefits from it without
+// having to handle expired tokens themselves.
+//
+// When a request fails because the access token expired, the session is
+// refreshed once under a lock (or re-created from the stored credentials if
+// the refresh token was rejected as well) and the request is retried with the
+// new access token.
+//
+// With a session cache, the refresh token is persisted after every login and
+// refresh, and the next run resumes the session instead of creating a new
+// one.
+type sessionManager struct {
+	identifier string
+	password   string
+	// authFactorToken is the sign-in code emailed to accounts with email
+	// two-factor authentication. Codes can only be used once, so it is only
+	// good for the first session; combine it with a session cache to keep
+	// the session across runs.
+	authFactorToken string
+
+	cache    *sessionCache
+	cacheKey string
+
+	// base is the http.Client used to talk to the PDS, both for the session
+	// management calls and for the requests of the shared client.
+	base *http.Client
+
+	mu   sync.RWMutex
+	auth xrpc.AuthInfo
+	// pdsHost starts out as the configured host, and follows the DID
+	// document of the account when the session was created with an entryway.
+	pdsHost string
+}
+
+func newSessionManager(host string, identifier string, password string, authFactorToken string, base *http.Client) *sessionManager {
+	return &sessionManager{
+		pdsHost:         host,
+		identifier:      identifier,
+		password:        password,
+		authFactorToken: authFactorToken,
+		base:            base,
+	}
+}
+
+// useCache persists the session in cache, under the configured PDS host and
+// identifier.
+func (s *sessionManager) useCache(cache *sessionCache) {
+	s.cache = cache
+	s.cacheKey = sessionCacheKey(s.pdsHost, s.identifier)
+}
+
+// login resumes the cached session if there is one, and otherwise creates a
+// new session from the stored credentials.
+func (s *sessionManager) login(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cache != nil {
+		entry, err := s.cache.load(s.cacheKey)
+		if err != nil {
+			tflog.Warn(ctx, "Could not read the Bluesky session cache", map[string]any{"error": err.Error()})
+		}
+		if entry != nil {
+			configuredHost := s.pdsHost
+			s.pdsHost = entry.PDSHost
+			s.auth = xrpc.AuthInfo{
+				RefreshJwt: entry.RefreshJwt,
+				Did:        entry.Did,
+				Handle:     entry.Handle,
+			}
+			err := s.refreshSession(ctx)
+			if err == nil {
+				tflog.Debug(ctx, "Resumed cached Bluesky session")
+				return nil
+			}
+			tflog.Debug(ctx, "Cached Bluesky session rejected, creating a new session", map[string]any{"error": err.Error()})
+			s.pdsHost = configuredHost
+			s.auth = xrpc.AuthInfo{}
+		}
+	}
+
+	return s.createSession(ctx, true)
+}
+
+// createSession calls com.atproto.server.createSession. With followDidDoc, the
+// session switches to the PDS in the DID document of the account. The caller
+// must hold the write lock.
+func (s *sessionManager) createSession(ctx context.Context, followDidDoc bool) error {
+	sessionClient := &xrpc.Client{
+		Host:   s.pdsHost,
+		Client: s.base,
+	}
+	input := &atproto.ServerCreateSession_Input{
+		Identifier: s.identifier,
+		Password:   s.password,
+	}
+	if s.authFactorToken != "" {
+		input.AuthFactorToken = &s.authFactorToken
+	}
+	authInfo, err := atproto.ServerCreateSession(ctx, sessionClient, input)
+	if err != nil {
+		return err
+	}
+
+	s.auth = xrpc.AuthInfo{
+		AccessJwt:  authInfo.AccessJwt,
+		RefreshJwt: authInfo.RefreshJwt,
+		Did:        authInfo.Did,
+		Handle:     authInfo.Handle,
+	}
+
+	// An entryway such as bsky.social hands out sessions for accounts hosted
+	// on other PDSes. Talk to the actual PDS from now on.
+	doc, err := didDocumentFromSession(authInfo.DidDoc)
+	if err != nil {
+		return err
+	}
+	if doc != nil && followDidDoc {
+		if endpoint := doc.pdsEndpoint(); endpoint != "" && strings.TrimSuffix(endpoint, "/") != strings.TrimSuffix(s.pdsHost, "/") {
+			tflog.Info(ctx, "Switching to the PDS from the account's DID document", map[string]any{"bluesky_pds_host": endpoint})
+			s.pdsHost = endpoint
+		}
+	}
+
+	s.saveSession(ctx)
+	return nil
+}
+
+// refreshSession calls com.atproto.server.refreshSession. The caller must hold
+// the write lock.
+func (s *sessionManager) refreshSession(ctx context.Context) error {
+	// com.atproto.server.refreshSession authenticates with the refresh token
+	// in place of the access token.
+	refreshClient := &xrpc.Client{
+		Host:   s.pdsHost,
+		Client: s.base,
+		Auth: &xrpc.AuthInfo{
+			AccessJwt: s.auth.RefreshJwt,
+		},
+	}
+	refreshed, err := atproto.ServerRefreshSession(ctx, refreshClient)
+	if err != nil {
+		return err
+	}
+
+	s.auth.AccessJwt = refreshed.AccessJwt
+	s.auth.RefreshJwt = refreshed.RefreshJwt
+	if refreshed.Did != "" {
+		s.auth.Did = refreshed.Did
+		s.auth.Handle = refreshed.Handle
+	}
+	s.saveSession(ctx)
+	return nil
+}
+
+// saveSession stores the current refresh token in the session cache. Failing
+// to do so only costs a new session on the next run, so it isn't an error. The
+// caller must hold the write lock.
+func (s *sessionManager) saveSession(ctx context.Context) {
+	if s.cache == nil {
+		return
+	}
+	err := s.cache.store(s.cacheKey, sessionCacheEntry{
+		PDSHost:    s.pdsHost,
+		Did:        s.auth.Did,
+		Handle:     s.auth.Handle,
+		RefreshJwt: s.auth.RefreshJwt,
+	})
+	if err != nil {
+		tflog.Warn(ctx, "Could not write the Bluesky session cache", map[string]any{"error": err.Error()})
+	}
+}
+
+// refresh replaces the access token that was rejected as expired. If another
+// request already refreshed the session while this one was waiting for the
+// lock, the new token is kept and no further call is made.
+func (s *sessionManager) refresh(ctx context.Context, expiredAccessJwt string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.auth.AccessJwt != expiredAccessJwt {
+		return nil
+	}
+
+	tflog.Debug(ctx, "Refreshing Bluesky session")
+
+	err := s.refreshSession(ctx)
+	if err == nil {
+		return nil
+	}
+
+	tflog.Debug(ctx, "Bluesky refresh token rejected, creating a new session", map[string]any{"error": err.Error()})
+
+	// The host of the shared xrpc.Client is fixed after Configure, so the new
+	// session stays on the PDS the requests are sent to.
+	return s.createSession(ctx, false)
+}
+
+// authInfo returns a copy of the current session.
+func (s *sessionManager) authInfo() *xrpc.AuthInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	authInfo := s.auth
+	return &authInfo
+}
+
+func (s *sessionManager) pdsEndpoint() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.pdsHost
+}
+
+func (s *sessionManager) accessJwt() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.auth.AccessJwt
+}
+
+// httpClient returns a copy of the base http.Client which routes its requests
+// through the session manager.
+func (s *sessionManager) httpClient() *http.Client {
+	httpClient := *s.base
+	httpClient.Transport = s
+	return &httpClient
+}
+
+// RoundTrip implements http.RoundTripper. Requests authenticated with a
+// bearer token always use the current access token of the session, since the
+// token stored on the shared xrpc.Client is never updated after Configure.
+// Requests with any other authentication (such as the admin basic auth) are
+// passed through untouched.
+func (s *sessionManager) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !strings.HasPrefix(req.Header.Get("Authorization"), "Bearer ") {
+		return s.transport().RoundTrip(req)
+	}
+
+	// Keep the body around so the request can be sent a second time.
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	accessJwt := s.accessJwt()
+	resp, err := s.send(req, body, accessJwt)
+	if err != nil || !isExpiredTokenResponse(resp) {
+		return resp, err
+	}
+	_ = resp.Body.Close()
+
+	if err := s.refresh(req.Context(), accessJwt); err != nil {
+		return nil, fmt.Errorf("could not refresh expired Bluesky session: %w", err)
+	}
+
+	return s.send(req, body, s.accessJwt())
+}
+
+func (s *sessionManager) send(req *http.Request, body []byte, accessJwt string) (*http.Response, error) {
+	r := req.Clone(req.Context())
+	r.Header.Set("Authorization", "Bearer "+accessJwt)
+	if body != nil {
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+		r.ContentLength = int64(len(body))
+	}
+	return s.transport().RoundTrip(r)
+}
+
+func (s *sessionManager) transport() http.RoundTripper {
+	if s.base.Transport == nil {
+		return http.DefaultTransport
+	}
+	return s.base.Transport
+}
+
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("could not read request body: %w", err)
+	}
+	return body, nil
+}
+
+// isExpiredTokenResponse reports whether the PDS rejected the request because
+// the access token expired. The response body is restored so that it can
+// still be decoded by the xrpc client.
+func isExpiredTokenResponse(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusBadRequest && resp.StatusCode != http.StatusUnauthorized {
+		return false
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+
+	var xrpcErr xrpc.XRPCError
+	if err := json.Unmarshal(body, &xrpcErr); err != nil {
+		return false
+	}
+	return xrpcErr.ErrStr == "ExpiredToken"
+}
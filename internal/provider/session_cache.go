@@ -0,0 +1,170 @@
+package provider
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+const sessionCacheKDFIterations = 600_000
+
+// sessionCache persists refresh JWTs between provider runs, so that plans and
+// applies don't have to call com.atproto.server.createSession every time.
+// The file is encrypted with AES-GCM using a key derived from a user-supplied
+// passphrase.
+type sessionCache struct {
+	path       string
+	passphrase string
+
+	mu sync.Mutex
+	// The key derived for salt, so that it is only derived once per run.
+	salt []byte
+	key  []byte
+}
+
+// sessionCacheEntry is the session stored for a PDS host and handle.
+type sessionCacheEntry struct {
+	// PDSHost is the PDS the session was created with, which differs from
+	// the configured host when logging in through an entryway.
+	PDSHost    string `json:"pds_host"`
+	Did        string `json:"did"`
+	Handle     string `json:"handle"`
+	RefreshJwt string `json:"refresh_jwt"`
+}
+
+// sessionCacheFile is the on-disk format of the session cache.
+type sessionCacheFile struct {
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+func newSessionCache(path string, passphrase string) *sessionCache {
+	return &sessionCache{
+		path:       path,
+		passphrase: passphrase,
+	}
+}
+
+// sessionCacheKey returns the key a session is stored under.
+func sessionCacheKey(pdsHost string, handle string) string {
+	return pdsHost + " " + handle
+}
+
+// load returns the cached session for key, or nil if there is none.
+func (c *sessionCache) load(key string) (*sessionCacheEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := c.read()
+	if err != nil {
+		return nil, err
+	}
+	entry, ok := entries[key]
+	if !ok {
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+// store saves the session for key, keeping the sessions of other accounts. A
+// cache file that can't be decrypted, for instance because the passphrase
+// changed, is replaced.
+func (c *sessionCache) store(key string, entry sessionCacheEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := c.read()
+	if err != nil {
+		entries = map[string]sessionCacheEntry{}
+	}
+	entries[key] = entry
+	return c.write(entries)
+}
+
+func (c *sessionCache) read() (map[string]sessionCacheEntry, error) {
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]sessionCacheEntry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var file sessionCacheFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("could not decode session cache %s: %w", c.path, err)
+	}
+	aead, err := c.aead(file.Salt)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, file.Nonce, file.Ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt session cache %s, was the key changed?", c.path)
+	}
+
+	entries := map[string]sessionCacheEntry{}
+	if err := json.Unmarshal(plaintext, &entries); err != nil {
+		return nil, fmt.Errorf("could not decode session cache %s: %w", c.path, err)
+	}
+	return entries, nil
+}
+
+func (c *sessionCache) write(entries map[string]sessionCacheEntry) error {
+	plaintext, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	salt := c.salt
+	if salt == nil {
+		salt = make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return err
+		}
+	}
+	aead, err := c.aead(salt)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(sessionCacheFile{
+		Salt:       salt,
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, plaintext, nil),
+	})
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(c.path, data)
+}
+
+// aead returns the cipher for the given salt. The caller must hold the lock.
+func (c *sessionCache) aead(salt []byte) (cipher.AEAD, error) {
+	if c.key == nil || string(c.salt) != string(salt) {
+		key, err := pbkdf2.Key(sha256.New, c.passphrase, salt, sessionCacheKDFIterations, 32)
+		if err != nil {
+			return nil, err
+		}
+		c.salt = salt
+		c.key = key
+	}
+
+	block, err := aes.NewCipher(c.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
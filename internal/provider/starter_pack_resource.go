@@ -9,7 +9,6 @@ import (
 	"github.com/bluesky-social/indigo/api/bsky"
 	"github.com/bluesky-social/indigo/atproto/syntax"
 	"github.com/bluesky-social/indigo/lex/util"
-	"github.com/bluesky-social/indigo/xrpc"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
@@ -32,11 +31,13 @@ func NewStarterPackResource() resource.Resource {
 
 // starterPackResource is the resource implementation.
 type starterPackResource struct {
-	client *xrpc.Client
+	data *providerData
 }
 
 type starterPackResourceModel struct {
+	Account     types.String `tfsdk:"account"`
 	Uri         types.String `tfsdk:"uri"`
+	Cid         types.String `tfsdk:"cid"`
 	ListUri     types.String `tfsdk:"list_uri"`
 	Name        types.String `tfsdk:"name"`
 	Description types.String `tfsdk:"description"`
@@ -52,6 +53,7 @@ func (r *starterPackResource) Schema(_ context.Context, _ resource.SchemaRequest
 	resp.Schema = schema.Schema{
 		MarkdownDescription: "Manage Starter Packs",
 		Attributes: map[string]schema.Attribute{
+			"account": accountResourceAttribute(),
 			"name": schema.StringAttribute{
 				MarkdownDescription: "The title of the Starter Pack",
 				Required:            true,
@@ -74,6 +76,13 @@ func (r *starterPackResource) Schema(_ context.Context, _ resource.SchemaRequest
 					stringplanmodifier.UseStateForUnknown(),
 				},
 			},
+			"cid": schema.StringAttribute{
+				MarkdownDescription: "Commit ID generated by Bluesky",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					recordCidPlanModifier{recordAttributes: []string{"name", "description", "list_uri"}},
+				},
+			},
 		},
 	}
 }
@@ -87,6 +96,12 @@ func (l *starterPackResource) Create(ctx context.Context, req resource.CreateReq
 		return
 	}
 
+	client, diags := l.data.accountClient(ctx, plan.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	// Generate API request body from plan.
 	item := &bsky.GraphStarterpack{
 		List:        plan.ListUri.ValueString(),
@@ -95,13 +110,17 @@ func (l *starterPackResource) Create(ctx context.Context, req resource.CreateReq
 		Description: plan.Description.ValueStringPointer(),
 	}
 	createRecordInput := &atproto.RepoCreateRecord_Input{
-		Repo:       l.client.Auth.Did,
+		Repo:       client.Auth.Did,
 		Collection: "app.bsky.graph.starterpack",
 		Record:     &util.LexiconTypeDecoder{Val: item},
 	}
 
 	// Create new pack.
-	record, err := atproto.RepoCreateRecord(ctx, l.client, createRecordInput)
+	record, err := atproto.RepoCreateRecord(ctx, client, createRecordInput)
+	if isSwapConflict(err) {
+		resp.Diagnostics.AddError("Repo changed outside of Terraform", swapConflictDetail("The repo "+client.Auth.Did, err))
+		return
+	}
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Error creating starter pack",
@@ -112,6 +131,7 @@ func (l *starterPackResource) Create(ctx context.Context, req resource.CreateReq
 
 	// Map response body to schema and populate Computed attribute values.
 	plan.Uri = types.StringValue(record.Uri)
+	plan.Cid = types.StringValue(record.Cid)
 
 	// Set state to fully populated data.
 	diags = resp.State.Set(ctx, plan)
@@ -131,6 +151,12 @@ func (l *starterPackResource) Read(ctx context.Context, req resource.ReadRequest
 		return
 	}
 
+	client, diags := l.data.accountClient(ctx, state.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	// Generate API request body from plan.
 	uri, err := syntax.ParseATURI(state.Uri.ValueString())
 	if err != nil {
@@ -140,7 +166,12 @@ func (l *starterPackResource) Read(ctx context.Context, req resource.ReadRequest
 		)
 		return
 	}
-	record, err := atproto.RepoGetRecord(ctx, l.client, "", uri.Collection().String(), uri.Authority().String(), uri.RecordKey().String())
+	record, err := atproto.RepoGetRecord(ctx, client, "", uri.Collection().String(), uri.Authority().String(), uri.RecordKey().String())
+	if isRecordNotFound(err) {
+		// The starter pack was deleted outside of Terraform.
+		resp.State.RemoveResource(ctx)
+		return
+	}
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Failed to retrieve starter pack",
@@ -160,6 +191,7 @@ func (l *starterPackResource) Read(ctx context.Context, req resource.ReadRequest
 	state.Name = types.StringValue(pack.Name)
 	state.Description = types.StringValue(*pack.Description)
 	state.ListUri = types.StringValue(pack.List)
+	state.Cid = types.StringValue(*record.Cid)
 
 	// Set refreshed state.
 	diags = resp.State.Set(ctx, &state)
@@ -179,6 +211,20 @@ func (l *starterPackResource) Update(ctx context.Context, req resource.UpdateReq
 		return
 	}
 
+	// Get the new values from the plan
+	var plan starterPackResourceModel
+	diags = req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, diags := l.data.accountClient(ctx, plan.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	// Generate API request body from plan.
 	uri, err := syntax.ParseATURI(state.Uri.ValueString())
 	if err != nil {
@@ -188,7 +234,7 @@ func (l *starterPackResource) Update(ctx context.Context, req resource.UpdateReq
 		)
 		return
 	}
-	record, err := atproto.RepoGetRecord(ctx, l.client, "", uri.Collection().String(), uri.Authority().String(), uri.RecordKey().String())
+	record, err := atproto.RepoGetRecord(ctx, client, "", uri.Collection().String(), uri.Authority().String(), uri.RecordKey().String())
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Failed to retrieve starter pack",
@@ -205,14 +251,6 @@ func (l *starterPackResource) Update(ctx context.Context, req resource.UpdateReq
 		return
 	}
 
-	// Get the new values from the plan
-	var plan starterPackResourceModel
-	diags = req.Plan.Get(ctx, &plan)
-	resp.Diagnostics.Append(diags...)
-	if resp.Diagnostics.HasError() {
-		return
-	}
-
 	// Update the pack with new values from the plan
 	pack.Name = plan.Name.ValueString()
 	pack.Description = plan.Description.ValueStringPointer()
@@ -223,12 +261,16 @@ func (l *starterPackResource) Update(ctx context.Context, req resource.UpdateReq
 		Collection: uri.Collection().String(),
 		Repo:       uri.Authority().String(),
 		Rkey:       uri.RecordKey().String(),
-		SwapRecord: record.Cid,
+		SwapRecord: swapRecord(state.Cid),
 		Record: &util.LexiconTypeDecoder{
 			Val: pack,
 		},
 	}
-	_, err = atproto.RepoPutRecord(ctx, l.client, putRecordInput)
+	updatedRecord, err := atproto.RepoPutRecord(ctx, client, putRecordInput)
+	if isSwapConflict(err) {
+		resp.Diagnostics.AddError("Starter pack changed outside of Terraform", swapConflictDetail("The starter pack "+state.Uri.ValueString(), err))
+		return
+	}
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Failed to update starter pack",
@@ -238,8 +280,10 @@ func (l *starterPackResource) Update(ctx context.Context, req resource.UpdateReq
 	}
 
 	// Update state with new values
+	state.Account = plan.Account
 	state.Name = plan.Name
 	state.Description = plan.Description
+	state.Cid = types.StringValue(updatedRecord.Cid)
 
 	diags = resp.State.Set(ctx, state)
 	resp.Diagnostics.Append(diags...)
@@ -258,6 +302,12 @@ func (l *starterPackResource) Delete(ctx context.Context, req resource.DeleteReq
 		return
 	}
 
+	client, diags := l.data.accountClient(ctx, state.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	// Delete existing list.
 	uri, err := syntax.ParseATURI(state.Uri.ValueString())
 	if err != nil {
@@ -271,8 +321,13 @@ func (l *starterPackResource) Delete(ctx context.Context, req resource.DeleteReq
 		Collection: uri.Collection().String(),
 		Repo:       uri.Authority().String(),
 		Rkey:       uri.RecordKey().String(),
+		SwapRecord: swapRecord(state.Cid),
+	}
+	_, err = atproto.RepoDeleteRecord(ctx, client, deleteRequest)
+	if isSwapConflict(err) {
+		resp.Diagnostics.AddError("Starter pack changed outside of Terraform", swapConflictDetail("The starter pack "+state.Uri.ValueString(), err))
+		return
 	}
-	_, err = atproto.RepoDeleteRecord(ctx, l.client, deleteRequest)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Error deleting starter pack",
@@ -289,18 +344,23 @@ func (l *starterPackResource) Configure(_ context.Context, req resource.Configur
 		return
 	}
 
-	client, ok := req.ProviderData.(*xrpc.Client)
+	data, ok := req.ProviderData.(*providerData)
 
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Data Source Configure Type",
-			fmt.Sprintf("Expected *xrpc.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+			fmt.Sprintf("Expected *providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
 		)
 
 		return
 	}
 
-	l.client = client
+	resp.Diagnostics.Append(data.requireAccount("starter packs")...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	l.data = data
 }
 
 func (l *starterPackResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
@@ -0,0 +1,326 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/bluesky-social/indigo/lex/util"
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ resource.Resource                = &subjectRecordResource{}
+	_ resource.ResourceWithConfigure   = &subjectRecordResource{}
+	_ resource.ResourceWithImportState = &subjectRecordResource{}
+)
+
+// subjectRecord describes a record which only points at a subject, such as a
+// follow or a block. Changing the subject replaces the record.
+type subjectRecord struct {
+	// typeName is the resource type name without the provider prefix.
+	typeName    string
+	description string
+	collection  string
+	// noun names the record in diagnostics.
+	noun string
+
+	// subjectAttribute is the attribute holding the subject, and the tfsdk tag
+	// of the subject field of the models newModel returns.
+	subjectAttribute   string
+	subjectDescription string
+	// newModel returns a pointer to an empty model of the resource.
+	newModel func() subjectRecordModel
+
+	// validate checks the subject before the record is created.
+	validate func(ctx context.Context, client *xrpc.Client, subject string) diag.Diagnostics
+	// newRecord returns the record pointing at the subject.
+	newRecord func(subject string, createdAt string) *util.LexiconTypeDecoder
+	// subjectOf returns the subject of a record read from the repo, and false
+	// when the record is of another type.
+	subjectOf func(record *util.LexiconTypeDecoder) (string, bool)
+}
+
+// subjectRecordResource is the resource implementation of a subjectRecord.
+type subjectRecordResource struct {
+	record subjectRecord
+	data   *providerData
+}
+
+// subjectRecordResourceModel holds the attributes every subjectRecordResource
+// has. The models of the resources embed it next to their subject attribute.
+type subjectRecordResourceModel struct {
+	Account types.String `tfsdk:"account"`
+	Uri     types.String `tfsdk:"uri"`
+	Cid     types.String `tfsdk:"cid"`
+}
+
+// subjectRecordModel is implemented by the models of subjectRecordResources,
+// which are read with Get and written with Set as a whole.
+type subjectRecordModel interface {
+	record() *subjectRecordResourceModel
+	subject() *types.String
+}
+
+func (m *subjectRecordResourceModel) record() *subjectRecordResourceModel {
+	return m
+}
+
+// subjectDidRecordModel is the model of records whose subject is the DID of an
+// account.
+type subjectDidRecordModel struct {
+	subjectRecordResourceModel
+	SubjectDid types.String `tfsdk:"subject_did"`
+}
+
+func (m *subjectDidRecordModel) subject() *types.String {
+	return &m.SubjectDid
+}
+
+// Metadata returns the resource type name.
+func (r *subjectRecordResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + r.record.typeName
+}
+
+// Schema defines the schema for the resource.
+func (r *subjectRecordResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: r.record.description,
+		Attributes: map[string]schema.Attribute{
+			"account": accountResourceAttribute(),
+			r.record.subjectAttribute: schema.StringAttribute{
+				MarkdownDescription: r.record.subjectDescription,
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"uri": schema.StringAttribute{
+				MarkdownDescription: "Atproto URI",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"cid": schema.StringAttribute{
+				MarkdownDescription: "Commit ID generated by Bluesky",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+	}
+}
+
+func (r *subjectRecordResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	// Retrieve values from a plan.
+	plan := r.record.newModel()
+	resp.Diagnostics.Append(req.Plan.Get(ctx, plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, diags := r.data.accountClient(ctx, plan.record().Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	subject := plan.subject().ValueString()
+	resp.Diagnostics.Append(r.record.validate(ctx, client, subject)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Generate API request body from plan.
+	createRecordInput := &atproto.RepoCreateRecord_Input{
+		Repo:       client.Auth.Did,
+		Collection: r.record.collection,
+		Record:     r.record.newRecord(subject, time.Now().Format(time.RFC3339)),
+	}
+
+	// Create new record.
+	record, err := atproto.RepoCreateRecord(ctx, client, createRecordInput)
+	if isSwapConflict(err) {
+		resp.Diagnostics.AddError("Repo changed outside of Terraform", swapConflictDetail("The repo "+client.Auth.Did, err))
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error creating "+r.record.noun,
+			"Could not create the "+r.record.noun+" of "+subject+", unexpected error: "+err.Error(),
+		)
+		return
+	}
+
+	// Map response body to schema and populate Computed attribute values.
+	plan.record().Uri = types.StringValue(record.Uri)
+	plan.record().Cid = types.StringValue(record.Cid)
+
+	// Set state to fully populated data.
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+// Read refreshes the Terraform state with the latest data.
+func (r *subjectRecordResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	// Get current state.
+	state := r.record.newModel()
+	resp.Diagnostics.Append(req.State.Get(ctx, state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	uri := state.record().Uri.ValueString()
+
+	client, diags := r.data.accountClient(ctx, state.record().Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	record, _, err := getRecordAndURIFromString(ctx, client, uri)
+	if isRecordNotFound(err) {
+		// The record was deleted outside of Terraform.
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error reading "+r.record.noun,
+			"Could not read Bluesky "+r.record.noun+" URI "+uri+": "+err.Error(),
+		)
+		return
+	}
+	subject, ok := r.record.subjectOf(record.Value)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Error reading "+r.record.noun,
+			uri+" is not a "+r.record.noun+" record",
+		)
+		return
+	}
+
+	// Overwrite with refreshed state using the repository record.
+	state.record().Cid = types.StringValue(*record.Cid)
+	*state.subject() = types.StringValue(subject)
+
+	// Set refreshed state.
+	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+}
+
+// Update only stores the new account of an imported record, since every other
+// change replaces the record.
+func (r *subjectRecordResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	plan := r.record.newModel()
+	resp.Diagnostics.Append(req.Plan.Get(ctx, plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+// Delete deletes the resource and removes the Terraform state on success.
+func (r *subjectRecordResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	// Retrieve values from state.
+	model := r.record.newModel()
+	resp.Diagnostics.Append(req.State.Get(ctx, model)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	state := model.record()
+
+	client, diags := r.data.accountClient(ctx, state.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	uri, err := syntax.ParseATURI(state.Uri.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Invalid "+r.record.noun+" URI",
+			"Could not parse Bluesky "+r.record.noun+" URI "+state.Uri.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+	deleteRequest := &atproto.RepoDeleteRecord_Input{
+		Collection: uri.Collection().String(),
+		Repo:       uri.Authority().String(),
+		Rkey:       uri.RecordKey().String(),
+		SwapRecord: swapRecord(state.Cid),
+	}
+	_, err = atproto.RepoDeleteRecord(ctx, client, deleteRequest)
+	if isSwapConflict(err) {
+		resp.Diagnostics.AddError(
+			strings.ToUpper(r.record.noun[:1])+r.record.noun[1:]+" changed outside of Terraform",
+			swapConflictDetail("The "+r.record.noun+" "+state.Uri.ValueString(), err),
+		)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error deleting "+r.record.noun,
+			"Could not delete "+r.record.noun+", error: "+err.Error(),
+		)
+	}
+}
+
+// Configure adds the provider configured client to the resource.
+func (r *subjectRecordResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Add a nil check when handling ProviderData because Terraform
+	// sets that data after it calls the ConfigureProvider RPC.
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	resp.Diagnostics.Append(data.requireAccount(r.record.noun + "s")...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r.data = data
+}
+
+func (r *subjectRecordResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	// Retrieve import ID and save to uri attribute.
+	resource.ImportStatePassthroughID(ctx, path.Root("uri"), req, resp)
+}
+
+// validateSubjectDid returns the validate function of records whose subject
+// is the DID of an account.
+func validateSubjectDid(action string) func(context.Context, *xrpc.Client, string) diag.Diagnostics {
+	return func(_ context.Context, _ *xrpc.Client, subject string) diag.Diagnostics {
+		var diags diag.Diagnostics
+		if _, err := syntax.ParseDID(subject); err != nil {
+			diags.AddAttributeError(
+				path.Root("subject_did"),
+				"Invalid subject DID",
+				"Could not parse the DID of the account to "+action+": "+err.Error(),
+			)
+		}
+		return diags
+	}
+}
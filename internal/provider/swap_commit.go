@@ -0,0 +1,131 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/bluesky-social/indigo/xrpc"
+)
+
+// swapCommitTransport passes the latest commit of the repo as swapCommit with
+// every record write, so that the PDS rejects the write with InvalidSwap when
+// another client wrote to the repo in the meantime. Writes to the same repo
+// are serialized, as concurrent writes of the provider would otherwise reject
+// each other.
+type swapCommitTransport struct {
+	base http.RoundTripper
+
+	mu    sync.Mutex
+	repos map[string]*sync.Mutex
+}
+
+func newSwapCommitTransport(base http.RoundTripper) *swapCommitTransport {
+	return &swapCommitTransport{
+		base:  base,
+		repos: map[string]*sync.Mutex{},
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *swapCommitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodPost || !writeMethods[xrpcMethod(req)] {
+		return t.base.RoundTrip(req)
+	}
+
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	var input map[string]json.RawMessage
+	var repo string
+	if err := json.Unmarshal(body, &input); err == nil {
+		_ = json.Unmarshal(input["repo"], &repo)
+	}
+	if _, ok := input["swapCommit"]; ok || repo == "" {
+		// The caller swaps against a commit of its own.
+		return t.base.RoundTrip(withRequestBody(req, body))
+	}
+
+	lock := t.repoLock(req.URL.Host + " " + repo)
+	lock.Lock()
+	defer lock.Unlock()
+
+	commit, err := t.latestCommit(req, repo)
+	if err != nil {
+		return nil, fmt.Errorf("could not get the latest commit of %s to swap: %w", repo, err)
+	}
+	input["swapCommit"], _ = json.Marshal(commit)
+	body, err = json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(withRequestBody(req, body))
+}
+
+// repoLock returns the lock serializing the writes to a repo.
+func (t *swapCommitTransport) repoLock(key string) *sync.Mutex {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	lock, ok := t.repos[key]
+	if !ok {
+		lock = &sync.Mutex{}
+		t.repos[key] = lock
+	}
+	return lock
+}
+
+// latestCommit returns the CID of the latest commit of the repo, as reported
+// by com.atproto.sync.getLatestCommit of the host the write goes to.
+func (t *swapCommitTransport) latestCommit(write *http.Request, repo string) (string, error) {
+	u := *write.URL
+	u.Path = "/xrpc/com.atproto.sync.getLatestCommit"
+	u.RawQuery = url.Values{"did": {repo}}.Encode()
+	req, err := http.NewRequestWithContext(write.Context(), http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		var xrpcErr xrpc.XRPCError
+		if err := json.NewDecoder(resp.Body).Decode(&xrpcErr); err != nil || xrpcErr.ErrStr == "" {
+			return "", fmt.Errorf("unexpected status %d", resp.StatusCode)
+		}
+		return "", &xrpc.Error{StatusCode: resp.StatusCode, Wrapped: &xrpcErr}
+	}
+	var out struct {
+		Cid string `json:"cid"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("could not decode the latest commit: %w", err)
+	}
+	if out.Cid == "" {
+		return "", fmt.Errorf("the latest commit has no CID")
+	}
+	return out.Cid, nil
+}
+
+// withRequestBody returns a copy of the request sending body.
+func withRequestBody(req *http.Request, body []byte) *http.Request {
+	r := req.Clone(req.Context())
+	if body != nil {
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+		r.ContentLength = int64(len(body))
+	}
+	return r
+}
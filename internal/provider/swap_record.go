@@ -0,0 +1,68 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// swapRecord returns the CID of the state to pass as swapRecord, so that a
+// record changed since it was last read isn't overwritten or deleted. States
+// written before the CID was tracked have none.
+func swapRecord(cid types.String) *string {
+	if cid.IsNull() || cid.IsUnknown() || cid.ValueString() == "" {
+		return nil
+	}
+	return cid.ValueStringPointer()
+}
+
+// recordCidPlanModifier plans the cid of the state as long as none of the
+// attributes written to the record change, since a record which is written
+// again with the same value keeps its CID.
+type recordCidPlanModifier struct {
+	recordAttributes []string
+	// blobHashes are the hash attributes among recordAttributes. Their plan
+	// isn't known to other attributes yet, so it is planned from the file
+	// again.
+	blobHashes map[string]blobHashPlanModifier
+}
+
+func (m recordCidPlanModifier) Description(_ context.Context) string {
+	return "The cid only changes when the record changes."
+}
+
+func (m recordCidPlanModifier) MarkdownDescription(ctx context.Context) string {
+	return m.Description(ctx)
+}
+
+func (m recordCidPlanModifier) PlanModifyString(ctx context.Context, req planmodifier.StringRequest, resp *planmodifier.StringResponse) {
+	// Nothing to keep when the resource is created or destroyed.
+	if req.State.Raw.IsNull() || req.Plan.Raw.IsNull() || !req.PlanValue.IsUnknown() {
+		return
+	}
+
+	for _, attribute := range m.recordAttributes {
+		var planned, prior types.String
+		resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root(attribute), &planned)...)
+		resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root(attribute), &prior)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		if hash, ok := m.blobHashes[attribute]; ok {
+			// An invalid file is reported by the hash attribute itself.
+			hashReq := planmodifier.StringRequest{Path: path.Root(attribute), Plan: req.Plan, PlanValue: planned}
+			var hashResp planmodifier.StringResponse
+			hash.PlanModifyString(ctx, hashReq, &hashResp)
+			if hashResp.Diagnostics.HasError() {
+				return
+			}
+			planned = hashResp.PlanValue
+		}
+		if !planned.Equal(prior) {
+			return
+		}
+	}
+	resp.PlanValue = req.StateValue
+}
@@ -0,0 +1,631 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/api/bsky"
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/bluesky-social/indigo/lex/util"
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/hashicorp/terraform-plugin-framework-validators/setvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const threadgateCollection = "app.bsky.feed.threadgate"
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ resource.Resource                = &threadgateResource{}
+	_ resource.ResourceWithConfigure   = &threadgateResource{}
+	_ resource.ResourceWithImportState = &threadgateResource{}
+	_ resource.ResourceWithModifyPlan  = &threadgateResource{}
+)
+
+// NewThreadgateResource is a helper function to simplify the provider implementation.
+func NewThreadgateResource() resource.Resource {
+	return &threadgateResource{}
+}
+
+// threadgateResource is the resource implementation. The threadgate of a post
+// has the same record key as the post.
+type threadgateResource struct {
+	data *providerData
+}
+
+type threadgateResourceModel struct {
+	Account       types.String          `tfsdk:"account"`
+	PostUri       types.String          `tfsdk:"post_uri"`
+	Uri           types.String          `tfsdk:"uri"`
+	Cid           types.String          `tfsdk:"cid"`
+	Allow         *threadgateAllowModel `tfsdk:"allow"`
+	HiddenReplies types.Set             `tfsdk:"hidden_replies"`
+}
+
+type threadgateAllowModel struct {
+	Mentioned types.Bool `tfsdk:"mentioned"`
+	Following types.Bool `tfsdk:"following"`
+	Followers types.Bool `tfsdk:"followers"`
+	Lists     types.Set  `tfsdk:"lists"`
+}
+
+// threadgateRecord is a bsky.FeedThreadgate whose allow list is kept in the
+// JSON record when it is empty, which means that nobody can reply. The lexicon
+// type omits empty lists, which would let anyone reply instead.
+type threadgateRecord struct {
+	bsky.FeedThreadgate
+}
+
+func (r *threadgateRecord) MarshalJSON() ([]byte, error) {
+	type fields bsky.FeedThreadgate
+	if r.Allow == nil {
+		return json.Marshal((*fields)(&r.FeedThreadgate))
+	}
+	return json.Marshal(struct {
+		*fields
+		Allow []*bsky.FeedThreadgate_Allow_Elem `json:"allow"`
+	}{(*fields)(&r.FeedThreadgate), r.Allow})
+}
+
+// Metadata returns the resource type name.
+func (t *threadgateResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_threadgate"
+}
+
+// Schema defines the schema for the resource.
+func (t *threadgateResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Manage who can reply to a Bluesky post, and which replies are hidden from its thread.",
+		Attributes: map[string]schema.Attribute{
+			"account":  accountResourceAttribute(),
+			"post_uri": gatedPostURIAttribute("threadgate"),
+			"uri": schema.StringAttribute{
+				MarkdownDescription: "Atproto URI",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"cid": schema.StringAttribute{
+				MarkdownDescription: "Commit ID generated by Bluesky",
+				Computed:            true,
+			},
+			"allow": schema.SingleNestedAttribute{
+				MarkdownDescription: "Who can reply to the post besides its author. When not set, anyone can reply. When set without any rule, nobody can.",
+				Optional:            true,
+				Attributes: map[string]schema.Attribute{
+					"mentioned": schema.BoolAttribute{
+						MarkdownDescription: "Whether accounts mentioned in the post can reply. Defaults to `false`.",
+						Optional:            true,
+						Computed:            true,
+						Default:             booldefault.StaticBool(false),
+					},
+					"following": schema.BoolAttribute{
+						MarkdownDescription: "Whether accounts followed by the author can reply. Defaults to `false`.",
+						Optional:            true,
+						Computed:            true,
+						Default:             booldefault.StaticBool(false),
+					},
+					"followers": schema.BoolAttribute{
+						MarkdownDescription: "Whether followers of the author can reply. Defaults to `false`.",
+						Optional:            true,
+						Computed:            true,
+						Default:             booldefault.StaticBool(false),
+					},
+					"lists": schema.SetAttribute{
+						MarkdownDescription: "Atproto URIs of the lists whose members can reply, such as the `uri` of a `bsky_list`",
+						ElementType:         types.StringType,
+						Optional:            true,
+						Validators: []validator.Set{
+							setvalidator.SizeBetween(1, 5),
+						},
+					},
+				},
+			},
+			"hidden_replies": schema.SetAttribute{
+				MarkdownDescription: "Atproto URIs of the replies hidden from the thread",
+				ElementType:         types.StringType,
+				Optional:            true,
+				Validators: []validator.Set{
+					setvalidator.SizeBetween(1, 300),
+				},
+			},
+		},
+	}
+}
+
+func (t *threadgateResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	// Retrieve values from a plan.
+	var plan threadgateResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
+		return
+	}
+
+	client, diags := t.data.accountClient(ctx, plan.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	post, err := parseGatedPostURI(client, plan.PostUri.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("post_uri"),
+			"Invalid post URI",
+			err.Error(),
+		)
+		return
+	}
+
+	// Generate API request body from plan.
+	threadgate, diags := newThreadgateRecord(ctx, &plan, time.Now().Format(time.RFC3339))
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	rkey := post.RecordKey().String()
+	createRecordInput := &atproto.RepoCreateRecord_Input{
+		Repo:       client.Auth.Did,
+		Collection: threadgateCollection,
+		Rkey:       &rkey,
+		Record:     &util.LexiconTypeDecoder{Val: threadgate},
+	}
+
+	// Create new threadgate.
+	record, err := atproto.RepoCreateRecord(ctx, client, createRecordInput)
+	if isSwapConflict(err) {
+		resp.Diagnostics.AddError("Repo changed outside of Terraform", swapConflictDetail("The repo "+client.Auth.Did, err))
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error creating threadgate",
+			"Could not create the threadgate of "+plan.PostUri.ValueString()+", unexpected error: "+err.Error(),
+		)
+		return
+	}
+
+	// Map response body to schema and populate Computed attribute values.
+	plan.Uri = types.StringValue(record.Uri)
+	plan.Cid = types.StringValue(record.Cid)
+
+	// Set state to fully populated data.
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Read refreshes the Terraform state with the latest data.
+func (t *threadgateResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	// Get current state.
+	var state threadgateResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, diags := t.data.accountClient(ctx, state.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	post, err := parseGatedPostURI(client, state.PostUri.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("post_uri"),
+			"Invalid post URI",
+			err.Error(),
+		)
+		return
+	}
+	record, err := getGate(ctx, client, threadgateCollection, post)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error reading threadgate",
+			"Could not read the threadgate of "+state.PostUri.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+	if record == nil {
+		// The threadgate was deleted outside of Terraform.
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	threadgate, ok := record.Value.Val.(*bsky.FeedThreadgate)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Error reading threadgate",
+			"Could not cast record to FeedThreadgate",
+		)
+		return
+	}
+
+	// Overwrite with refreshed state using the repository record.
+	state.Uri = types.StringValue(record.Uri)
+	state.Cid = types.StringValue(*record.Cid)
+	state.Allow = nil
+	if threadgate.Allow != nil {
+		state.Allow = &threadgateAllowModel{
+			Mentioned: types.BoolValue(false),
+			Following: types.BoolValue(false),
+			Followers: types.BoolValue(false),
+			Lists:     types.SetNull(types.StringType),
+		}
+		var lists []string
+		for _, rule := range threadgate.Allow {
+			switch {
+			case rule.FeedThreadgate_MentionRule != nil:
+				state.Allow.Mentioned = types.BoolValue(true)
+			case rule.FeedThreadgate_FollowingRule != nil:
+				state.Allow.Following = types.BoolValue(true)
+			case rule.FeedThreadgate_FollowerRule != nil:
+				state.Allow.Followers = types.BoolValue(true)
+			case rule.FeedThreadgate_ListRule != nil:
+				lists = append(lists, rule.FeedThreadgate_ListRule.List)
+			}
+		}
+		if len(lists) > 0 {
+			state.Allow.Lists, diags = types.SetValueFrom(ctx, types.StringType, lists)
+			resp.Diagnostics.Append(diags...)
+		}
+	}
+	state.HiddenReplies = types.SetNull(types.StringType)
+	if len(threadgate.HiddenReplies) > 0 {
+		state.HiddenReplies, diags = types.SetValueFrom(ctx, types.StringType, threadgate.HiddenReplies)
+		resp.Diagnostics.Append(diags...)
+	}
+
+	// Set refreshed state.
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Update updates the resource and sets the updated Terraform state on success.
+func (t *threadgateResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	// Retrieve values from a plan.
+	var plan threadgateResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
+		return
+	}
+
+	var state threadgateResourceModel
+	diags = req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, diags := t.data.accountClient(ctx, plan.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Get the current threadgate to keep its creation time.
+	record, parsedUri, err := getRecordAndURIFromString(ctx, client, plan.Uri.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to retrieve threadgate",
+			"Could not retrieve the current state of the threadgate "+plan.Uri.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+	current, ok := record.Value.Val.(*bsky.FeedThreadgate)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Failed to retrieve threadgate",
+			"Could not cast record to FeedThreadgate",
+		)
+		return
+	}
+
+	threadgate, diags := newThreadgateRecord(ctx, &plan, current.CreatedAt)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	putRecordInput := &atproto.RepoPutRecord_Input{
+		Collection: parsedUri.Collection().String(),
+		Repo:       parsedUri.Authority().String(),
+		Rkey:       parsedUri.RecordKey().String(),
+		SwapRecord: swapRecord(state.Cid),
+		Record: &util.LexiconTypeDecoder{
+			Val: threadgate,
+		},
+	}
+	updatedRecord, err := atproto.RepoPutRecord(ctx, client, putRecordInput)
+	if isSwapConflict(err) {
+		resp.Diagnostics.AddError("Threadgate changed outside of Terraform", swapConflictDetail("The threadgate "+plan.Uri.ValueString(), err))
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to update threadgate",
+			"Could not update threadgate "+plan.Uri.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+
+	// Update resource state.
+	plan.Cid = types.StringValue(updatedRecord.Cid)
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Delete deletes the resource and removes the Terraform state on success.
+func (t *threadgateResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	// Retrieve values from state.
+	var state threadgateResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, diags := t.data.accountClient(ctx, state.Account)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(deleteGate(ctx, client, "threadgate", state.Uri.ValueString(), state.Cid)...)
+}
+
+// Configure adds the provider configured client to the resource.
+func (t *threadgateResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Add a nil check when handling ProviderData because Terraform
+	// sets that data after it calls the ConfigureProvider RPC.
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	resp.Diagnostics.Append(data.requireAccount("threadgates")...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	t.data = data
+}
+
+// ImportState imports the threadgate of the post with the URI given as ID.
+// ModifyPlan fails the plan when post_uri is a post of another account.
+func (t *threadgateResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	resp.Diagnostics.Append(checkGatedPostPlan(ctx, t.data, req.Plan)...)
+}
+
+func (t *threadgateResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("post_uri"), req, resp)
+}
+
+// newThreadgateRecord returns the threadgate record of model.
+func newThreadgateRecord(ctx context.Context, model *threadgateResourceModel, createdAt string) (*threadgateRecord, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	threadgate := &threadgateRecord{bsky.FeedThreadgate{
+		Post:      model.PostUri.ValueString(),
+		CreatedAt: createdAt,
+	}}
+	diags.Append(model.HiddenReplies.ElementsAs(ctx, &threadgate.HiddenReplies, false)...)
+
+	if model.Allow != nil {
+		allow := []*bsky.FeedThreadgate_Allow_Elem{}
+		if model.Allow.Mentioned.ValueBool() {
+			allow = append(allow, &bsky.FeedThreadgate_Allow_Elem{FeedThreadgate_MentionRule: &bsky.FeedThreadgate_MentionRule{}})
+		}
+		if model.Allow.Following.ValueBool() {
+			allow = append(allow, &bsky.FeedThreadgate_Allow_Elem{FeedThreadgate_FollowingRule: &bsky.FeedThreadgate_FollowingRule{}})
+		}
+		if model.Allow.Followers.ValueBool() {
+			allow = append(allow, &bsky.FeedThreadgate_Allow_Elem{FeedThreadgate_FollowerRule: &bsky.FeedThreadgate_FollowerRule{}})
+		}
+		var lists []string
+		diags.Append(model.Allow.Lists.ElementsAs(ctx, &lists, false)...)
+		for _, list := range lists {
+			uri, err := syntax.ParseATURI(list)
+			if err != nil || uri.Collection().String() != "app.bsky.graph.list" {
+				diags.AddAttributeError(
+					path.Root("allow").AtName("lists"),
+					"Invalid list URI",
+					list+" is not the Atproto URI of a list",
+				)
+				continue
+			}
+			allow = append(allow, &bsky.FeedThreadgate_Allow_Elem{FeedThreadgate_ListRule: &bsky.FeedThreadgate_ListRule{List: list}})
+		}
+		if len(allow) > 5 {
+			diags.AddAttributeError(
+				path.Root("allow"),
+				"Too many reply rules",
+				fmt.Sprintf("A threadgate allows at most 5 rules, including lists, got %d", len(allow)),
+			)
+		}
+		threadgate.Allow = allow
+	}
+	return threadgate, diags
+}
+
+// gatedPostURIAttribute returns the attribute of the post a threadgate or a
+// postgate applies to.
+func gatedPostURIAttribute(gate string) schema.StringAttribute {
+	return schema.StringAttribute{
+		MarkdownDescription: "Atproto URI of the post the " + gate + " applies to, which must be a post of the account. " +
+			"The " + gate + " has the same record key as the post.",
+		Required: true,
+		Validators: []validator.String{
+			postURIValidator{},
+		},
+		PlanModifiers: []planmodifier.String{
+			stringplanmodifier.RequiresReplace(),
+		},
+	}
+}
+
+// parsePostURI parses the URI of a post.
+func parsePostURI(uri string) (syntax.ATURI, error) {
+	parsed, err := syntax.ParseATURI(uri)
+	if err != nil {
+		return parsed, fmt.Errorf("could not parse URI %s: %w", uri, err)
+	}
+	if parsed.Collection().String() != postCollection || parsed.RecordKey() == "" {
+		return parsed, fmt.Errorf("%s is not the URI of a post", uri)
+	}
+	return parsed, nil
+}
+
+// parseGatedPostURI parses the URI of the post a gate applies to. Gates share
+// the record key of their post, so the post has to be in the repo of client.
+// The URI is checked again when applying, since it may only be known then,
+// and imported gates skip the plan.
+func parseGatedPostURI(client *xrpc.Client, uri string) (syntax.ATURI, error) {
+	parsed, err := parsePostURI(uri)
+	if err != nil {
+		return parsed, err
+	}
+	if parsed.Authority().String() != client.Auth.Did {
+		return parsed, fmt.Errorf("%s is not a post of the account %s, posts can only be gated by their author. "+
+			"The URI must use the DID of the account rather than its handle.", uri, client.Auth.Did)
+	}
+	return parsed, nil
+}
+
+// checkGatedPostPlan checks that the post_uri of the plan of a gate is a post
+// of its account, once both are known.
+func checkGatedPostPlan(ctx context.Context, data *providerData, plan tfsdk.Plan) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if plan.Raw.IsNull() || data == nil {
+		return diags
+	}
+
+	var account, postUri types.String
+	diags.Append(plan.GetAttribute(ctx, path.Root("account"), &account)...)
+	diags.Append(plan.GetAttribute(ctx, path.Root("post_uri"), &postUri)...)
+	if diags.HasError() || account.IsUnknown() || postUri.IsUnknown() {
+		return diags
+	}
+
+	client, d := data.accountClient(ctx, account)
+	diags.Append(d...)
+	if diags.HasError() {
+		return diags
+	}
+	if _, err := parseGatedPostURI(client, postUri.ValueString()); err != nil {
+		diags.AddAttributeError(
+			path.Root("post_uri"),
+			"Invalid post URI",
+			err.Error(),
+		)
+	}
+	return diags
+}
+
+// postURIValidator checks that a string is the URI of a post.
+type postURIValidator struct{}
+
+func (v postURIValidator) Description(_ context.Context) string {
+	return "value must be the URI of a post such as at://did:plc:z72i7hdynmk6r22z27h6tvur/app.bsky.feed.post/3l6oveex3ii2l"
+}
+
+func (v postURIValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v postURIValidator) ValidateString(_ context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	if _, err := parsePostURI(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid post URI",
+			err.Error(),
+		)
+	}
+}
+
+// getGate returns the record of the gate of post in collection, or nil if the
+// post has none.
+func getGate(ctx context.Context, client *xrpc.Client, collection string, post syntax.ATURI) (*atproto.RepoGetRecord_Output, error) {
+	record, err := atproto.RepoGetRecord(ctx, client, "", collection, post.Authority().String(), post.RecordKey().String())
+	if isRecordNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not get record: %w", err)
+	}
+	if record.Cid == nil {
+		return nil, fmt.Errorf("record.Cid is nil")
+	}
+	return record, nil
+}
+
+// deleteGate deletes the gate record at uri, unless it changed since its cid
+// was read.
+func deleteGate(ctx context.Context, client *xrpc.Client, gate string, uri string, cid types.String) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	parsedUri, err := syntax.ParseATURI(uri)
+	if err != nil {
+		diags.AddError(
+			"Invalid "+gate+" URI",
+			"Could not parse "+gate+" URI "+uri+": "+err.Error(),
+		)
+		return diags
+	}
+	deleteRequest := &atproto.RepoDeleteRecord_Input{
+		Collection: parsedUri.Collection().String(),
+		Repo:       parsedUri.Authority().String(),
+		Rkey:       parsedUri.RecordKey().String(),
+		SwapRecord: swapRecord(cid),
+	}
+	_, err = atproto.RepoDeleteRecord(ctx, client, deleteRequest)
+	if isSwapConflict(err) {
+		diags.AddError(strings.ToUpper(gate[:1])+gate[1:]+" changed outside of Terraform", swapConflictDetail("The "+gate+" "+uri, err))
+		return diags
+	}
+	if err != nil {
+		diags.AddError(
+			"Error deleting "+gate,
+			"Could not delete "+gate+", error: "+err.Error(),
+		)
+	}
+	return diags
+}
@@ -0,0 +1,485 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	defaultMaxRetries     = 3
+	defaultRequestTimeout = 60 * time.Second
+
+	retryBaseDelay = 1 * time.Second
+	retryMaxDelay  = 30 * time.Second
+)
+
+// writeMethods are the XRPC procedures counted against the writes-per-hour
+// budget.
+var writeMethods = map[string]bool{
+	"com.atproto.repo.createRecord": true,
+	"com.atproto.repo.putRecord":    true,
+	"com.atproto.repo.deleteRecord": true,
+	"com.atproto.repo.applyWrites":  true,
+}
+
+// transportConfig configures the http.Client of the provider.
+type transportConfig struct {
+	maxRetries    int
+	writesPerHour int
+	// swapCommit passes the latest commit of the repo with every record
+	// write, see swapCommitTransport.
+	swapCommit bool
+
+	// requestTimeout bounds each attempt of a request, zero disables it.
+	requestTimeout time.Duration
+	// proxy is the proxy requests go through. When nil, the proxy is taken
+	// from the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+	proxy *url.URL
+	// tlsConfig holds the extra CA certificates and the client certificate.
+	tlsConfig *tls.Config
+	userAgent string
+
+	// logRequests traces every attempt of a request in the xrpc log
+	// subsystem, masking secrets.
+	logRequests bool
+	secrets     []string
+}
+
+// newHTTPClient creates the http.Client shared by every session, resolver and
+// resource of a provider instance.
+func newHTTPClient(config transportConfig) *http.Client {
+	var transport http.RoundTripper = newBaseTransport(config)
+	transport = &requestTransport{
+		base:      transport,
+		userAgent: config.userAgent,
+		timeout:   config.requestTimeout,
+	}
+	if config.logRequests {
+		transport = newLoggingTransport(transport, config.secrets)
+	}
+	transport = newRateLimitTransport(transport, config.maxRetries, config.writesPerHour)
+	if config.swapCommit {
+		// Outermost, so that a retried write swaps against the same commit
+		// and the lookup of the commit isn't counted as a write.
+		transport = newSwapCommitTransport(transport)
+	}
+	return &http.Client{Transport: transport}
+}
+
+func newBaseTransport(config transportConfig) *http.Transport {
+	proxy := http.ProxyFromEnvironment
+	if config.proxy != nil {
+		proxy = http.ProxyURL(config.proxy)
+	}
+	return &http.Transport{
+		Proxy: proxy,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSClientConfig:       config.tlsConfig,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   10,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+// newTLSConfig returns the TLS configuration trusting the certificates of
+// caBundleFile in addition to the system roots, and presenting the client
+// certificate of certFile and keyFile. It returns nil when all are empty.
+func newTLSConfig(caBundleFile string, certFile string, keyFile string) (*tls.Config, error) {
+	if caBundleFile == "" && certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if caBundleFile != "" {
+		pem, err := os.ReadFile(caBundleFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM encoded certificates found in %s", caBundleFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if certFile != "" || keyFile != "" {
+		if certFile == "" || keyFile == "" {
+			return nil, fmt.Errorf("a client certificate requires both the certificate and the key file")
+		}
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+// requestTransport sets the user agent of every request, and bounds how long a
+// single attempt may take. Timed out attempts are retried by the
+// rateLimitTransport above it.
+type requestTransport struct {
+	base      http.RoundTripper
+	userAgent string
+	timeout   time.Duration
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *requestTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	if t.userAgent != "" {
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+	if t.timeout <= 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	// The deadline covers reading the body, so it is only released once the
+	// body is closed.
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose releases the context of a request when its response body is
+// closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// rateLimitTransport throttles and retries the XRPC requests of the provider.
+// A single instance is shared by all concurrent resource operations, so
+// Terraform's parallelism doesn't multiply the load on the PDS.
+//
+// Requests wait while the PDS reported through the RateLimit-Remaining and
+// RateLimit-Reset headers that the budget is used up, writes additionally
+// wait for the writes-per-hour budget, and 429 and 5xx responses are retried
+// with jittered exponential backoff.
+type rateLimitTransport struct {
+	base       http.RoundTripper
+	maxRetries int
+	writes     *writeBudget
+
+	// RateLimit-Reset times of exhausted rate limits, tracked separately for
+	// writes and all other requests.
+	mu                 sync.Mutex
+	readsBlockedUntil  time.Time
+	writesBlockedUntil time.Time
+}
+
+func newRateLimitTransport(base http.RoundTripper, maxRetries int, writesPerHour int) *rateLimitTransport {
+	return &rateLimitTransport{
+		base:       base,
+		maxRetries: maxRetries,
+		writes:     newWriteBudget(writesPerHour),
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	method := xrpcMethod(req)
+	isWrite := writeMethods[method]
+
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	guarded := false
+	if isWrite {
+		if err := t.writes.wait(ctx, writeCount(method, body)); err != nil {
+			return nil, err
+		}
+		guarded = isGuardedWrite(body)
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := t.waitUntilUnblocked(ctx, isWrite); err != nil {
+			return nil, err
+		}
+
+		// Track whether the write reached the connection, after which it may
+		// have been committed even if the response is lost.
+		var sent atomic.Bool
+		r := req.Clone(httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+			WroteHeaders: func() { sent.Store(true) },
+		}))
+		if body != nil {
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			r.GetBody = func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(body)), nil
+			}
+			r.ContentLength = int64(len(body))
+		}
+
+		resp, err := t.base.RoundTrip(r)
+		if err == nil {
+			t.recordRateLimit(resp, isWrite)
+		}
+
+		if attempt >= t.maxRetries || !shouldRetry(ctx, resp, err) {
+			return resp, err
+		}
+		if isWrite && !guarded && !retryableWrite(resp, err, sent.Load()) {
+			return resp, err
+		}
+
+		delay := backoff(attempt)
+		fields := map[string]any{
+			"xrpc_method": method,
+			"attempt":     attempt + 1,
+			"delay":       delay.String(),
+		}
+		if err != nil {
+			fields["error"] = err.Error()
+		} else {
+			fields["status"] = resp.StatusCode
+			if resp.StatusCode == http.StatusTooManyRequests {
+				if reset, ok := rateLimitReset(resp); ok {
+					delay = time.Until(reset)
+					fields["delay"] = delay.String()
+				}
+			}
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+		tflog.Debug(ctx, "Retrying XRPC request", fields)
+
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (t *rateLimitTransport) waitUntilUnblocked(ctx context.Context, isWrite bool) error {
+	t.mu.Lock()
+	until := *t.blockedUntil(isWrite)
+	t.mu.Unlock()
+
+	if delay := time.Until(until); delay > 0 {
+		tflog.Info(ctx, "Bluesky rate limit exhausted, waiting for it to reset", map[string]any{"reset": until.Format(time.RFC3339)})
+		return sleep(ctx, delay)
+	}
+	return nil
+}
+
+// recordRateLimit blocks further requests until the rate limit resets when
+// the PDS reports that no requests remain.
+func (t *rateLimitTransport) recordRateLimit(resp *http.Response, isWrite bool) {
+	remaining, err := strconv.Atoi(resp.Header.Get("RateLimit-Remaining"))
+	if err != nil || remaining > 0 {
+		return
+	}
+	reset, ok := rateLimitReset(resp)
+	if !ok {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if until := t.blockedUntil(isWrite); reset.After(*until) {
+		*until = reset
+	}
+}
+
+// blockedUntil returns the reset time of the rate limit that applies to the
+// request. The caller must hold the lock.
+func (t *rateLimitTransport) blockedUntil(isWrite bool) *time.Time {
+	if isWrite {
+		return &t.writesBlockedUntil
+	}
+	return &t.readsBlockedUntil
+}
+
+// rateLimitReset returns the reset time from the RateLimit-Reset header
+// (seconds since the epoch) or the Retry-After header (seconds).
+func rateLimitReset(resp *http.Response) (time.Time, bool) {
+	if reset, err := strconv.ParseInt(resp.Header.Get("RateLimit-Reset"), 10, 64); err == nil {
+		return time.Unix(reset, 0), true
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		return time.Now().Add(time.Duration(seconds) * time.Second), true
+	}
+	return time.Time{}, false
+}
+
+func shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		// The request context is still alive, so the error is a network
+		// error or an attempt running into the request timeout.
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryableWrite reports whether a write which isn't guarded by swapRecord or
+// swapCommit can be sent again. Such a write may have been committed although
+// it failed or its response was lost, and sending it again would create a
+// duplicate record, so it is only retried when the PDS throttled it or when it
+// never left the client.
+func retryableWrite(resp *http.Response, err error, sent bool) bool {
+	if err != nil {
+		return !sent
+	}
+	return resp.StatusCode == http.StatusTooManyRequests
+}
+
+// isGuardedWrite reports whether the body of a write passes swapRecord or
+// swapCommit, which makes the PDS reject it if it was already committed.
+func isGuardedWrite(body []byte) bool {
+	var input struct {
+		SwapRecord *string `json:"swapRecord"`
+		SwapCommit *string `json:"swapCommit"`
+	}
+	if err := json.Unmarshal(body, &input); err != nil {
+		return false
+	}
+	return (input.SwapRecord != nil && *input.SwapRecord != "") || (input.SwapCommit != nil && *input.SwapCommit != "")
+}
+
+// backoff returns the jittered delay before the given retry attempt.
+func backoff(attempt int) time.Duration {
+	delay := retryBaseDelay << attempt
+	if delay > retryMaxDelay || delay <= 0 {
+		delay = retryMaxDelay
+	}
+	// Equal jitter: a random delay in the upper half of the interval.
+	return delay/2 + rand.N(delay/2)
+}
+
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// xrpcMethod returns the NSID of the XRPC method a request calls.
+func xrpcMethod(req *http.Request) string {
+	_, method, _ := strings.Cut(req.URL.Path, "/xrpc/")
+	return method
+}
+
+// writeCount returns the number of writes a request performs. applyWrites
+// performs one per element of its writes array.
+func writeCount(method string, body []byte) int {
+	if method != "com.atproto.repo.applyWrites" {
+		return 1
+	}
+	var input struct {
+		Writes []json.RawMessage `json:"writes"`
+	}
+	if err := json.Unmarshal(body, &input); err != nil || len(input.Writes) == 0 {
+		return 1
+	}
+	return len(input.Writes)
+}
+
+// writeBudget is a token bucket spreading writes so that no more than the
+// configured number happen within an hour.
+type writeBudget struct {
+	perHour int
+
+	mu       sync.Mutex
+	tokens   float64
+	lastFill time.Time
+}
+
+func newWriteBudget(perHour int) *writeBudget {
+	return &writeBudget{
+		perHour:  perHour,
+		tokens:   float64(perHour),
+		lastFill: time.Now(),
+	}
+}
+
+// wait blocks until n writes fit in the budget, then takes them out of it. A
+// budget of zero is unlimited.
+func (b *writeBudget) wait(ctx context.Context, n int) error {
+	if b.perHour <= 0 {
+		return nil
+	}
+	// A single applyWrites may be larger than the bucket.
+	if n > b.perHour {
+		n = b.perHour
+	}
+
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.tokens += now.Sub(b.lastFill).Hours() * float64(b.perHour)
+		if b.tokens > float64(b.perHour) {
+			b.tokens = float64(b.perHour)
+		}
+		b.lastFill = now
+
+		if b.tokens >= float64(n) {
+			b.tokens -= float64(n)
+			b.mu.Unlock()
+			return nil
+		}
+		missing := float64(n) - b.tokens
+		b.mu.Unlock()
+
+		delay := time.Duration(missing / float64(b.perHour) * float64(time.Hour))
+		tflog.Debug(ctx, "Waiting for the writes-per-hour budget", map[string]any{"delay": delay.String()})
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
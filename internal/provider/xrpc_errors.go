@@ -0,0 +1,116 @@
+package provider
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/bluesky-social/indigo/xrpc"
+)
+
+// xrpcErrorClass is the kind of failure of an XRPC request.
+type xrpcErrorClass int
+
+const (
+	// xrpcErrorOther is any other failure, including errors about the repo or
+	// account of a record, such as an unknown or deactivated repo. Those don't
+	// mean the record is gone, and fail the request.
+	xrpcErrorOther xrpcErrorClass = iota
+	// xrpcErrorRecordNotFound means the record doesn't exist.
+	xrpcErrorRecordNotFound
+	// xrpcErrorAccountNotFound means the PDS responded to an admin request
+	// that the account doesn't exist.
+	xrpcErrorAccountNotFound
+	// xrpcErrorListNotFound means the AppView responded that a list doesn't
+	// exist, such as after it was deleted.
+	xrpcErrorListNotFound
+	// xrpcErrorConflict means a write was rejected because the record or the
+	// repo changed since its swapRecord or swapCommit was read.
+	xrpcErrorConflict
+	// xrpcErrorAuthFactorRequired means createSession was rejected because the
+	// account has email two-factor authentication enabled and no valid
+	// sign-in code was given.
+	xrpcErrorAuthFactorRequired
+	// xrpcErrorTransport means no response was received, such as when the
+	// host can't be reached or the connection was closed.
+	xrpcErrorTransport
+	// xrpcErrorUnauthorized, xrpcErrorRateLimited and xrpcErrorUnavailable
+	// are responses with the HTTP status 401 or 403, 429 and 5xx which aren't
+	// any of the errors above, including responses which aren't XRPC errors
+	// at all, such as the error page of a proxy.
+	xrpcErrorUnauthorized
+	xrpcErrorRateLimited
+	xrpcErrorUnavailable
+)
+
+// recordNotFoundMessage is the prefix of the message of InvalidRequest errors
+// which PDS use for missing records.
+const recordNotFoundMessage = "Could not locate record"
+
+// classifyXRPCError returns the kind of failure of an XRPC request. Only
+// errors the server named as such are not found, so that a response of
+// another server, such as a 404 page of a proxy, never counts as a deleted
+// record. Neither do missing repos, since a wrong pds_host or an account
+// being migrated would otherwise drop every resource from the state.
+func classifyXRPCError(err error) xrpcErrorClass {
+	var xrpcErr *xrpc.XRPCError
+	if errors.As(err, &xrpcErr) {
+		switch {
+		case xrpcErr.ErrStr == "RecordNotFound":
+			return xrpcErrorRecordNotFound
+		case xrpcErr.ErrStr == "InvalidRequest" && strings.HasPrefix(xrpcErr.Message, recordNotFoundMessage):
+			return xrpcErrorRecordNotFound
+		case xrpcErr.ErrStr == "AccountNotFound":
+			return xrpcErrorAccountNotFound
+		case xrpcErr.ErrStr == "NotFound" && strings.HasPrefix(xrpcErr.Message, "Account not found"):
+			return xrpcErrorAccountNotFound
+		case xrpcErr.ErrStr == "InvalidRequest" && strings.HasPrefix(xrpcErr.Message, "List not found"):
+			return xrpcErrorListNotFound
+		case xrpcErr.ErrStr == "InvalidSwap":
+			return xrpcErrorConflict
+		case xrpcErr.ErrStr == "AuthFactorTokenRequired":
+			return xrpcErrorAuthFactorRequired
+		}
+	}
+
+	var statusErr *xrpc.Error
+	if errors.As(err, &statusErr) {
+		switch {
+		case statusErr.StatusCode == http.StatusUnauthorized || statusErr.StatusCode == http.StatusForbidden:
+			return xrpcErrorUnauthorized
+		case statusErr.StatusCode == http.StatusTooManyRequests:
+			return xrpcErrorRateLimited
+		case statusErr.StatusCode >= http.StatusInternalServerError:
+			return xrpcErrorUnavailable
+		}
+		return xrpcErrorOther
+	}
+
+	var urlErr *url.Error
+	var netErr net.Error
+	if errors.As(err, &urlErr) || errors.As(err, &netErr) {
+		return xrpcErrorTransport
+	}
+	return xrpcErrorOther
+}
+
+// isRecordNotFound reports whether the PDS responded that the record doesn't
+// exist.
+func isRecordNotFound(err error) bool {
+	return classifyXRPCError(err) == xrpcErrorRecordNotFound
+}
+
+// isSwapConflict reports whether a write was rejected because the record or
+// the repo changed since its swapRecord or swapCommit was read.
+func isSwapConflict(err error) bool {
+	return classifyXRPCError(err) == xrpcErrorConflict
+}
+
+// swapConflictDetail returns the detail of the diagnostic of a write rejected
+// by isSwapConflict. The subject names the record or repo which changed.
+func swapConflictDetail(subject string, err error) string {
+	return subject + " was changed by another client since Terraform last read it, so the write was rejected instead of overwriting those changes. " +
+		"Run terraform apply again to review the changes in a new plan.\n\nError: " + err.Error()
+}
@@ -1,9 +1,13 @@
 package test
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
@@ -54,6 +58,125 @@ func TestAccAccountResource(t *testing.T) {
 	})
 }
 
+// adminStandIn is a PDS managing accounts on behalf of its admin, which keeps
+// the accounts in memory.
+type adminStandIn struct {
+	mu       sync.Mutex
+	accounts map[string]map[string]any
+	created  int
+}
+
+func newAdminStandIn(t *testing.T) (*adminStandIn, *httptest.Server) {
+	s := &adminStandIn{accounts: map[string]map[string]any{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /xrpc/com.atproto.server.createInviteCode", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"code": "invite"})
+	})
+	mux.HandleFunc("POST /xrpc/com.atproto.server.createAccount", func(w http.ResponseWriter, r *http.Request) {
+		var input struct {
+			Handle string `json:"handle"`
+			Email  string `json:"email"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&input)
+
+		s.mu.Lock()
+		s.created++
+		did := fmt.Sprintf("did:plc:account%d", s.created)
+		s.accounts[did] = map[string]any{
+			"did":       did,
+			"handle":    input.Handle,
+			"email":     input.Email,
+			"indexedAt": "2024-01-01T00:00:00Z",
+		}
+		s.mu.Unlock()
+
+		writeJSON(w, http.StatusOK, map[string]any{
+			"accessJwt":  did,
+			"refreshJwt": "refresh",
+			"did":        did,
+			"handle":     input.Handle,
+		})
+	})
+	mux.HandleFunc("GET /xrpc/com.atproto.admin.getAccountInfo", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		account, ok := s.accounts[r.URL.Query().Get("did")]
+		s.mu.Unlock()
+		if !ok {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "NotFound", "message": "Account not found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, account)
+	})
+	mux.HandleFunc("POST /xrpc/com.atproto.admin.deleteAccount", func(w http.ResponseWriter, r *http.Request) {
+		var input struct {
+			Did string `json:"did"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&input)
+
+		s.mu.Lock()
+		delete(s.accounts, input.Did)
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return s, server
+}
+
+// TestAccAccountResourceDeletedOutOfBand checks that an account deleted by
+// another admin is created again.
+func TestAccAccountResourceDeletedOutOfBand(t *testing.T) {
+	standIn, pds := newAdminStandIn(t)
+	var firstDid string
+	// Configure the provider with the admin password alone.
+	t.Setenv("BSKY_HANDLE", "")
+	t.Setenv("BSKY_PASSWORD", "")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccAccountDeletedOutOfBandConfig(pds.URL),
+				Check: func(s *terraform.State) error {
+					firstDid = s.RootModule().Resources["bsky_account.test"].Primary.Attributes["did"]
+					return nil
+				},
+			},
+			{
+				PreConfig: func() {
+					standIn.mu.Lock()
+					delete(standIn.accounts, firstDid)
+					standIn.mu.Unlock()
+				},
+				Config: testAccAccountDeletedOutOfBandConfig(pds.URL),
+				Check: func(s *terraform.State) error {
+					if did := s.RootModule().Resources["bsky_account.test"].Primary.Attributes["did"]; did == firstDid {
+						return fmt.Errorf("expected the account to be created again, got %s", did)
+					}
+					return nil
+				},
+			},
+		},
+	})
+}
+
+func testAccAccountDeletedOutOfBandConfig(pdsHost string) string {
+	return fmt.Sprintf(`
+		provider "bsky" {
+			pds_host           = %q
+			pds_admin_password = "admin"
+		}
+
+		resource "bsky_account" "test" {
+			handle   = "member.test"
+			email    = "member@example.com"
+			password = "password"
+		}
+	`, pdsHost)
+}
+
 func pdsDomain() string {
 	return strings.Replace(os.Getenv("BSKY_PDS_HOST"), "https://", "", 1)
 }
@@ -0,0 +1,551 @@
+package test
+
+import (
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// accountsStandIn is a PDS hosting several accounts, which keeps the records
+// created by each account in memory. Every handle ending in .test resolves.
+// Tests pass the accountsStandInFeatures they need on top, such as the
+// AppView views of lists.
+type accountsStandIn struct {
+	mu      sync.Mutex
+	logins  map[string]int
+	records map[string]json.RawMessage
+	// mutes holds the lists muted by each account, keyed by DID and list URI.
+	mutes   map[string]bool
+	nextKey int
+	// applyWrites counts the applyWrites calls.
+	applyWrites int
+	// handles overrides the DIDs handles resolve to.
+	handles map[string]string
+	// repoError makes getRecord fail with the repo-level error it names, such
+	// as RepoDeactivated or RepoNotFound.
+	repoError string
+	// commits counts the commits of each repo, and swapCommits the writes
+	// which passed swapCommit.
+	commits     map[string]int
+	swapCommits int
+	// races names the read methods after which another client writes to the
+	// record or repo which was read, before the provider gets to write.
+	races map[string]bool
+}
+
+// accountsStandInFeedService is the DID of the feed generator service of the
+// stand-in.
+const accountsStandInFeedService = "did:plc:feedservice"
+
+// accountsStandInCid returns the CID of a record of the stand-in, which changes
+// with its content.
+func accountsStandInCid(record json.RawMessage) string {
+	sum := sha256.Sum256(record)
+	return "bafyrei" + strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(sum[:]))
+}
+
+// commit returns the CID of the latest commit of a repo of the stand-in. s.mu
+// must be held.
+func (s *accountsStandIn) commit(repo string) string {
+	return fmt.Sprintf("bafyreicommit%s%d", strings.TrimPrefix(repo, "did:plc:"), s.commits[repo])
+}
+
+// swap checks the swapRecord and swapCommit of a write, and counts the
+// commit of the write when they match. s.mu must be held.
+func (s *accountsStandIn) swap(w http.ResponseWriter, repo string, uri string, swapRecord *string, swapCommit *string) bool {
+	if swapRecord != nil && (s.records[uri] == nil || accountsStandInCid(s.records[uri]) != *swapRecord) {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "InvalidSwap", "message": "Record was at " + accountsStandInCid(s.records[uri])})
+		return false
+	}
+	if swapCommit != nil {
+		if *swapCommit != s.commit(repo) {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "InvalidSwap", "message": "Commit was at " + s.commit(repo)})
+			return false
+		}
+		s.swapCommits++
+	}
+	s.commits[repo]++
+	return true
+}
+
+// race returns a PreConfig function which starts or stops the races after the
+// read method.
+func (s *accountsStandIn) race(method string, enabled bool) func() {
+	return func() {
+		s.mu.Lock()
+		s.races[method] = enabled
+		s.mu.Unlock()
+	}
+}
+
+// accountsStandInDid returns the DID of an account of the stand-in.
+func accountsStandInDid(handle string) string {
+	return "did:plc:" + strings.ReplaceAll(handle, ".", "")
+}
+
+// accountsStandInFeature registers the handlers of a feature of the stand-in
+// which only some tests need.
+type accountsStandInFeature func(s *accountsStandIn, mux *http.ServeMux)
+
+// newAccountsStandIn starts the stand-in, which serves the sessions, handles
+// and repos of its accounts, and the features the test asks for. Any other
+// method fails with MethodNotImplemented.
+func newAccountsStandIn(t *testing.T, features ...accountsStandInFeature) (*accountsStandIn, *httptest.Server) {
+	s := &accountsStandIn{
+		logins:  map[string]int{},
+		records: map[string]json.RawMessage{},
+		mutes:   map[string]bool{},
+		handles: map[string]string{},
+		commits: map[string]int{},
+		races:   map[string]bool{},
+	}
+
+	mux := http.NewServeMux()
+	s.serveAccounts(mux)
+	s.serveRepos(mux)
+	for _, feature := range features {
+		feature(s, mux)
+	}
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusNotImplemented, map[string]any{"error": "MethodNotImplemented", "message": r.URL.Path})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return s, server
+}
+
+// accountsStandInAuthorized reports whether a request is authorized to write
+// to repo. Access tokens are the DID of the account.
+func accountsStandInAuthorized(r *http.Request, repo string) bool {
+	return r.Header.Get("Authorization") == "Bearer "+repo
+}
+
+// serveAccounts signs in to every account, and resolves the handles of the
+// stand-in.
+func (s *accountsStandIn) serveAccounts(mux *http.ServeMux) {
+	mux.HandleFunc("POST /xrpc/com.atproto.server.createSession", func(w http.ResponseWriter, r *http.Request) {
+		var input struct {
+			Identifier string `json:"identifier"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&input)
+
+		s.mu.Lock()
+		s.logins[input.Identifier]++
+		s.mu.Unlock()
+
+		writeJSON(w, http.StatusOK, map[string]any{
+			"accessJwt":  accountsStandInDid(input.Identifier),
+			"refreshJwt": "refresh",
+			"did":        accountsStandInDid(input.Identifier),
+			"handle":     input.Identifier,
+		})
+	})
+	mux.HandleFunc("GET /xrpc/com.atproto.identity.resolveHandle", func(w http.ResponseWriter, r *http.Request) {
+		handle := r.URL.Query().Get("handle")
+		s.mu.Lock()
+		did, ok := s.handles[handle]
+		s.mu.Unlock()
+		if ok {
+			writeJSON(w, http.StatusOK, map[string]any{"did": did})
+			return
+		}
+		if !strings.HasSuffix(handle, ".test") {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "InvalidRequest", "message": "Unable to resolve handle"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"did": accountsStandInDid(handle)})
+	})
+}
+
+// serveRepos serves the records of the accounts, including the writes
+// checked against swapRecord and swapCommit.
+func (s *accountsStandIn) serveRepos(mux *http.ServeMux) {
+	mux.HandleFunc("POST /xrpc/com.atproto.repo.createRecord", func(w http.ResponseWriter, r *http.Request) {
+		var input struct {
+			Repo       string          `json:"repo"`
+			Collection string          `json:"collection"`
+			Rkey       string          `json:"rkey"`
+			Record     json.RawMessage `json:"record"`
+			SwapCommit *string         `json:"swapCommit"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&input)
+		if !accountsStandInAuthorized(r, input.Repo) {
+			writeJSON(w, http.StatusUnauthorized, map[string]any{"error": "AuthRequired", "message": "not the owner of " + input.Repo})
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.nextKey++
+		uri := fmt.Sprintf("at://%s/%s/3lbo5zov45j%03d", input.Repo, input.Collection, s.nextKey)
+		if input.Rkey != "" {
+			uri = "at://" + input.Repo + "/" + input.Collection + "/" + input.Rkey
+		}
+		if _, exists := s.records[uri]; exists {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "InvalidRequest", "message": "Record already exists: " + uri})
+			return
+		}
+		if !s.swap(w, input.Repo, uri, nil, input.SwapCommit) {
+			return
+		}
+		s.records[uri] = input.Record
+
+		writeJSON(w, http.StatusOK, map[string]any{
+			"uri": uri,
+			"cid": accountsStandInCid(input.Record),
+		})
+	})
+	mux.HandleFunc("POST /xrpc/com.atproto.repo.putRecord", func(w http.ResponseWriter, r *http.Request) {
+		var input struct {
+			Repo       string          `json:"repo"`
+			Collection string          `json:"collection"`
+			Rkey       string          `json:"rkey"`
+			Record     json.RawMessage `json:"record"`
+			SwapRecord *string         `json:"swapRecord"`
+			SwapCommit *string         `json:"swapCommit"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&input)
+		if !accountsStandInAuthorized(r, input.Repo) {
+			writeJSON(w, http.StatusUnauthorized, map[string]any{"error": "AuthRequired", "message": "not the owner of " + input.Repo})
+			return
+		}
+
+		uri := "at://" + input.Repo + "/" + input.Collection + "/" + input.Rkey
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if !s.swap(w, input.Repo, uri, input.SwapRecord, input.SwapCommit) {
+			return
+		}
+		s.records[uri] = input.Record
+
+		writeJSON(w, http.StatusOK, map[string]any{
+			"uri": uri,
+			"cid": accountsStandInCid(input.Record),
+		})
+	})
+	mux.HandleFunc("GET /xrpc/com.atproto.repo.getRecord", func(w http.ResponseWriter, r *http.Request) {
+		uri := "at://" + r.URL.Query().Get("repo") + "/" + r.URL.Query().Get("collection") + "/" + r.URL.Query().Get("rkey")
+
+		s.mu.Lock()
+		record, ok := s.records[uri]
+		repoError := s.repoError
+		if ok && s.races["com.atproto.repo.getRecord"] {
+			// Change the CID without changing the value of the record.
+			s.records[uri] = append(append(json.RawMessage{}, record...), ' ')
+			s.commits[r.URL.Query().Get("repo")]++
+		}
+		s.mu.Unlock()
+		if repoError != "" {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": repoError, "message": "Could not find repo: " + r.URL.Query().Get("repo")})
+			return
+		}
+		if !ok {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "RecordNotFound", "message": "Could not locate record: " + uri})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]any{
+			"uri":   uri,
+			"cid":   accountsStandInCid(record),
+			"value": record,
+		})
+	})
+	mux.HandleFunc("POST /xrpc/com.atproto.repo.deleteRecord", func(w http.ResponseWriter, r *http.Request) {
+		var input struct {
+			Repo       string  `json:"repo"`
+			Collection string  `json:"collection"`
+			Rkey       string  `json:"rkey"`
+			SwapRecord *string `json:"swapRecord"`
+			SwapCommit *string `json:"swapCommit"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&input)
+		if !accountsStandInAuthorized(r, input.Repo) {
+			writeJSON(w, http.StatusUnauthorized, map[string]any{"error": "AuthRequired", "message": "not the owner of " + input.Repo})
+			return
+		}
+
+		uri := "at://" + input.Repo + "/" + input.Collection + "/" + input.Rkey
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if !s.swap(w, input.Repo, uri, input.SwapRecord, input.SwapCommit) {
+			return
+		}
+		delete(s.records, uri)
+
+		writeJSON(w, http.StatusOK, map[string]any{})
+	})
+	mux.HandleFunc("GET /xrpc/com.atproto.repo.listRecords", func(w http.ResponseWriter, r *http.Request) {
+		prefix := "at://" + r.URL.Query().Get("repo") + "/" + r.URL.Query().Get("collection") + "/"
+		limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+		if err != nil || limit <= 0 {
+			limit = 50
+		}
+
+		s.mu.Lock()
+		var uris []string
+		for uri := range s.records {
+			if strings.HasPrefix(uri, prefix) && uri > prefix+r.URL.Query().Get("cursor") {
+				uris = append(uris, uri)
+			}
+		}
+		sort.Strings(uris)
+		output := map[string]any{}
+		if len(uris) > limit {
+			uris = uris[:limit]
+			output["cursor"] = strings.TrimPrefix(uris[limit-1], prefix)
+		}
+		records := []any{}
+		for _, uri := range uris {
+			records = append(records, map[string]any{
+				"uri":   uri,
+				"cid":   accountsStandInCid(s.records[uri]),
+				"value": s.records[uri],
+			})
+		}
+		s.mu.Unlock()
+
+		output["records"] = records
+		writeJSON(w, http.StatusOK, output)
+	})
+	mux.HandleFunc("POST /xrpc/com.atproto.repo.applyWrites", func(w http.ResponseWriter, r *http.Request) {
+		var input struct {
+			Repo   string `json:"repo"`
+			Writes []struct {
+				Type       string          `json:"$type"`
+				Collection string          `json:"collection"`
+				Rkey       string          `json:"rkey"`
+				Value      json.RawMessage `json:"value"`
+			} `json:"writes"`
+			SwapCommit *string `json:"swapCommit"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&input)
+		if !accountsStandInAuthorized(r, input.Repo) {
+			writeJSON(w, http.StatusUnauthorized, map[string]any{"error": "AuthRequired", "message": "not the owner of " + input.Repo})
+			return
+		}
+		if len(input.Writes) > 200 {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "InvalidRequest", "message": "Too many writes. Max: 200"})
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if !s.swap(w, input.Repo, "", nil, input.SwapCommit) {
+			return
+		}
+		s.applyWrites++
+		results := []any{}
+		for _, write := range input.Writes {
+			switch write.Type {
+			case "com.atproto.repo.applyWrites#create":
+				s.nextKey++
+				uri := fmt.Sprintf("at://%s/%s/3lbo5zov45j%03d", input.Repo, write.Collection, s.nextKey)
+				s.records[uri] = write.Value
+				results = append(results, map[string]any{
+					"$type": "com.atproto.repo.applyWrites#createResult",
+					"uri":   uri,
+					"cid":   accountsStandInCid(write.Value),
+				})
+			case "com.atproto.repo.applyWrites#delete":
+				delete(s.records, "at://"+input.Repo+"/"+write.Collection+"/"+write.Rkey)
+				results = append(results, map[string]any{"$type": "com.atproto.repo.applyWrites#deleteResult"})
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"results": results})
+	})
+	mux.HandleFunc("GET /xrpc/com.atproto.sync.getLatestCommit", func(w http.ResponseWriter, r *http.Request) {
+		repo := r.URL.Query().Get("did")
+
+		s.mu.Lock()
+		commit := map[string]any{"cid": s.commit(repo), "rev": fmt.Sprintf("3lbo5zov45j%03d", s.commits[repo])}
+		if s.races["com.atproto.sync.getLatestCommit"] {
+			s.commits[repo]++
+		}
+		s.mu.Unlock()
+
+		writeJSON(w, http.StatusOK, commit)
+	})
+}
+
+// withBlobs accepts blob uploads, such as images embedded in posts.
+func withBlobs(_ *accountsStandIn, mux *http.ServeMux) {
+	mux.HandleFunc("POST /xrpc/com.atproto.repo.uploadBlob", func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		writeJSON(w, http.StatusOK, map[string]any{
+			"blob": map[string]any{
+				"$type":    "blob",
+				"ref":      map[string]any{"$link": "bafkreibme22gw2h7y2h7tg2fhqotaqjucnbc24deqo72b6mkl2egezxhvy"},
+				"mimeType": r.Header.Get("Content-Type"),
+				"size":     len(data),
+			},
+		})
+	})
+}
+
+// withListViews serves the AppView views of lists, with their items and
+// whether the viewer mutes them.
+func withListViews(s *accountsStandIn, mux *http.ServeMux) {
+	mux.HandleFunc("GET /xrpc/app.bsky.graph.getList", func(w http.ResponseWriter, r *http.Request) {
+		uri := r.URL.Query().Get("list")
+		viewer := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+		if err != nil || limit <= 0 {
+			limit = 50
+		}
+
+		s.mu.Lock()
+		record, ok := s.records[uri]
+		muted := s.mutes[viewer+" "+uri]
+		var itemUris []string
+		for itemUri, item := range s.records {
+			var listItem struct {
+				List string `json:"list"`
+			}
+			if strings.Contains(itemUri, "/app.bsky.graph.listitem/") && json.Unmarshal(item, &listItem) == nil && listItem.List == uri && itemUri > r.URL.Query().Get("cursor") {
+				itemUris = append(itemUris, itemUri)
+			}
+		}
+		sort.Strings(itemUris)
+		var cursor any
+		if len(itemUris) > limit {
+			itemUris = itemUris[:limit]
+			cursor = itemUris[limit-1]
+		}
+		items := []any{}
+		for _, itemUri := range itemUris {
+			var listItem struct {
+				Subject string `json:"subject"`
+			}
+			_ = json.Unmarshal(s.records[itemUri], &listItem)
+			items = append(items, map[string]any{
+				"uri":     itemUri,
+				"subject": map[string]any{"did": listItem.Subject, "handle": strings.TrimPrefix(listItem.Subject, "did:plc:") + ".test"},
+			})
+		}
+		s.mu.Unlock()
+		if !ok {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "InvalidRequest", "message": "List not found"})
+			return
+		}
+
+		var list struct {
+			Name    string `json:"name"`
+			Purpose string `json:"purpose"`
+		}
+		_ = json.Unmarshal(record, &list)
+		creator := strings.Split(strings.TrimPrefix(uri, "at://"), "/")[0]
+		writeJSON(w, http.StatusOK, map[string]any{
+			"list": map[string]any{
+				"uri":       uri,
+				"cid":       "bafyreie5737gdxlw5i64vzichcalba3z2v5n6icifvx5xytvske7mr3hpm",
+				"name":      list.Name,
+				"purpose":   list.Purpose,
+				"creator":   map[string]any{"did": creator, "handle": "creator.test"},
+				"indexedAt": "2024-11-20T15:04:05Z",
+				"viewer":    map[string]any{"muted": muted},
+			},
+			"items":  items,
+			"cursor": cursor,
+		})
+	})
+}
+
+// withListMutes mutes and unmutes lists for the account signed in.
+func withListMutes(s *accountsStandIn, mux *http.ServeMux) {
+	mux.HandleFunc("POST /xrpc/app.bsky.graph.muteActorList", func(w http.ResponseWriter, r *http.Request) {
+		var input struct {
+			List string `json:"list"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&input)
+		viewer := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		s.mu.Lock()
+		s.mutes[viewer+" "+input.List] = true
+		s.mu.Unlock()
+
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("POST /xrpc/app.bsky.graph.unmuteActorList", func(w http.ResponseWriter, r *http.Request) {
+		var input struct {
+			List string `json:"list"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&input)
+		viewer := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		s.mu.Lock()
+		delete(s.mutes, viewer+" "+input.List)
+		s.mu.Unlock()
+
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+// withFeedGenerators serves the AppView views of feed generators, and the PLC
+// directory and feed generator service of accountsStandInFeedService.
+func withFeedGenerators(s *accountsStandIn, mux *http.ServeMux) {
+	mux.HandleFunc("GET /xrpc/app.bsky.feed.getFeedGenerator", func(w http.ResponseWriter, r *http.Request) {
+		uri := r.URL.Query().Get("feed")
+
+		s.mu.Lock()
+		record, ok := s.records[uri]
+		s.mu.Unlock()
+		if !ok {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "InvalidRequest", "message": "could not find feed"})
+			return
+		}
+
+		var generator map[string]any
+		_ = json.Unmarshal(record, &generator)
+		creator := strings.Split(strings.TrimPrefix(uri, "at://"), "/")[0]
+		view := map[string]any{
+			"uri":       uri,
+			"cid":       "bafyreie5737gdxlw5i64vzichcalba3z2v5n6icifvx5xytvske7mr3hpm",
+			"did":       generator["did"],
+			"creator":   map[string]any{"did": creator, "handle": "creator.test"},
+			"likeCount": 3,
+			"indexedAt": "2024-11-20T15:04:05Z",
+		}
+		for _, field := range []string{"displayName", "description", "acceptsInteractions", "contentMode"} {
+			if value, ok := generator[field]; ok {
+				view[field] = value
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"view":     view,
+			"isOnline": generator["did"] == accountsStandInFeedService,
+			"isValid":  generator["did"] == accountsStandInFeedService,
+		})
+	})
+	mux.HandleFunc("GET /xrpc/app.bsky.feed.describeFeedGenerator", func(w http.ResponseWriter, r *http.Request) {
+		feeds := []any{}
+		s.mu.Lock()
+		for uri := range s.records {
+			if strings.Contains(uri, "/app.bsky.feed.generator/") {
+				feeds = append(feeds, map[string]any{"uri": uri})
+			}
+		}
+		s.mu.Unlock()
+		writeJSON(w, http.StatusOK, map[string]any{"did": accountsStandInFeedService, "feeds": feeds})
+	})
+	mux.HandleFunc("GET /"+accountsStandInFeedService, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"id": accountsStandInFeedService,
+			"service": []any{map[string]any{
+				"id":              "#bsky_fg",
+				"type":            "BskyFeedGenerator",
+				"serviceEndpoint": "http://" + r.Host,
+			}},
+		})
+	})
+}
@@ -0,0 +1,69 @@
+package test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccFeedGeneratorDataSource(t *testing.T) {
+	_, pds := newAccountsStandIn(t, withFeedGenerators)
+	uri := "at://" + accountsStandInDid("default.test") + "/app.bsky.feed.generator/cats"
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccFeedGeneratorResourceConfig(pds.URL, `
+					display_name = "Cat Pictures"
+					description  = "Only cats"
+				`) + `
+					data "bsky_feed_generator" "test" {
+						uri = bsky_feed_generator.test.uri
+					}
+				`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.bsky_feed_generator.test", "display_name", "Cat Pictures"),
+					resource.TestCheckResourceAttr("data.bsky_feed_generator.test", "description", "Only cats"),
+					resource.TestCheckResourceAttr("data.bsky_feed_generator.test", "service_did", accountsStandInFeedService),
+					resource.TestCheckResourceAttr("data.bsky_feed_generator.test", "creator_did", accountsStandInDid("default.test")),
+					resource.TestCheckResourceAttr("data.bsky_feed_generator.test", "like_count", "3"),
+					resource.TestCheckResourceAttr("data.bsky_feed_generator.test", "is_online", "true"),
+					resource.TestCheckResourceAttr("data.bsky_feed_generator.test", "is_valid", "true"),
+					resource.TestCheckResourceAttr("data.bsky_feed_generator.test", "service_endpoint", pds.URL),
+					resource.TestCheckResourceAttr("data.bsky_feed_generator.test", "service_feeds.#", "1"),
+					resource.TestCheckResourceAttr("data.bsky_feed_generator.test", "service_feeds.0", uri),
+				),
+			},
+			// A feed whose service can't be resolved is still read from the
+			// AppView, which reports it offline.
+			{
+				Config: fmt.Sprintf(`
+					provider "bsky" {
+						pds_host = %[1]q
+						plc_url  = %[1]q
+						handle   = "default.test"
+						password = "password"
+					}
+
+					resource "bsky_feed_generator" "test" {
+						rkey         = "cats"
+						service_did  = "did:plc:offline"
+						display_name = "Cat Pictures"
+					}
+
+					data "bsky_feed_generator" "test" {
+						uri = bsky_feed_generator.test.uri
+					}
+				`, pds.URL),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.bsky_feed_generator.test", "is_online", "false"),
+					resource.TestCheckResourceAttr("data.bsky_feed_generator.test", "is_valid", "false"),
+					resource.TestCheckNoResourceAttr("data.bsky_feed_generator.test", "service_endpoint"),
+					resource.TestCheckNoResourceAttr("data.bsky_feed_generator.test", "service_feeds"),
+				),
+			},
+		},
+	})
+}
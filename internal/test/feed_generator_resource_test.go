@@ -0,0 +1,151 @@
+package test
+
+import (
+	"fmt"
+	"image/color"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+func TestAccFeedGeneratorResource(t *testing.T) {
+	standIn, pds := newAccountsStandIn(t)
+	red := writeTestImage(t, "red.png", color.RGBA{R: 255, A: 255})
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Create and Read testing
+			{
+				Config: testAccFeedGeneratorResourceConfig(pds.URL, fmt.Sprintf(`
+					display_name         = "Cat Pictures"
+					description          = "Only cats"
+					avatar_file          = %q
+					accepts_interactions = true
+					content_mode         = "app.bsky.feed.defs#contentModeVideo"
+				`, red)),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("bsky_feed_generator.test", "uri", "at://"+accountsStandInDid("default.test")+"/app.bsky.feed.generator/cats"),
+					resource.TestCheckResourceAttr("bsky_feed_generator.test", "service_did", accountsStandInFeedService),
+					resource.TestCheckResourceAttrSet("bsky_feed_generator.test", "avatar_hash"),
+					resource.TestCheckResourceAttrSet("bsky_feed_generator.test", "cid"),
+					func(s *terraform.State) error {
+						generator, err := standInRecord(standIn, s, "bsky_feed_generator.test")
+						if err != nil {
+							return err
+						}
+						if generator["did"] != accountsStandInFeedService || generator["displayName"] != "Cat Pictures" {
+							return fmt.Errorf("unexpected feed generator %v", generator)
+						}
+						if generator["acceptsInteractions"] != true || generator["contentMode"] != "app.bsky.feed.defs#contentModeVideo" {
+							return fmt.Errorf("unexpected feed generator %v", generator)
+						}
+						if generator["avatar"] == nil {
+							return fmt.Errorf("expected an avatar, got none")
+						}
+						return nil
+					},
+				),
+			},
+			// ImportState testing
+			{
+				ResourceName:                         "bsky_feed_generator.test",
+				ImportState:                          true,
+				ImportStateIdFunc:                    testAccFeedGeneratorImportID,
+				ImportStateVerify:                    true,
+				ImportStateVerifyIdentifierAttribute: "uri",
+				ImportStateVerifyIgnore:              []string{"avatar_file", "avatar_hash"},
+			},
+			// Update and Read testing
+			{
+				Config: testAccFeedGeneratorResourceConfig(pds.URL, `
+					display_name = "Cat Videos"
+				`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("bsky_feed_generator.test", "display_name", "Cat Videos"),
+					resource.TestCheckNoResourceAttr("bsky_feed_generator.test", "description"),
+					resource.TestCheckNoResourceAttr("bsky_feed_generator.test", "avatar_hash"),
+					resource.TestCheckNoResourceAttr("bsky_feed_generator.test", "content_mode"),
+					func(s *terraform.State) error {
+						generator, err := standInRecord(standIn, s, "bsky_feed_generator.test")
+						if err != nil {
+							return err
+						}
+						if generator["displayName"] != "Cat Videos" || generator["description"] != nil || generator["avatar"] != nil {
+							return fmt.Errorf("unexpected feed generator %v", generator)
+						}
+						if generator["createdAt"] == nil {
+							return fmt.Errorf("expected the creation date to be kept, got %v", generator)
+						}
+						return nil
+					},
+				),
+			},
+			// A feed deleted outside of Terraform is declared again.
+			{
+				PreConfig: func() {
+					standIn.mu.Lock()
+					delete(standIn.records, "at://"+accountsStandInDid("default.test")+"/app.bsky.feed.generator/cats")
+					standIn.mu.Unlock()
+				},
+				Config: testAccFeedGeneratorResourceConfig(pds.URL, `
+					display_name = "Cat Videos"
+				`),
+				Check: func(s *terraform.State) error {
+					_, err := standInRecord(standIn, s, "bsky_feed_generator.test")
+					return err
+				},
+			},
+			// Invalid record keys and service DIDs fail the plan.
+			{
+				Config:      testAccFeedGeneratorInvalidConfig(pds.URL, "dogs", "feeds.example.com"),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("Invalid DID"),
+			},
+			{
+				Config:      testAccFeedGeneratorInvalidConfig(pds.URL, "dogs/cats", accountsStandInFeedService),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("Invalid record key"),
+			},
+		},
+	})
+}
+
+func testAccFeedGeneratorInvalidConfig(pdsHost string, rkey string, serviceDid string) string {
+	return fmt.Sprintf(`
+		provider "bsky" {
+			pds_host = %q
+			handle   = "default.test"
+			password = "password"
+		}
+
+		resource "bsky_feed_generator" "invalid" {
+			rkey         = %q
+			service_did  = %q
+			display_name = "Dogs"
+		}
+	`, pdsHost, rkey, serviceDid)
+}
+
+func testAccFeedGeneratorImportID(s *terraform.State) (string, error) {
+	return s.RootModule().Resources["bsky_feed_generator.test"].Primary.Attributes["uri"], nil
+}
+
+func testAccFeedGeneratorResourceConfig(pdsHost string, attributes string) string {
+	return fmt.Sprintf(`
+		provider "bsky" {
+			pds_host = %q
+			plc_url  = %[1]q
+			handle   = "default.test"
+			password = "password"
+		}
+
+		resource "bsky_feed_generator" "test" {
+			rkey        = "cats"
+			service_did = %q
+			%s
+		}
+	`, pdsHost, accountsStandInFeedService, attributes)
+}
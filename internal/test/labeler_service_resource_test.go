@@ -0,0 +1,193 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+func TestAccLabelerServiceResource(t *testing.T) {
+	standIn, pds := newAccountsStandIn(t)
+	did := accountsStandInDid("default.test")
+	uri := "at://" + did + "/app.bsky.labeler.service/self"
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Create and Read testing
+			{
+				Config: testAccLabelerServiceResourceConfig(pds.URL, `
+					label_values = ["!hide", "spoiler"]
+					label_value_definitions = [{
+						identifier = "spoiler"
+						severity   = "inform"
+						blurs      = "content"
+						locales = [
+							{ lang = "en", name = "Spoiler", description = "Reveals the plot" },
+							{ lang = "fr", name = "Divulgâcheur", description = "Révèle l'intrigue" },
+						]
+					}]
+					reason_types        = ["com.atproto.moderation.defs#reasonSpam"]
+					subject_types       = ["account", "record"]
+					subject_collections = ["app.bsky.feed.post"]
+				`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("bsky_labeler_service.test", "did", did),
+					resource.TestCheckResourceAttr("bsky_labeler_service.test", "uri", uri),
+					resource.TestCheckResourceAttr("bsky_labeler_service.test", "label_value_definitions.0.default_setting", "warn"),
+					resource.TestCheckResourceAttr("bsky_labeler_service.test", "label_value_definitions.0.adult_only", "false"),
+					resource.TestCheckResourceAttr("bsky_labeler_service.test", "label_value_definitions.0.locales.#", "2"),
+					resource.TestCheckResourceAttrSet("bsky_labeler_service.test", "cid"),
+					func(s *terraform.State) error {
+						service, err := standInRecord(standIn, s, "bsky_labeler_service.test")
+						if err != nil {
+							return err
+						}
+						policies, _ := service["policies"].(map[string]any)
+						definitions, _ := policies["labelValueDefinitions"].([]any)
+						if len(definitions) != 1 {
+							return fmt.Errorf("expected one label value definition, got %v", policies)
+						}
+						expected := map[string]any{
+							"identifier":     "spoiler",
+							"severity":       "inform",
+							"blurs":          "content",
+							"defaultSetting": "warn",
+							"adultOnly":      false,
+							"locales": []any{
+								map[string]any{"lang": "en", "name": "Spoiler", "description": "Reveals the plot"},
+								map[string]any{"lang": "fr", "name": "Divulgâcheur", "description": "Révèle l'intrigue"},
+							},
+						}
+						if !reflect.DeepEqual(definitions[0], expected) {
+							return fmt.Errorf("expected label value definition %v, got %v", expected, definitions[0])
+						}
+						if !reflect.DeepEqual(service["subjectCollections"], []any{"app.bsky.feed.post"}) {
+							return fmt.Errorf("unexpected subject collections %v", service["subjectCollections"])
+						}
+						return nil
+					},
+				),
+			},
+			// ImportState testing
+			{
+				ResourceName:                         "bsky_labeler_service.test",
+				ImportState:                          true,
+				ImportStateId:                        did,
+				ImportStateVerify:                    true,
+				ImportStateVerifyIdentifierAttribute: "did",
+			},
+			// Changes of nested fields outside of Terraform show up as drift.
+			{
+				PreConfig: func() {
+					standIn.mu.Lock()
+					defer standIn.mu.Unlock()
+					var service map[string]any
+					_ = json.Unmarshal(standIn.records[uri], &service)
+					definition := service["policies"].(map[string]any)["labelValueDefinitions"].([]any)[0].(map[string]any)
+					definition["locales"].([]any)[1].(map[string]any)["name"] = "Spoiler"
+					standIn.records[uri], _ = json.Marshal(service)
+				},
+				RefreshState:       true,
+				ExpectNonEmptyPlan: true,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("bsky_labeler_service.test", "label_value_definitions.0.locales.1.name", "Spoiler"),
+				),
+			},
+			// Update and Read testing
+			{
+				Config: testAccLabelerServiceResourceConfig(pds.URL, `
+					label_values = ["spoiler"]
+					label_value_definitions = [{
+						identifier      = "spoiler"
+						severity        = "alert"
+						blurs           = "media"
+						default_setting = "hide"
+						adult_only      = true
+						locales = [
+							{ lang = "en", name = "Spoiler", description = "Reveals the plot" },
+						]
+					}]
+				`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("bsky_labeler_service.test", "label_value_definitions.0.severity", "alert"),
+					resource.TestCheckNoResourceAttr("bsky_labeler_service.test", "reason_types"),
+					func(s *terraform.State) error {
+						service, err := standInRecord(standIn, s, "bsky_labeler_service.test")
+						if err != nil {
+							return err
+						}
+						definition := service["policies"].(map[string]any)["labelValueDefinitions"].([]any)[0].(map[string]any)
+						if definition["defaultSetting"] != "hide" || definition["adultOnly"] != true || definition["blurs"] != "media" {
+							return fmt.Errorf("unexpected label value definition %v", definition)
+						}
+						if service["reasonTypes"] != nil || service["createdAt"] == nil {
+							return fmt.Errorf("unexpected labeler service %v", service)
+						}
+						return nil
+					},
+				),
+			},
+			// Definitions are validated at plan time.
+			{
+				Config: testAccLabelerServiceResourceConfig(pds.URL, `
+					label_values = ["!hide"]
+					label_value_definitions = [{
+						identifier = "spoiler"
+						severity   = "inform"
+						blurs      = "content"
+						locales    = [{ lang = "en", name = "Spoiler", description = "Reveals the plot" }]
+					}]
+				`),
+				ExpectError: regexp.MustCompile("Unpublished label value definition"),
+			},
+			{
+				Config: testAccLabelerServiceResourceConfig(pds.URL, `
+					label_values = ["spoiler"]
+					label_value_definitions = [{
+						identifier = "spoiler"
+						severity   = "inform"
+						blurs      = "content"
+						locales = [
+							{ lang = "en", name = "Spoiler", description = "Reveals the plot" },
+							{ lang = "en", name = "Plot twist", description = "Reveals the plot" },
+						]
+					}]
+				`),
+				ExpectError: regexp.MustCompile("Duplicate label value locale"),
+			},
+			{
+				Config: testAccLabelerServiceResourceConfig(pds.URL, `
+					label_values = ["Spoiler"]
+				`),
+				ExpectError: regexp.MustCompile("must only contain lowercase letters and hyphens"),
+			},
+			{
+				Config: testAccLabelerServiceResourceConfig(pds.URL, `
+					label_values  = ["spoiler"]
+					subject_types = ["post"]
+				`),
+				ExpectError: regexp.MustCompile("Invalid Attribute Value Match"),
+			},
+		},
+	})
+}
+
+func testAccLabelerServiceResourceConfig(pdsHost string, attributes string) string {
+	return fmt.Sprintf(`
+		provider "bsky" {
+			pds_host = %q
+			handle   = "default.test"
+			password = "password"
+		}
+
+		resource "bsky_labeler_service" "test" {
+			%s
+		}
+	`, pdsHost, attributes)
+}
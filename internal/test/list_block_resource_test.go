@@ -0,0 +1,97 @@
+package test
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+func TestAccListBlockResource(t *testing.T) {
+	standIn, pds := newAccountsStandIn(t)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Create and Read testing
+			{
+				Config: testAccListBlockResourceConfig(pds.URL, "bsky_list.modlist"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrPair("bsky_list_block.test", "list_uri", "bsky_list.modlist", "uri"),
+					resource.TestCheckResourceAttrSet("bsky_list_block.test", "cid"),
+					resource.TestCheckResourceAttrWith("bsky_list_block.test", "uri", func(uri string) error {
+						return expectRepo(uri, accountsStandInDid("member.test"))
+					}),
+					func(s *terraform.State) error {
+						listBlock, err := standInRecord(standIn, s, "bsky_list_block.test")
+						if err != nil {
+							return err
+						}
+						if listBlock["subject"] != s.RootModule().Resources["bsky_list.modlist"].Primary.Attributes["uri"] {
+							return fmt.Errorf("unexpected subject %v", listBlock["subject"])
+						}
+						return nil
+					},
+				),
+			},
+			// ImportState testing
+			{
+				ResourceName: "bsky_list_block.test",
+				ImportState:  true,
+				ImportStateIdFunc: func(s *terraform.State) (string, error) {
+					return s.RootModule().Resources["bsky_list_block.test"].Primary.Attributes["uri"], nil
+				},
+				ImportStateVerify:                    true,
+				ImportStateVerifyIdentifierAttribute: "uri",
+			},
+			{
+				Config:      testAccListBlockResourceConfig(pds.URL, "bsky_list.curatelist"),
+				ExpectError: regexp.MustCompile("Not a moderation list"),
+			},
+		},
+	})
+}
+
+// testAccModListsConfig configures a moderation list and a curation list of
+// the mods account, and the member account subscribing to them by default.
+func testAccModListsConfig(pdsHost string) string {
+	return fmt.Sprintf(`
+		provider "bsky" {
+			pds_host = %[1]q
+			handle   = "member.test"
+			password = "password"
+
+			accounts = {
+				mods = {
+					pds_host = %[1]q
+					handle   = "mods.test"
+					password = "password"
+				}
+			}
+		}
+
+		resource "bsky_list" "modlist" {
+			account     = "mods"
+			name        = "Spammers"
+			purpose     = "app.bsky.graph.defs#modlist"
+			description = "Known spam accounts"
+		}
+
+		resource "bsky_list" "curatelist" {
+			account     = "mods"
+			name        = "Partners"
+			purpose     = "app.bsky.graph.defs#curatelist"
+			description = "Partner accounts"
+		}
+	`, pdsHost)
+}
+
+func testAccListBlockResourceConfig(pdsHost string, list string) string {
+	return testAccModListsConfig(pdsHost) + fmt.Sprintf(`
+		resource "bsky_list_block" "test" {
+			list_uri = %s.uri
+		}
+	`, list)
+}
@@ -1,8 +1,11 @@
 package test
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
@@ -57,6 +60,233 @@ func TestAccListItemResource(t *testing.T) {
 	})
 }
 
+func TestAccListItemResource_subjectHandle(t *testing.T) {
+	standIn, pds := newAccountsStandIn(t)
+	var firstUri string
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// The handle is resolved to the DID of the list item.
+			{
+				Config: testAccListItemSubjectConfig(pds.URL, `subject_handle = "alice.test"`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("bsky_list_item.test", "subject_handle", "alice.test"),
+					resource.TestCheckResourceAttr("bsky_list_item.test", "subject_did", accountsStandInDid("alice.test")),
+					resource.TestCheckResourceAttrWith("bsky_list_item.test", "uri", func(uri string) error {
+						firstUri = uri
+						return nil
+					}),
+					func(s *terraform.State) error {
+						item, err := standInRecord(standIn, s, "bsky_list_item.test")
+						if err != nil {
+							return err
+						}
+						if item["subject"] != accountsStandInDid("alice.test") {
+							return fmt.Errorf("unexpected subject %v", item["subject"])
+						}
+						return nil
+					},
+				),
+			},
+			// Switching to the DID the handle resolves to keeps the list item.
+			{
+				Config: testAccListItemSubjectConfig(pds.URL, fmt.Sprintf(`subject_did = %q`, accountsStandInDid("alice.test"))),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckNoResourceAttr("bsky_list_item.test", "subject_handle"),
+					resource.TestCheckResourceAttrWith("bsky_list_item.test", "uri", func(uri string) error {
+						if uri != firstUri {
+							return fmt.Errorf("expected the list item %s to be kept, got %s", firstUri, uri)
+						}
+						return nil
+					}),
+				),
+			},
+			{
+				Config: testAccListItemSubjectConfig(pds.URL, `subject_handle = "alice.test"`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrWith("bsky_list_item.test", "uri", func(uri string) error {
+						if uri != firstUri {
+							return fmt.Errorf("expected the list item %s to be kept, got %s", firstUri, uri)
+						}
+						return nil
+					}),
+				),
+			},
+			// A handle which now resolves to another DID replaces the list item.
+			{
+				PreConfig: func() {
+					standIn.mu.Lock()
+					standIn.handles["alice.test"] = "did:plc:newalice"
+					standIn.mu.Unlock()
+				},
+				Config: testAccListItemSubjectConfig(pds.URL, `subject_handle = "alice.test"`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("bsky_list_item.test", "subject_did", "did:plc:newalice"),
+					func(s *terraform.State) error {
+						standIn.mu.Lock()
+						_, ok := standIn.records[firstUri]
+						standIn.mu.Unlock()
+						if ok {
+							return fmt.Errorf("expected the list item %s to be deleted", firstUri)
+						}
+						return nil
+					},
+				),
+			},
+			{
+				Config:      testAccListItemSubjectConfig(pds.URL, `subject_handle = "alice.invalid"`),
+				ExpectError: regexp.MustCompile("Could not resolve handle"),
+			},
+			{
+				Config: testAccListItemSubjectConfig(pds.URL, `
+					subject_did    = "did:plc:newalice"
+					subject_handle = "alice.test"
+				`),
+				ExpectError: regexp.MustCompile("Invalid Attribute Combination"),
+			},
+		},
+	})
+}
+
+func TestAccListItemResource_handleKnownAfterApply(t *testing.T) {
+	standIn, pds := newAccountsStandIn(t)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// A handle only known when applying is resolved when the list item
+			// is created.
+			{
+				Config: testAccListItemSubjectConfig(pds.URL, `subject_handle = bsky_list.test.uri != "" ? "bob.test" : "alice.test"`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("bsky_list_item.test", "subject_did", accountsStandInDid("bob.test")),
+					func(s *terraform.State) error {
+						item, err := standInRecord(standIn, s, "bsky_list_item.test")
+						if err != nil {
+							return err
+						}
+						if item["subject"] != accountsStandInDid("bob.test") {
+							return fmt.Errorf("expected the subject %s, got %v", accountsStandInDid("bob.test"), item["subject"])
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestAccListItemResource_changedOutOfBand(t *testing.T) {
+	standIn, pds := newAccountsStandIn(t)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccListItemSwapConfig(pds.URL, false, "alice.test"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrWith(`bsky_list_item.test["alice.test"]`, "cid", func(cid string) error {
+						uri := ""
+						standIn.mu.Lock()
+						defer standIn.mu.Unlock()
+						for itemUri, record := range standIn.records {
+							if strings.Contains(string(record), accountsStandInDid("alice.test")) {
+								uri = itemUri
+							}
+						}
+						if uri == "" || cid != accountsStandInCid(standIn.records[uri]) {
+							return fmt.Errorf("expected the CID of the list item %s, got %s", uri, cid)
+						}
+						return nil
+					}),
+				),
+			},
+			// A list item changed after it was read isn't deleted.
+			{
+				PreConfig:   standIn.race("com.atproto.repo.getRecord", true),
+				Config:      testAccListItemSwapConfig(pds.URL, false),
+				ExpectError: regexp.MustCompile("List item changed outside of Terraform"),
+			},
+			{
+				PreConfig: standIn.race("com.atproto.repo.getRecord", false),
+				Config:    testAccListItemSwapConfig(pds.URL, false),
+				Check:     resource.TestCheckNoResourceAttr(`bsky_list_item.test["alice.test"]`, "uri"),
+			},
+			// With swap_commit, writes swap against the latest commit of the repo.
+			{
+				Config: testAccListItemSwapConfig(pds.URL, true, "alice.test"),
+				Check: func(s *terraform.State) error {
+					standIn.mu.Lock()
+					defer standIn.mu.Unlock()
+					if standIn.swapCommits != 1 {
+						return fmt.Errorf("expected 1 write with swapCommit, got %d", standIn.swapCommits)
+					}
+					return nil
+				},
+			},
+			{
+				PreConfig:   standIn.race("com.atproto.sync.getLatestCommit", true),
+				Config:      testAccListItemSwapConfig(pds.URL, true, "alice.test", "bob.test"),
+				ExpectError: regexp.MustCompile("Repo changed outside of Terraform"),
+			},
+			{
+				PreConfig: standIn.race("com.atproto.sync.getLatestCommit", false),
+				Config:    testAccListItemSwapConfig(pds.URL, true, "alice.test", "bob.test"),
+				Check:     resource.TestCheckResourceAttrSet(`bsky_list_item.test["bob.test"]`, "cid"),
+			},
+		},
+	})
+}
+
+func testAccListItemSwapConfig(pdsHost string, swapCommit bool, handles ...string) string {
+	subjects, _ := json.Marshal(append([]string{}, handles...))
+	return fmt.Sprintf(`
+		provider "bsky" {
+			pds_host        = %[1]q
+			handle_resolver = %[1]q
+			handle          = "default.test"
+			password        = "password"
+			swap_commit     = %t
+		}
+
+		resource "bsky_list" "test" {
+			name        = "Members"
+			purpose     = "app.bsky.graph.defs#curatelist"
+			description = "Accounts added by handle"
+		}
+
+		resource "bsky_list_item" "test" {
+			for_each = toset(%s)
+
+			list_uri       = bsky_list.test.uri
+			subject_handle = each.key
+		}
+	`, pdsHost, swapCommit, subjects)
+}
+
+func testAccListItemSubjectConfig(pdsHost string, subject string) string {
+	return fmt.Sprintf(`
+		provider "bsky" {
+			pds_host        = %[1]q
+			handle_resolver = %[1]q
+			handle          = "default.test"
+			password        = "password"
+		}
+
+		resource "bsky_list" "test" {
+			name        = "Members"
+			purpose     = "app.bsky.graph.defs#curatelist"
+			description = "Accounts added by handle"
+		}
+
+		resource "bsky_list_item" "test" {
+			list_uri = bsky_list.test.uri
+			%s
+		}
+	`, pdsHost, subject)
+}
+
 func testAccListItemResourceConfig() string {
 	return fmt.Sprintf(`
 		resource "bsky_list" "test" {
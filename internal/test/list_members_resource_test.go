@@ -0,0 +1,198 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+func TestAccListMembersResource(t *testing.T) {
+	standIn, pds := newAccountsStandIn(t)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Create and Read testing
+			{
+				Config: testAccListMembersResourceConfig(pds.URL, `
+					subject_dids = [for i in range(250) : format("did:plc:member%03d", i)]
+					batch_size   = 100
+				`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("bsky_list_members.test", "subject_dids.#", "250"),
+					resource.TestCheckResourceAttr("bsky_list_members.test", "members.%", "250"),
+					resource.TestCheckResourceAttrWith("bsky_list_members.test", "members.did:plc:member042", func(uri string) error {
+						return expectRepo(uri, accountsStandInDid("default.test"))
+					}),
+					expectListMembers(standIn, 251),
+					func(s *terraform.State) error {
+						standIn.mu.Lock()
+						defer standIn.mu.Unlock()
+						if standIn.applyWrites != 3 {
+							return fmt.Errorf("expected 3 applyWrites calls, got %d", standIn.applyWrites)
+						}
+						return nil
+					},
+				),
+			},
+			// ImportState testing adopts every list item of the list, including
+			// the unrelated one.
+			{
+				ResourceName: "bsky_list_members.test",
+				ImportState:  true,
+				ImportStateIdFunc: func(s *terraform.State) (string, error) {
+					return s.RootModule().Resources["bsky_list.test"].Primary.Attributes["uri"], nil
+				},
+				ImportStateCheck: func(states []*terraform.InstanceState) error {
+					if len(states) != 1 || states[0].Attributes["members.%"] != "251" || states[0].Attributes["batch_size"] != "200" {
+						return fmt.Errorf("unexpected imported state %v", states)
+					}
+					return nil
+				},
+			},
+			// List items deleted outside of Terraform show up as drift.
+			{
+				PreConfig: func() {
+					standIn.mu.Lock()
+					defer standIn.mu.Unlock()
+					for uri, record := range standIn.records {
+						if strings.Contains(string(record), `"did:plc:member007"`) {
+							delete(standIn.records, uri)
+						}
+					}
+				},
+				RefreshState:       true,
+				ExpectNonEmptyPlan: true,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("bsky_list_members.test", "members.%", "249"),
+					resource.TestCheckNoResourceAttr("bsky_list_members.test", "members.did:plc:member007"),
+				),
+			},
+			// Update and Read testing only writes the difference, and leaves the
+			// unrelated list item alone.
+			{
+				PreConfig: func() {
+					standIn.mu.Lock()
+					standIn.applyWrites = 0
+					standIn.mu.Unlock()
+				},
+				Config: testAccListMembersResourceConfig(pds.URL, `
+					subject_dids = [for i in range(10) : format("did:plc:member%03d", i)]
+				`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("bsky_list_members.test", "members.%", "10"),
+					resource.TestCheckResourceAttrSet("bsky_list_members.test", "members.did:plc:member007"),
+					expectListMembers(standIn, 11),
+					func(s *terraform.State) error {
+						standIn.mu.Lock()
+						defer standIn.mu.Unlock()
+						if standIn.applyWrites != 3 {
+							return fmt.Errorf("expected 3 applyWrites calls, got %d", standIn.applyWrites)
+						}
+						return nil
+					},
+				),
+			},
+			// Accounts which are already on the list adopt their list item.
+			{
+				Config: testAccListMembersResourceConfig(pds.URL, `
+					subject_dids = concat([for i in range(10) : format("did:plc:member%03d", i)], ["did:plc:unrelated"])
+				`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrPair("bsky_list_members.test", "members.did:plc:unrelated", "bsky_list_item.unrelated", "uri"),
+					expectListMembers(standIn, 11),
+				),
+			},
+			// Invalid DIDs fail the plan.
+			{
+				Config: testAccListMembersResourceConfig(pds.URL, `
+					subject_dids = ["handle.test"]
+				`),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("Invalid DID"),
+			},
+		},
+	})
+}
+
+func TestAccListMembersResource_foreignList(t *testing.T) {
+	_, pds := newAccountsStandIn(t)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+					provider "bsky" {
+						pds_host = %q
+						handle   = "default.test"
+						password = "password"
+					}
+
+					resource "bsky_list_members" "test" {
+						list_uri     = "at://%s/app.bsky.graph.list/3lbo5zov45j001"
+						subject_dids = ["did:plc:member000"]
+					}
+				`, pds.URL, accountsStandInDid("mods.test")),
+				ExpectError: regexp.MustCompile("Invalid list URI"),
+			},
+		},
+	})
+}
+
+// expectListMembers checks the number of list items of the list stored by the
+// stand-in.
+func expectListMembers(standIn *accountsStandIn, expected int) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		listUri := s.RootModule().Resources["bsky_list.test"].Primary.Attributes["uri"]
+
+		standIn.mu.Lock()
+		defer standIn.mu.Unlock()
+		count := 0
+		for uri, record := range standIn.records {
+			var item struct {
+				List string `json:"list"`
+			}
+			if strings.Contains(uri, "/app.bsky.graph.listitem/") && json.Unmarshal(record, &item) == nil && item.List == listUri {
+				count++
+			}
+		}
+		if count != expected {
+			return fmt.Errorf("expected %d list items, got %d", expected, count)
+		}
+		return nil
+	}
+}
+
+func testAccListMembersResourceConfig(pdsHost string, attributes string) string {
+	return fmt.Sprintf(`
+		provider "bsky" {
+			pds_host = %q
+			handle   = "default.test"
+			password = "password"
+		}
+
+		resource "bsky_list" "test" {
+			name        = "Members"
+			purpose     = "app.bsky.graph.defs#modlist"
+			description = "Accounts managed in bulk"
+		}
+
+		resource "bsky_list_item" "unrelated" {
+			list_uri    = bsky_list.test.uri
+			subject_did = "did:plc:unrelated"
+		}
+
+		resource "bsky_list_members" "test" {
+			list_uri = bsky_list.test.uri
+			%s
+
+			depends_on = [bsky_list_item.unrelated]
+		}
+	`, pdsHost, attributes)
+}
@@ -0,0 +1,152 @@
+package test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+func TestAccListMirrorResource(t *testing.T) {
+	standIn, pds := newAccountsStandIn(t, withListViews)
+	source := filepath.Join(t.TempDir(), "blocklist.csv")
+	writeSource := func(content string) {
+		if err := os.WriteFile(source, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Create and Read testing
+			{
+				Config: testAccListMirrorResourceConfig(pds.URL, `
+					source_list_uri = bsky_list.source.uri
+					exclude         = ["^did:plc:member004$"]
+				`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("bsky_list_mirror.test", "subject_dids.#", "4"),
+					resource.TestCheckResourceAttr("bsky_list_mirror.test", "members.%", "4"),
+					resource.TestCheckNoResourceAttr("bsky_list_mirror.test", "members.did:plc:member004"),
+					expectListMembers(standIn, 4),
+				),
+			},
+			// ImportState testing
+			{
+				ResourceName: "bsky_list_mirror.test",
+				ImportState:  true,
+				ImportStateIdFunc: func(s *terraform.State) (string, error) {
+					return s.RootModule().Resources["bsky_list.test"].Primary.Attributes["uri"], nil
+				},
+				ImportStateCheck: func(states []*terraform.InstanceState) error {
+					if len(states) != 1 || states[0].Attributes["members.%"] != "4" {
+						return fmt.Errorf("unexpected imported state %v", states)
+					}
+					return nil
+				},
+			},
+			// Update and Read testing from a file, with handles resolved and the
+			// header and comments skipped.
+			{
+				PreConfig: func() {
+					writeSource("did,reason\n# Reported by partners\ndid:plc:member000,spam\n@alice.test,impersonation\ndid:plc:other,spam\n")
+				},
+				Config: testAccListMirrorResourceConfig(pds.URL, fmt.Sprintf(`
+					source_file = %q
+					include     = ["^did:plc:member", "\\.test$"]
+				`, source)),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("bsky_list_mirror.test", "members.%", "2"),
+					resource.TestCheckResourceAttrSet("bsky_list_mirror.test", "members.did:plc:member000"),
+					resource.TestCheckResourceAttrSet("bsky_list_mirror.test", "members.did:plc:alicetest"),
+					expectListMembers(standIn, 2),
+				),
+			},
+			// A source removing more accounts than max_removals fails the plan.
+			{
+				PreConfig: func() {
+					writeSource("did:plc:member001\n")
+				},
+				Config: testAccListMirrorResourceConfig(pds.URL, fmt.Sprintf(`
+					source_file  = %q
+					max_removals = 1
+				`, source)),
+				ExpectError: regexp.MustCompile("Too many removals"),
+			},
+			{
+				Config: testAccListMirrorResourceConfig(pds.URL, fmt.Sprintf(`
+					source_file  = %q
+					max_removals = 2
+				`, source)),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("bsky_list_mirror.test", "members.%", "1"),
+					resource.TestCheckResourceAttrSet("bsky_list_mirror.test", "members.did:plc:member001"),
+					expectListMembers(standIn, 1),
+				),
+			},
+			{
+				PreConfig: func() {
+					writeSource("did:plc:member001\nnot a handle\n")
+				},
+				Config: testAccListMirrorResourceConfig(pds.URL, fmt.Sprintf(`
+					source_file = %q
+				`, source)),
+				ExpectError: regexp.MustCompile("is neither a DID nor a handle"),
+			},
+			{
+				Config: testAccListMirrorResourceConfig(pds.URL, fmt.Sprintf(`
+					source_list_uri = bsky_list.source.uri
+					source_file     = %q
+				`, source)),
+				ExpectError: regexp.MustCompile("Invalid Attribute Combination"),
+			},
+			{
+				Config: testAccListMirrorResourceConfig(pds.URL, `
+					source_list_uri = bsky_list.source.uri
+					exclude         = ["member[0-9"]
+				`),
+				ExpectError: regexp.MustCompile("Invalid regular expression"),
+			},
+		},
+	})
+}
+
+func testAccListMirrorResourceConfig(pdsHost string, attributes string) string {
+	return fmt.Sprintf(`
+		provider "bsky" {
+			pds_host        = %[1]q
+			handle_resolver = %[1]q
+			handle          = "default.test"
+			password        = "password"
+		}
+
+		resource "bsky_list" "source" {
+			name        = "Canonical"
+			purpose     = "app.bsky.graph.defs#modlist"
+			description = "The canonical moderation list"
+		}
+
+		resource "bsky_list_members" "source" {
+			list_uri     = bsky_list.source.uri
+			subject_dids = [for i in range(5) : format("did:plc:member%%03d", i)]
+		}
+
+		resource "bsky_list" "test" {
+			name        = "Derived"
+			purpose     = "app.bsky.graph.defs#modlist"
+			description = "Mirrors the canonical moderation list"
+		}
+
+		resource "bsky_list_mirror" "test" {
+			list_uri = bsky_list.test.uri
+			%s
+
+			depends_on = [bsky_list_members.source]
+		}
+	`, pdsHost, attributes)
+}
@@ -0,0 +1,87 @@
+package test
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+func TestAccListMuteResource(t *testing.T) {
+	standIn, pds := newAccountsStandIn(t, withListViews, withListMutes)
+
+	// muted checks that the member account mutes the moderation list.
+	muted := func(s *terraform.State) error {
+		key := accountsStandInDid("member.test") + " " + s.RootModule().Resources["bsky_list.modlist"].Primary.Attributes["uri"]
+
+		standIn.mu.Lock()
+		defer standIn.mu.Unlock()
+		if !standIn.mutes[key] {
+			return fmt.Errorf("expected the list to be muted, got mutes %v", standIn.mutes)
+		}
+		return nil
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Create and Read testing
+			{
+				Config: testAccListMuteResourceConfig(pds.URL, "bsky_list.modlist"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrPair("bsky_list_mute.test", "list_uri", "bsky_list.modlist", "uri"),
+					muted,
+				),
+			},
+			// ImportState testing
+			{
+				ResourceName: "bsky_list_mute.test",
+				ImportState:  true,
+				ImportStateIdFunc: func(s *terraform.State) (string, error) {
+					return s.RootModule().Resources["bsky_list.modlist"].Primary.Attributes["uri"], nil
+				},
+				ImportStateVerify:                    true,
+				ImportStateVerifyIdentifierAttribute: "list_uri",
+			},
+			// A list unmuted outside of Terraform is muted again.
+			{
+				PreConfig: func() {
+					standIn.mu.Lock()
+					clear(standIn.mutes)
+					standIn.mu.Unlock()
+				},
+				Config: testAccListMuteResourceConfig(pds.URL, "bsky_list.modlist"),
+				Check:  muted,
+			},
+			// A deleted list counts as unmuted, and its replacement is muted.
+			{
+				PreConfig: func() {
+					standIn.mu.Lock()
+					for uri := range standIn.records {
+						if strings.Contains(uri, "/app.bsky.graph.list/") {
+							delete(standIn.records, uri)
+						}
+					}
+					standIn.mu.Unlock()
+				},
+				Config: testAccListMuteResourceConfig(pds.URL, "bsky_list.modlist"),
+				Check:  muted,
+			},
+			{
+				Config:      testAccListMuteResourceConfig(pds.URL, "bsky_list.curatelist"),
+				ExpectError: regexp.MustCompile("Not a moderation list"),
+			},
+		},
+	})
+}
+
+func testAccListMuteResourceConfig(pdsHost string, list string) string {
+	return testAccModListsConfig(pdsHost) + fmt.Sprintf(`
+		resource "bsky_list_mute" "test" {
+			list_uri = %s.uri
+		}
+	`, list)
+}
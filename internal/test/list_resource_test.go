@@ -1,12 +1,21 @@
 package test
 
 import (
+	"bytes"
 	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
 	"regexp"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
 	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/hashicorp/terraform-plugin-testing/tfjsonpath"
 )
 
 func TestAccListResource(t *testing.T) {
@@ -51,6 +60,146 @@ func TestAccListResource(t *testing.T) {
 }
 
 // Test invalid purpose.
+// TestAccListResourceDeletedOutOfBand checks that lists, list items and
+// starter packs deleted in the app are created again, while repo-level errors
+// of the PDS still fail the refresh.
+func TestAccListResourceDeletedOutOfBand(t *testing.T) {
+	standIn, pds := newAccountsStandIn(t)
+	uris := map[string]string{}
+	saveURIs := func(s *terraform.State) error {
+		for name, r := range s.RootModule().Resources {
+			uris[name] = r.Primary.Attributes["uri"]
+		}
+		return nil
+	}
+
+	steps := []resource.TestStep{
+		{
+			Config: testAccListDeletedOutOfBandConfig(pds.URL),
+			Check:  saveURIs,
+		},
+	}
+	// Delete the record of each resource on its own, so the refresh of the
+	// others doesn't hide a resource which fails to notice.
+	for _, name := range []string{"bsky_list_item.test", "bsky_starter_pack.test", "bsky_list.test"} {
+		var deleted string
+		steps = append(steps, resource.TestStep{
+			PreConfig: func() {
+				deleted = uris[name]
+				standIn.mu.Lock()
+				delete(standIn.records, deleted)
+				standIn.mu.Unlock()
+			},
+			Config: testAccListDeletedOutOfBandConfig(pds.URL),
+			Check: resource.ComposeAggregateTestCheckFunc(
+				func(s *terraform.State) error {
+					if uri := s.RootModule().Resources[name].Primary.Attributes["uri"]; uri == deleted {
+						return fmt.Errorf("expected %s to be created again, got %s", name, uri)
+					}
+					return nil
+				},
+				saveURIs,
+			),
+		})
+	}
+	// Missing or deactivated repos aren't deleted records.
+	for _, repoError := range []string{"RepoDeactivated", "RepoNotFound"} {
+		steps = append(steps, resource.TestStep{
+			PreConfig: func() {
+				standIn.mu.Lock()
+				standIn.repoError = repoError
+				standIn.mu.Unlock()
+			},
+			RefreshState: true,
+			ExpectError:  regexp.MustCompile(repoError),
+		})
+	}
+	steps = append(steps, resource.TestStep{
+		PreConfig: func() {
+			standIn.mu.Lock()
+			standIn.repoError = ""
+			standIn.mu.Unlock()
+		},
+		Config: testAccListDeletedOutOfBandConfig(pds.URL),
+		Check: func(s *terraform.State) error {
+			for name, r := range s.RootModule().Resources {
+				if uri := r.Primary.Attributes["uri"]; uri != uris[name] {
+					return fmt.Errorf("expected %s to be kept at %s, got %s", name, uris[name], uri)
+				}
+			}
+			return nil
+		},
+	})
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps:                    steps,
+	})
+}
+
+// TestAccListResourceChangedOutOfBand checks that the cid is only planned to
+// change with the record, and that updates swap against the cid of the state.
+func TestAccListResourceChangedOutOfBand(t *testing.T) {
+	standIn, pds := newAccountsStandIn(t, withBlobs)
+	red := writeTestImage(t, "red.png", color.RGBA{R: 255, A: 255})
+	sameRed := writeTestImage(t, "same-red.png", color.RGBA{R: 255, A: 255})
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccListChangedOutOfBandConfig(pds.URL, "Partner accounts", red),
+			},
+			// Moving the avatar to a file with the same contents keeps the
+			// record.
+			{
+				Config: testAccListChangedOutOfBandConfig(pds.URL, "Partner accounts", sameRed),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectKnownValue("bsky_list.test", tfjsonpath.New("cid"), knownvalue.NotNull()),
+					},
+				},
+			},
+			{
+				Config: testAccListChangedOutOfBandConfig(pds.URL, "Partners of the project", sameRed),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectUnknownValue("bsky_list.test", tfjsonpath.New("cid")),
+					},
+				},
+			},
+			// A list changed after it was refreshed isn't overwritten.
+			{
+				PreConfig:   standIn.race("com.atproto.repo.getRecord", true),
+				Config:      testAccListChangedOutOfBandConfig(pds.URL, "Partner accounts", sameRed),
+				ExpectError: regexp.MustCompile("List changed outside of Terraform"),
+			},
+			{
+				PreConfig: standIn.race("com.atproto.repo.getRecord", false),
+				Config:    testAccListChangedOutOfBandConfig(pds.URL, "Partner accounts", sameRed),
+				Check:     resource.TestCheckResourceAttr("bsky_list.test", "description", "Partner accounts"),
+			},
+		},
+	})
+}
+
+func testAccListChangedOutOfBandConfig(pdsHost string, description string, avatarFile string) string {
+	return fmt.Sprintf(`
+		provider "bsky" {
+			pds_host = %q
+			handle   = "default.test"
+			password = "password"
+		}
+
+		resource "bsky_list" "test" {
+			name        = "Partners"
+			description = %q
+			purpose     = "app.bsky.graph.defs#curatelist"
+			avatar_file = %q
+		}
+	`, pdsHost, description, avatarFile)
+}
+
 func TestAccListResourceInvalidPurpose(t *testing.T) {
 	resource.Test(t, resource.TestCase{
 		PreCheck: func() {
@@ -66,6 +215,117 @@ func TestAccListResourceInvalidPurpose(t *testing.T) {
 	})
 }
 
+func TestAccListResourceAvatar(t *testing.T) {
+	red := writeTestImage(t, "red.png", color.RGBA{R: 255, A: 255})
+	blue := writeTestImage(t, "blue.png", color.RGBA{B: 255, A: 255})
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testProviderPreCheck(t)
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccListResourceAvatarConfig(red),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("bsky_list.test", "avatar_file", red),
+					resource.TestMatchResourceAttr("bsky_list.test", "avatar_hash", regexp.MustCompile(`^[0-9a-f]{64}$`)),
+				),
+			},
+			// Changing the image uploads it again.
+			{
+				Config: testAccListResourceAvatarConfig(blue),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("bsky_list.test", "avatar_file", blue),
+					resource.TestMatchResourceAttr("bsky_list.test", "avatar_hash", regexp.MustCompile(`^[0-9a-f]{64}$`)),
+					resource.TestMatchResourceAttr("data.bsky_list.test", "avatar", regexp.MustCompile(`/xrpc/com\.atproto\.sync\.getBlob\?cid=\w+&did=did%3A`)),
+				),
+			},
+			// Removing the file from the configuration removes the image.
+			{
+				Config: testAccListResourceAvatarConfig(""),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckNoResourceAttr("bsky_list.test", "avatar_file"),
+					resource.TestCheckNoResourceAttr("bsky_list.test", "avatar_hash"),
+					resource.TestCheckResourceAttr("data.bsky_list.test", "avatar", ""),
+				),
+			},
+			{
+				Config:      testAccListResourceAvatarConfig(filepath.Join(t.TempDir(), "missing.png")),
+				ExpectError: regexp.MustCompile(`Invalid image file`),
+			},
+		},
+	})
+}
+
+// writeTestImage writes a small PNG image of a single color and returns its
+// path.
+func writeTestImage(t *testing.T, name string, c color.Color) string {
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	for x := range 16 {
+		for y := range 16 {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// testAccListResourceAvatarConfig returns a list with the avatar avatarFile,
+// or without avatar_file when it is empty, and the list read back.
+func testAccListResourceAvatarConfig(avatarFile string) string {
+	avatar := ""
+	if avatarFile != "" {
+		avatar = fmt.Sprintf("avatar_file = %q", avatarFile)
+	}
+	return fmt.Sprintf(`
+		resource "bsky_list" "test" {
+			name        = "Avatar List"
+			description = "List with an avatar"
+			purpose     = "app.bsky.graph.defs#curatelist"
+			%s
+		}
+
+		data "bsky_list" "test" {
+			uri = bsky_list.test.uri
+		}
+	`, avatar)
+}
+
+func testAccListDeletedOutOfBandConfig(pdsHost string) string {
+	return fmt.Sprintf(`
+		provider "bsky" {
+			pds_host = %q
+			handle   = "default.test"
+			password = "password"
+		}
+
+		resource "bsky_list" "test" {
+			name        = "Partners"
+			description = "Partner accounts"
+			purpose     = "app.bsky.graph.defs#curatelist"
+		}
+
+		resource "bsky_list_item" "test" {
+			list_uri    = bsky_list.test.uri
+			subject_did = "did:plc:member000"
+		}
+
+		resource "bsky_starter_pack" "test" {
+			name        = "Partners"
+			description = "Follow our partners"
+			list_uri    = bsky_list.test.uri
+		}
+	`, pdsHost)
+}
+
 func testAccListResourceConfig(name string, description string, purpose string) string {
 	return fmt.Sprintf(`
 		resource "bsky_list" "test" {
@@ -0,0 +1,316 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+func TestAccPostResource(t *testing.T) {
+	standIn, pds := newAccountsStandIn(t)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Create and Read testing
+			{
+				Config: testAccPostResourceConfig(pds.URL, "Héllo @alice.test, see https://example.com/releases. #terraform"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("bsky_post.test", "langs.0", "en"),
+					resource.TestCheckResourceAttr("bsky_post.test", "created_at", "2024-11-20T15:04:05Z"),
+					resource.TestCheckResourceAttrWith("bsky_post.test", "uri", func(uri string) error {
+						return expectRepo(uri, accountsStandInDid("poster.test"))
+					}),
+					resource.TestCheckResourceAttrSet("bsky_post.test", "cid"),
+					// Facets are indexed by byte offsets, é takes two bytes.
+					func(s *terraform.State) error {
+						return expectFacets(standIn, s, []any{
+							facet(7, 18, map[string]any{"$type": "app.bsky.richtext.facet#mention", "did": accountsStandInDid("alice.test")}),
+							facet(24, 52, map[string]any{"$type": "app.bsky.richtext.facet#link", "uri": "https://example.com/releases"}),
+							facet(54, 64, map[string]any{"$type": "app.bsky.richtext.facet#tag", "tag": "terraform"}),
+						})
+					},
+				),
+			},
+			// ImportState testing
+			{
+				ResourceName: "bsky_post.test",
+				ImportState:  true,
+				ImportStateIdFunc: func(s *terraform.State) (string, error) {
+					return s.RootModule().Resources["bsky_post.test"].Primary.Attributes["uri"], nil
+				},
+				ImportStateVerify:                    true,
+				ImportStateVerifyIdentifierAttribute: "uri",
+			},
+			// Changing the text replaces the post.
+			{
+				Config: testAccPostResourceConfig(pds.URL, "Plain text"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("bsky_post.test", "text", "Plain text"),
+					func(s *terraform.State) error {
+						return expectFacets(standIn, s, nil)
+					},
+				),
+			},
+			// A post deleted outside of Terraform is created again.
+			{
+				PreConfig: func() {
+					standIn.mu.Lock()
+					for uri := range standIn.records {
+						if strings.Contains(uri, "/app.bsky.feed.post/") {
+							delete(standIn.records, uri)
+						}
+					}
+					standIn.mu.Unlock()
+				},
+				Config: testAccPostResourceConfig(pds.URL, "Plain text"),
+				Check: func(s *terraform.State) error {
+					_, err := standInRecord(standIn, s, "bsky_post.test")
+					return err
+				},
+			},
+		},
+	})
+}
+
+func TestAccPostResourceEmbeds(t *testing.T) {
+	standIn, pds := newAccountsStandIn(t, withBlobs)
+	red := writeTestImage(t, "red.png", color.RGBA{R: 255, A: 255})
+	blue := writeTestImage(t, "blue.png", color.RGBA{B: 255, A: 255})
+	imagesConfig := testAccPostResourceEmbedsConfig(pds.URL, fmt.Sprintf(`
+		images = [
+			{ file = %q, alt = "Red square" },
+			{ file = %q, alt = "Blue square" },
+		]
+		quote_uri = bsky_post.quoted.uri
+	`, red, blue))
+	var redHash, firstURI string
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: imagesConfig,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrWith("bsky_post.test", "images.0.hash", func(hash string) error {
+						redHash = hash
+						return nil
+					}),
+					resource.TestCheckResourceAttrWith("bsky_post.test", "uri", func(uri string) error {
+						firstURI = uri
+						return nil
+					}),
+					resource.TestMatchResourceAttr("bsky_post.test", "images.1.hash", regexp.MustCompile(`^[0-9a-f]{64}$`)),
+					func(s *terraform.State) error {
+						embed, err := postEmbed(standIn, s)
+						if err != nil {
+							return err
+						}
+						if embed["$type"] != "app.bsky.embed.recordWithMedia" {
+							return fmt.Errorf("expected a quote with media, got %v", embed)
+						}
+						images := embed["media"].(map[string]any)["images"].([]any)
+						first := images[0].(map[string]any)
+						if len(images) != 2 || first["alt"] != "Red square" || first["aspectRatio"] == nil {
+							return fmt.Errorf("unexpected images %v", images)
+						}
+						quoted := embed["record"].(map[string]any)["record"].(map[string]any)
+						if quoted["uri"] != s.RootModule().Resources["bsky_post.quoted"].Primary.Attributes["uri"] || quoted["cid"] == "" {
+							return fmt.Errorf("unexpected quoted post %v", quoted)
+						}
+						return nil
+					},
+				),
+			},
+			// Changing the contents of an image file replaces the post.
+			{
+				PreConfig: func() {
+					green := writeTestImage(t, "green.png", color.RGBA{G: 255, A: 255})
+					if err := os.Rename(green, red); err != nil {
+						t.Fatal(err)
+					}
+				},
+				Config: imagesConfig,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrWith("bsky_post.test", "images.0.hash", func(hash string) error {
+						if hash == redHash {
+							return fmt.Errorf("expected the hash to change")
+						}
+						return nil
+					}),
+					resource.TestCheckResourceAttrWith("bsky_post.test", "uri", func(uri string) error {
+						if uri == firstURI {
+							return fmt.Errorf("expected the post to be replaced")
+						}
+						return nil
+					}),
+				),
+			},
+			{
+				Config: testAccPostResourceEmbedsConfig(pds.URL, `
+					external = {
+						uri         = "https://example.com/releases"
+						title       = "Releases"
+						description = "Release notes"
+					}
+				`),
+				Check: func(s *terraform.State) error {
+					embed, err := postEmbed(standIn, s)
+					if err != nil {
+						return err
+					}
+					if embed["$type"] != "app.bsky.embed.external" {
+						return fmt.Errorf("expected a link card, got %v", embed)
+					}
+					return nil
+				},
+			},
+			{
+				Config: testAccPostResourceEmbedsConfig(pds.URL, fmt.Sprintf(`
+					images = [%s]
+				`, strings.Repeat(fmt.Sprintf(`{ file = %q, alt = "Red square" },`, red), 5))),
+				ExpectError: regexp.MustCompile(`Invalid Attribute Value`),
+			},
+			{
+				Config: testAccPostResourceEmbedsConfig(pds.URL, fmt.Sprintf(`
+					images = [{ file = %q, alt = "" }]
+				`, red)),
+				ExpectError: regexp.MustCompile(`Invalid Attribute Value Length`),
+			},
+		},
+	})
+}
+
+// postEmbed returns the embed of the post record stored by the stand-in.
+func postEmbed(standIn *accountsStandIn, s *terraform.State) (map[string]any, error) {
+	uri := s.RootModule().Resources["bsky_post.test"].Primary.Attributes["uri"]
+
+	standIn.mu.Lock()
+	record, ok := standIn.records[uri]
+	standIn.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("post %s not found", uri)
+	}
+
+	var post struct {
+		Embed map[string]any `json:"embed"`
+	}
+	if err := json.Unmarshal(record, &post); err != nil {
+		return nil, err
+	}
+	return post.Embed, nil
+}
+
+func testAccPostResourceEmbedsConfig(pdsHost string, embeds string) string {
+	return fmt.Sprintf(`
+		provider "bsky" {
+			pds_host = %q
+			handle   = "poster.test"
+			password = "password"
+		}
+
+		resource "bsky_post" "quoted" {
+			text = "Quoted post"
+		}
+
+		resource "bsky_post" "test" {
+			text = "Post with embeds"
+			%s
+		}
+	`, pdsHost, embeds)
+}
+
+// TestAccPostResourceTextLength checks that the text is limited to 300
+// graphemes and 3000 bytes, rather than to a number of characters.
+func TestAccPostResourceTextLength(t *testing.T) {
+	_, pds := newAccountsStandIn(t)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Each emoji with a skin tone is one grapheme of two characters.
+			{
+				Config: testAccPostResourceTextConfig(pds.URL, strings.Repeat("👍🏽", 300)),
+				Check:  resource.TestCheckResourceAttrSet("bsky_post.test", "uri"),
+			},
+			{
+				Config:      testAccPostResourceTextConfig(pds.URL, strings.Repeat("a", 301)),
+				ExpectError: regexp.MustCompile(`is 301 graphemes long`),
+			},
+			// Each family emoji is one grapheme of 25 bytes.
+			{
+				Config:      testAccPostResourceTextConfig(pds.URL, strings.Repeat("👨‍👩‍👧‍👦", 200)),
+				ExpectError: regexp.MustCompile(`is 5000 bytes long`),
+			},
+		},
+	})
+}
+
+func testAccPostResourceTextConfig(pdsHost string, text string) string {
+	return fmt.Sprintf(`
+		provider "bsky" {
+			pds_host = %q
+			handle   = "poster.test"
+			password = "password"
+		}
+
+		resource "bsky_post" "test" {
+			text = %q
+		}
+	`, pdsHost, text)
+}
+
+func facet(byteStart int, byteEnd int, feature map[string]any) any {
+	return map[string]any{
+		"index":    map[string]any{"byteStart": float64(byteStart), "byteEnd": float64(byteEnd)},
+		"features": []any{feature},
+	}
+}
+
+// expectFacets checks the facets of the post record stored by the stand-in.
+func expectFacets(standIn *accountsStandIn, s *terraform.State, expected []any) error {
+	uri := s.RootModule().Resources["bsky_post.test"].Primary.Attributes["uri"]
+
+	standIn.mu.Lock()
+	record, ok := standIn.records[uri]
+	standIn.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("post %s not found", uri)
+	}
+
+	var post struct {
+		Facets []any `json:"facets"`
+	}
+	if err := json.Unmarshal(record, &post); err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(post.Facets, expected) {
+		return fmt.Errorf("expected facets %v, got %v", expected, post.Facets)
+	}
+	return nil
+}
+
+func testAccPostResourceConfig(pdsHost string, text string) string {
+	return fmt.Sprintf(`
+		provider "bsky" {
+			pds_host        = %[1]q
+			handle_resolver = %[1]q
+			handle          = "poster.test"
+			password        = "password"
+		}
+
+		resource "bsky_post" "test" {
+			text       = %q
+			langs      = ["en"]
+			created_at = "2024-11-20T15:04:05Z"
+		}
+	`, pdsHost, text)
+}
@@ -0,0 +1,92 @@
+package test
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+func TestAccPostgateResource(t *testing.T) {
+	standIn, pds := newAccountsStandIn(t)
+	quote := "at://did:plc:quoter/app.bsky.feed.post/3lbo5zov45j2q"
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Create and Read testing
+			{
+				Config: testAccPostgateResourceConfig(pds.URL, "bsky_post.test", fmt.Sprintf(`
+					disable_embedding       = true
+					detached_embedding_uris = [%q]
+				`, quote)),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("bsky_postgate.test", "disable_embedding", "true"),
+					resource.TestCheckResourceAttrSet("bsky_postgate.test", "cid"),
+					expectGateOfPost("bsky_postgate.test", "app.bsky.feed.postgate"),
+					func(s *terraform.State) error {
+						postgate, err := standInRecord(standIn, s, "bsky_postgate.test")
+						if err != nil {
+							return err
+						}
+						rules := []any{map[string]any{"$type": "app.bsky.feed.postgate#disableRule"}}
+						if !reflect.DeepEqual(postgate["embeddingRules"], rules) {
+							return fmt.Errorf("expected embedding rules %v, got %v", rules, postgate["embeddingRules"])
+						}
+						if !reflect.DeepEqual(postgate["detachedEmbeddingUris"], []any{quote}) {
+							return fmt.Errorf("unexpected detached embeddings %v", postgate["detachedEmbeddingUris"])
+						}
+						return nil
+					},
+				),
+			},
+			// ImportState testing
+			{
+				ResourceName: "bsky_postgate.test",
+				ImportState:  true,
+				ImportStateIdFunc: func(s *terraform.State) (string, error) {
+					return s.RootModule().Resources["bsky_post.test"].Primary.Attributes["uri"], nil
+				},
+				ImportStateVerify:                    true,
+				ImportStateVerifyIdentifierAttribute: "post_uri",
+			},
+			// Update and Read testing
+			{
+				Config: testAccPostgateResourceConfig(pds.URL, "bsky_post.test", ""),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("bsky_postgate.test", "disable_embedding", "false"),
+					resource.TestCheckNoResourceAttr("bsky_postgate.test", "detached_embedding_uris"),
+					func(s *terraform.State) error {
+						postgate, err := standInRecord(standIn, s, "bsky_postgate.test")
+						if err != nil {
+							return err
+						}
+						if postgate["embeddingRules"] != nil {
+							return fmt.Errorf("expected no embedding rules, got %v", postgate["embeddingRules"])
+						}
+						return nil
+					},
+				),
+			},
+			// Only the author of a post can gate it, which is checked when
+			// planning.
+			{
+				Config:      testAccPostgateResourceConfig(pds.URL, "bsky_post.other", ""),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("Invalid post URI"),
+			},
+		},
+	})
+}
+
+func testAccPostgateResourceConfig(pdsHost string, post string, attributes string) string {
+	return testAccGatedPostConfig(pdsHost) + fmt.Sprintf(`
+		resource "bsky_postgate" "test" {
+			post_uri = %s.uri
+			%s
+		}
+	`, post, attributes)
+}
@@ -0,0 +1,124 @@
+package test
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+func TestAccProfileResource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testProviderPreCheck(t)
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Create and Read testing
+			{
+				Config: testAccProfileResourceConfig("Test Profile", "Test description"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("bsky_profile.test", "display_name", "Test Profile"),
+					resource.TestCheckResourceAttr("bsky_profile.test", "description", "Test description"),
+					resource.TestCheckResourceAttr("bsky_profile.test", "labels.#", "1"),
+					resource.TestCheckResourceAttr("bsky_profile.test", "labels.0", "!no-unauthenticated"),
+					resource.TestMatchResourceAttr("bsky_profile.test", "uri", regexp.MustCompile(`/app\.bsky\.actor\.profile/self$`)),
+					resource.TestCheckResourceAttrSet("bsky_profile.test", "did"),
+					resource.TestCheckResourceAttrSet("bsky_profile.test", "cid"),
+				),
+			},
+			// ImportState testing
+			{
+				ResourceName: "bsky_profile.test",
+				ImportState:  true,
+				ImportStateIdFunc: func(s *terraform.State) (string, error) {
+					return s.RootModule().Resources["bsky_profile.test"].Primary.Attributes["did"], nil
+				},
+				ImportStateVerify:                    true,
+				ImportStateVerifyIdentifierAttribute: "did",
+			},
+			// Update and Read testing
+			{
+				Config: testAccProfileResourceConfig("Updated Profile", "Updated description"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("bsky_profile.test", "display_name", "Updated Profile"),
+					resource.TestCheckResourceAttr("bsky_profile.test", "description", "Updated description"),
+				),
+			},
+		},
+	})
+}
+
+// Test an invalid import ID.
+func TestAccProfileResourceInvalidImportID(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testProviderPreCheck(t)
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:        testAccProfileResourceConfig("Test Profile", "Test description"),
+				ResourceName:  "bsky_profile.test",
+				ImportState:   true,
+				ImportStateId: "not-a-did",
+				ExpectError:   regexp.MustCompile(`Invalid profile import ID`),
+			},
+		},
+	})
+}
+
+// TestAccProfileResourceCreatedConcurrently checks that creating a profile
+// doesn't overwrite a profile written since Terraform found none.
+func TestAccProfileResourceCreatedConcurrently(t *testing.T) {
+	standIn, pds := newAccountsStandIn(t)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				PreConfig:   standIn.race("com.atproto.sync.getLatestCommit", true),
+				Config:      testAccProfileStandInConfig(pds.URL),
+				ExpectError: regexp.MustCompile("Profile changed outside of Terraform"),
+			},
+			{
+				PreConfig: standIn.race("com.atproto.sync.getLatestCommit", false),
+				Config:    testAccProfileStandInConfig(pds.URL),
+				Check: func(s *terraform.State) error {
+					standIn.mu.Lock()
+					defer standIn.mu.Unlock()
+					if standIn.swapCommits != 1 {
+						return fmt.Errorf("expected the profile to be created with swapCommit, got %d writes with swapCommit", standIn.swapCommits)
+					}
+					return nil
+				},
+			},
+		},
+	})
+}
+
+func testAccProfileStandInConfig(pdsHost string) string {
+	return fmt.Sprintf(`
+		provider "bsky" {
+			pds_host = %q
+			handle   = "default.test"
+			password = "password"
+		}
+
+		resource "bsky_profile" "test" {
+			display_name = "Default"
+		}
+	`, pdsHost)
+}
+
+func testAccProfileResourceConfig(displayName string, description string) string {
+	return fmt.Sprintf(`
+		resource "bsky_profile" "test" {
+			display_name = %[1]q
+			description  = %[2]q
+			labels       = ["!no-unauthenticated"]
+		}
+	`, displayName, description)
+}
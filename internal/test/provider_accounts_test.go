@@ -0,0 +1,110 @@
+package test
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+func TestAccProviderNamedAccounts(t *testing.T) {
+	standIn, pds := newAccountsStandIn(t, withListViews)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderNamedAccountsConfig(pds.URL),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrWith("bsky_list.default", "uri", func(uri string) error {
+						return expectRepo(uri, accountsStandInDid("default.test"))
+					}),
+					resource.TestCheckResourceAttrWith("bsky_list.community", "uri", func(uri string) error {
+						return expectRepo(uri, accountsStandInDid("community.test"))
+					}),
+					resource.TestCheckResourceAttrWith("bsky_list_item.community", "uri", func(uri string) error {
+						return expectRepo(uri, accountsStandInDid("community.test"))
+					}),
+					resource.TestCheckResourceAttr("data.bsky_list.community", "name", "Community List"),
+					// Accounts that no resource uses never log in.
+					func(*terraform.State) error {
+						standIn.mu.Lock()
+						defer standIn.mu.Unlock()
+						if n := standIn.logins["unused.test"]; n != 0 {
+							return fmt.Errorf("expected the unused account not to log in, got %d logins", n)
+						}
+						return nil
+					},
+				),
+			},
+			{
+				Config: testAccProviderNamedAccountsConfig(pds.URL) + `
+					resource "bsky_list" "unknown" {
+						account     = "missing"
+						name        = "Unknown Account List"
+						purpose     = "app.bsky.graph.defs#curatelist"
+						description = "Not created"
+					}
+				`,
+				ExpectError: regexp.MustCompile("Unknown Bluesky account"),
+			},
+		},
+	})
+}
+
+func expectRepo(uri string, did string) error {
+	if !strings.HasPrefix(uri, "at://"+did+"/") {
+		return fmt.Errorf("expected a record in the repo of %s, got %s", did, uri)
+	}
+	return nil
+}
+
+func testAccProviderNamedAccountsConfig(pdsHost string) string {
+	return fmt.Sprintf(`
+		provider "bsky" {
+			pds_host = %[1]q
+			handle   = "default.test"
+			password = "password"
+
+			accounts = {
+				community = {
+					pds_host = %[1]q
+					handle   = "community.test"
+					password = "password"
+				}
+				unused = {
+					pds_host = %[1]q
+					handle   = "unused.test"
+					password = "password"
+				}
+			}
+		}
+
+		resource "bsky_list" "default" {
+			name        = "Default List"
+			purpose     = "app.bsky.graph.defs#curatelist"
+			description = "Created by the default account"
+		}
+
+		resource "bsky_list" "community" {
+			account     = "community"
+			name        = "Community List"
+			purpose     = "app.bsky.graph.defs#curatelist"
+			description = "Created by the community account"
+		}
+
+		resource "bsky_list_item" "community" {
+			account     = "community"
+			list_uri    = bsky_list.community.uri
+			subject_did = %[2]q
+		}
+
+		data "bsky_list" "community" {
+			account = "community"
+			uri     = bsky_list.community.uri
+		}
+	`, pdsHost, accountsStandInDid("default.test"))
+}
@@ -0,0 +1,58 @@
+package test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+func TestAccProviderAdminOnly(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testProviderPreCheck(t)
+			// Configure the provider with the PDS host and admin password
+			// alone.
+			t.Setenv("BSKY_HANDLE", "")
+			t.Setenv("BSKY_PASSWORD", "")
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccAccountResourceConfig("adminonly@example.com", "testpass123"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet("bsky_account.test", "did"),
+					resource.TestCheckResourceAttr("bsky_account.test", "handle", "testusr."+pdsDomain()),
+				),
+			},
+			{
+				ResourceName: "bsky_account.test",
+				ImportState:  true,
+				ImportStateIdFunc: func(s *terraform.State) (string, error) {
+					return s.RootModule().Resources["bsky_account.test"].Primary.Attributes["did"], nil
+				},
+				ImportStateVerify:                    true,
+				ImportStateVerifyIdentifierAttribute: "did",
+				ImportStateVerifyIgnore:              []string{"password", "email"},
+			},
+		},
+	})
+}
+
+func TestAccProviderAdminOnlyUserResource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testProviderPreCheck(t)
+			t.Setenv("BSKY_HANDLE", "")
+			t.Setenv("BSKY_PASSWORD", "")
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccListResourceConfig("Admin Only List", "Can't be created without an account", "app.bsky.graph.defs#curatelist"),
+				ExpectError: regexp.MustCompile("Bluesky account required"),
+			},
+		},
+	})
+}
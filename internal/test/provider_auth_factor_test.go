@@ -0,0 +1,97 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+// newAuthFactorStandIn starts a PDS for an account with email two-factor
+// authentication, which only creates sessions given the sign-in code.
+func newAuthFactorStandIn(t *testing.T, code string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /xrpc/com.atproto.server.createSession", func(w http.ResponseWriter, r *http.Request) {
+		var input struct {
+			AuthFactorToken string `json:"authFactorToken"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&input)
+		if input.AuthFactorToken != code {
+			writeJSON(w, http.StatusUnauthorized, map[string]any{
+				"error":   "AuthFactorTokenRequired",
+				"message": "A sign in code has been sent to your email address",
+			})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"accessJwt":       "access",
+			"refreshJwt":      "refresh",
+			"did":             "did:plc:authfactortest00000000000",
+			"handle":          "twofactor.test",
+			"emailAuthFactor": true,
+		})
+	})
+	mux.HandleFunc("GET /xrpc/com.atproto.repo.getRecord", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"uri": "at://" + r.URL.Query().Get("repo") + "/" + r.URL.Query().Get("collection") + "/" + r.URL.Query().Get("rkey"),
+			"cid": "bafyreie5737gdxlw5i64vzichcalba3z2v5n6icifvx5xytvske7mr3hpm",
+			"value": map[string]any{
+				"$type":     "app.bsky.graph.list",
+				"name":      "Two-Factor List",
+				"purpose":   "app.bsky.graph.defs#curatelist",
+				"createdAt": "2024-01-01T00:00:00Z",
+			},
+		})
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusNotImplemented, map[string]any{"error": "MethodNotImplemented", "message": r.URL.Path})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestAccProviderAuthFactorToken(t *testing.T) {
+	pds := newAuthFactorStandIn(t, "ABCDE-12345")
+	t.Setenv("BSKY_AUTH_FACTOR_TOKEN", "")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccProviderAuthFactorTokenConfig(pds.URL, ""),
+				ExpectError: regexp.MustCompile("Bluesky sign-in code required"),
+			},
+			{
+				Config:      testAccProviderAuthFactorTokenConfig(pds.URL, "WRONG-00000"),
+				ExpectError: regexp.MustCompile("sign-in code for twofactor.test was rejected"),
+			},
+			{
+				Config: testAccProviderAuthFactorTokenConfig(pds.URL, "ABCDE-12345"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.bsky_list.test", "name", "Two-Factor List"),
+				),
+			},
+		},
+	})
+}
+
+func testAccProviderAuthFactorTokenConfig(pdsHost string, code string) string {
+	return fmt.Sprintf(`
+		provider "bsky" {
+			pds_host          = %[1]q
+			handle            = "twofactor.test"
+			password          = "password"
+			auth_factor_token = %[2]q
+		}
+
+		data "bsky_list" "test" {
+			uri = "at://did:plc:authfactortest00000000000/app.bsky.graph.list/3lbo5zov45j2q"
+		}
+	`, pdsHost, code)
+}
@@ -0,0 +1,125 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+const discoveryTestDid = "did:plc:discoverytestaccount0000"
+
+// newDiscoveryStandIns starts local stand-ins for a handle resolver and PLC
+// directory, an entryway that only creates sessions, and the PDS hosting the
+// account.
+func newDiscoveryStandIns(t *testing.T) (resolver *httptest.Server, entryway *httptest.Server, pds *httptest.Server) {
+	pdsMux := http.NewServeMux()
+	pdsMux.HandleFunc("GET /xrpc/com.atproto.repo.getRecord", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access" {
+			writeJSON(w, http.StatusUnauthorized, map[string]any{"error": "AuthMissing"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"uri": "at://" + r.URL.Query().Get("repo") + "/" + r.URL.Query().Get("collection") + "/" + r.URL.Query().Get("rkey"),
+			"cid": "bafyreie5737gdxlw5i64vzichcalba3z2v5n6icifvx5xytvske7mr3hpm",
+			"value": map[string]any{
+				"$type":       "app.bsky.graph.list",
+				"name":        "Discovered List",
+				"purpose":     "app.bsky.graph.defs#curatelist",
+				"description": "Read from the PDS in the DID document",
+				"createdAt":   "2024-01-01T00:00:00Z",
+			},
+		})
+	})
+	pdsMux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusNotImplemented, map[string]any{"error": "MethodNotImplemented", "message": r.URL.Path})
+	})
+	pds = httptest.NewServer(pdsMux)
+	t.Cleanup(pds.Close)
+
+	didDoc := map[string]any{
+		"id":          discoveryTestDid,
+		"alsoKnownAs": []string{"at://discovery.test"},
+		"service": []map[string]any{{
+			"id":              "#atproto_pds",
+			"type":            "AtprotoPersonalDataServer",
+			"serviceEndpoint": pds.URL,
+		}},
+	}
+
+	entrywayMux := http.NewServeMux()
+	entrywayMux.HandleFunc("POST /xrpc/com.atproto.server.createSession", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"accessJwt":  "access",
+			"refreshJwt": "refresh",
+			"did":        discoveryTestDid,
+			"handle":     "discovery.test",
+			"didDoc":     didDoc,
+		})
+	})
+	entrywayMux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusNotImplemented, map[string]any{"error": "MethodNotImplemented", "message": r.URL.Path})
+	})
+	entryway = httptest.NewServer(entrywayMux)
+	t.Cleanup(entryway.Close)
+
+	// The DID document served by the PLC directory points to the entryway,
+	// like accounts hosted behind bsky.social.
+	resolverMux := http.NewServeMux()
+	resolverMux.HandleFunc("GET /xrpc/com.atproto.identity.resolveHandle", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("handle") != "discovery.test" {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "HandleNotFound"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"did": discoveryTestDid})
+	})
+	resolverMux.HandleFunc("GET /"+discoveryTestDid, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"id":          discoveryTestDid,
+			"alsoKnownAs": []string{"at://discovery.test"},
+			"service": []map[string]any{{
+				"id":              "#atproto_pds",
+				"type":            "AtprotoPersonalDataServer",
+				"serviceEndpoint": entryway.URL,
+			}},
+		})
+	})
+	resolver = httptest.NewServer(resolverMux)
+	t.Cleanup(resolver.Close)
+
+	return resolver, entryway, pds
+}
+
+func TestAccProviderPDSDiscovery(t *testing.T) {
+	resolver, _, _ := newDiscoveryStandIns(t)
+	t.Setenv("BSKY_PDS_HOST", "")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderPDSDiscoveryConfig(resolver.URL),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.bsky_list.test", "name", "Discovered List"),
+				),
+			},
+		},
+	})
+}
+
+func testAccProviderPDSDiscoveryConfig(resolver string) string {
+	return fmt.Sprintf(`
+		provider "bsky" {
+			handle          = "discovery.test"
+			password        = "password"
+			plc_url         = %[1]q
+			handle_resolver = %[1]q
+		}
+
+		data "bsky_list" "test" {
+			uri = "at://%[2]s/app.bsky.graph.list/3lbo5zov45j2q"
+		}
+	`, resolver, discoveryTestDid)
+}
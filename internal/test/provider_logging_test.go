@@ -0,0 +1,43 @@
+package test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+// Logging reads and restores the bodies of requests and responses, which must
+// reach the PDS and the resources unchanged.
+func TestAccProviderLogXRPCRequests(t *testing.T) {
+	t.Setenv("TF_LOG_PROVIDER_BSKY_XRPC", "TRACE")
+	_, pds := newAccountsStandIn(t)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+					provider "bsky" {
+						pds_host          = %q
+						handle            = "logging.test"
+						password          = "password"
+						log_xrpc_requests = true
+					}
+
+					resource "bsky_list" "test" {
+						name        = "Logged List"
+						purpose     = "app.bsky.graph.defs#curatelist"
+						description = "Created with XRPC request logging"
+					}
+				`, pds.URL),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrWith("bsky_list.test", "uri", func(uri string) error {
+						return expectRepo(uri, accountsStandInDid("logging.test"))
+					}),
+					resource.TestCheckResourceAttr("bsky_list.test", "name", "Logged List"),
+				),
+			},
+		},
+	})
+}
@@ -0,0 +1,295 @@
+package test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+const oauthTestDid = "did:plc:oauthtestaccount0000000"
+
+// oauthStandIn is a local stand-in for an authorization server and the PDS it
+// protects. It rotates the refresh token on every token request and requires
+// DPoP proofs with a nonce.
+type oauthStandIn struct {
+	t      *testing.T
+	server *httptest.Server
+
+	mu           sync.Mutex
+	refreshToken string
+	accessToken  string
+	issued       int
+}
+
+func newOAuthStandIn(t *testing.T, refreshToken string) *oauthStandIn {
+	s := &oauthStandIn{t: t, refreshToken: refreshToken}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/oauth-protected-resource", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"authorization_servers": []string{s.server.URL}})
+	})
+	mux.HandleFunc("GET /.well-known/oauth-authorization-server", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"issuer":         s.server.URL,
+			"token_endpoint": s.server.URL + "/oauth/token",
+		})
+	})
+	mux.HandleFunc("POST /oauth/token", s.token)
+	mux.HandleFunc("GET /xrpc/com.atproto.server.getSession", s.authenticated(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"did": oauthTestDid, "handle": "oauth.test"})
+	}))
+	mux.HandleFunc("GET /xrpc/com.atproto.repo.getRecord", s.authenticated(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"uri": "at://" + r.URL.Query().Get("repo") + "/" + r.URL.Query().Get("collection") + "/" + r.URL.Query().Get("rkey"),
+			"cid": "bafyreie5737gdxlw5i64vzichcalba3z2v5n6icifvx5xytvske7mr3hpm",
+			"value": map[string]any{
+				"$type":       "app.bsky.graph.list",
+				"name":        "OAuth List",
+				"purpose":     "app.bsky.graph.defs#curatelist",
+				"description": "Read with a DPoP-bound token",
+				"createdAt":   "2024-01-01T00:00:00Z",
+			},
+		})
+	}))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusNotImplemented, map[string]any{"error": "MethodNotImplemented", "message": r.URL.Path})
+	})
+
+	s.server = httptest.NewServer(mux)
+	t.Cleanup(s.server.Close)
+	return s
+}
+
+func (s *oauthStandIn) token(w http.ResponseWriter, r *http.Request) {
+	proof, ok := dpopClaims(r)
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid_dpop_proof"})
+		return
+	}
+	w.Header().Set("DPoP-Nonce", "as-nonce")
+	if proof["nonce"] != "as-nonce" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "use_dpop_nonce"})
+		return
+	}
+	if r.FormValue("grant_type") != "refresh_token" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "unsupported_grant_type"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.FormValue("refresh_token") != s.refreshToken {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid_grant"})
+		return
+	}
+	s.issued++
+	s.refreshToken = fmt.Sprintf("refresh-%d", s.issued)
+	s.accessToken = fmt.Sprintf("access-%d", s.issued)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token":  s.accessToken,
+		"token_type":    "DPoP",
+		"refresh_token": s.refreshToken,
+		"sub":           oauthTestDid,
+		"scope":         "atproto transition:generic",
+	})
+}
+
+func (s *oauthStandIn) authenticated(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		proof, ok := dpopClaims(r)
+		if !ok || proof["ath"] == nil {
+			w.Header().Set("WWW-Authenticate", `DPoP error="invalid_dpop_proof"`)
+			writeJSON(w, http.StatusUnauthorized, map[string]any{"error": "invalid_dpop_proof"})
+			return
+		}
+		w.Header().Set("DPoP-Nonce", "pds-nonce")
+		if proof["nonce"] != "pds-nonce" {
+			w.Header().Set("WWW-Authenticate", `DPoP error="use_dpop_nonce"`)
+			writeJSON(w, http.StatusUnauthorized, map[string]any{"error": "use_dpop_nonce"})
+			return
+		}
+
+		s.mu.Lock()
+		accessToken := s.accessToken
+		s.mu.Unlock()
+		if r.Header.Get("Authorization") != "DPoP "+accessToken {
+			w.Header().Set("WWW-Authenticate", `DPoP error="invalid_token"`)
+			writeJSON(w, http.StatusUnauthorized, map[string]any{"error": "InvalidToken"})
+			return
+		}
+		next(w, r)
+	}
+}
+
+// dpopClaims decodes the claims of the DPoP proof of a request, without
+// verifying its signature.
+func dpopClaims(r *http.Request) (map[string]any, bool) {
+	parts := strings.Split(r.Header.Get("DPoP"), ".")
+	if len(parts) != 3 {
+		return nil, false
+	}
+	var header map[string]any
+	if data, err := base64.RawURLEncoding.DecodeString(parts[0]); err != nil || json.Unmarshal(data, &header) != nil {
+		return nil, false
+	}
+	if header["typ"] != "dpop+jwt" || header["jwk"] == nil {
+		return nil, false
+	}
+	var claims map[string]any
+	if data, err := base64.RawURLEncoding.DecodeString(parts[1]); err != nil || json.Unmarshal(data, &claims) != nil {
+		return nil, false
+	}
+	return claims, claims["htm"] == r.Method
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func TestAccProviderOAuth(t *testing.T) {
+	standIn := newOAuthStandIn(t, "initial-refresh-token")
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenFile := filepath.Join(t.TempDir(), "tokens.json")
+	data, err := json.Marshal(map[string]string{
+		"refresh_token": "initial-refresh-token",
+		"dpop_key":      string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(tokenFile, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderOAuthConfig(standIn.server.URL, tokenFile),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.bsky_list.test", "name", "OAuth List"),
+					resource.TestCheckResourceAttr("data.bsky_list.test", "description", "Read with a DPoP-bound token"),
+					// The rotated refresh token must have been written back.
+					func(*terraform.State) error {
+						data, err := os.ReadFile(tokenFile)
+						if err != nil {
+							return err
+						}
+						standIn.mu.Lock()
+						defer standIn.mu.Unlock()
+						if !strings.Contains(string(data), `"`+standIn.refreshToken+`"`) {
+							return fmt.Errorf("token file does not contain the latest refresh token %s", standIn.refreshToken)
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+// TestAccProviderOAuthSeededTokenFile configures both refresh_token and
+// token_file, so that every configuration of the provider after the first
+// must use the rotated refresh token of the file instead of the spent one.
+func TestAccProviderOAuthSeededTokenFile(t *testing.T) {
+	standIn := newOAuthStandIn(t, "initial-refresh-token")
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenFile := filepath.Join(t.TempDir(), "tokens.json")
+	config := fmt.Sprintf(`
+		provider "bsky" {
+			pds_host = %[1]q
+			oauth = {
+				client_id     = "https://client.example.com/client-metadata.json"
+				token_file    = %[2]q
+				refresh_token = "initial-refresh-token"
+				dpop_key      = %[3]q
+			}
+		}
+
+		data "bsky_list" "test" {
+			uri = "at://%[4]s/app.bsky.graph.list/3lbo5zov45j2q"
+		}
+	`, standIn.server.URL, tokenFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), oauthTestDid)
+
+	expectRefreshes := func(atLeast int) resource.TestCheckFunc {
+		return func(*terraform.State) error {
+			standIn.mu.Lock()
+			defer standIn.mu.Unlock()
+			if standIn.issued < atLeast {
+				return fmt.Errorf("expected at least %d refreshes, got %d", atLeast, standIn.issued)
+			}
+			return nil
+		}
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.bsky_list.test", "name", "OAuth List"),
+					expectRefreshes(1),
+				),
+			},
+			// The spent refresh token of the configuration is rejected by the
+			// stand-in, so the second run only succeeds with the token file.
+			{
+				Config: config,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.bsky_list.test", "name", "OAuth List"),
+					expectRefreshes(2),
+				),
+			},
+		},
+	})
+}
+
+func testAccProviderOAuthConfig(host string, tokenFile string) string {
+	return fmt.Sprintf(`
+		provider "bsky" {
+			pds_host = %[1]q
+			oauth = {
+				client_id  = "https://client.example.com/client-metadata.json"
+				token_file = %[2]q
+			}
+		}
+
+		data "bsky_list" "test" {
+			uri = "at://%[3]s/app.bsky.graph.list/3lbo5zov45j2q"
+		}
+	`, host, tokenFile, oauthTestDid)
+}
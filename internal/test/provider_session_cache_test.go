@@ -0,0 +1,142 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+// sessionCacheStandIn is a PDS counting the sessions created, which rotates the
+// refresh token on every refreshSession.
+type sessionCacheStandIn struct {
+	mu           sync.Mutex
+	created      int
+	refreshed    int
+	refreshToken string
+}
+
+func newSessionCacheStandIn(t *testing.T) (*sessionCacheStandIn, *httptest.Server) {
+	s := &sessionCacheStandIn{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /xrpc/com.atproto.server.createSession", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.created++
+		s.refreshToken = fmt.Sprintf("refresh-%d-%d", s.created, s.refreshed)
+		writeJSON(w, http.StatusOK, s.session())
+	})
+	mux.HandleFunc("POST /xrpc/com.atproto.server.refreshSession", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if r.Header.Get("Authorization") != "Bearer "+s.refreshToken {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "ExpiredToken"})
+			return
+		}
+		s.refreshed++
+		s.refreshToken = fmt.Sprintf("refresh-%d-%d", s.created, s.refreshed)
+		writeJSON(w, http.StatusOK, s.session())
+	})
+	mux.HandleFunc("GET /xrpc/com.atproto.repo.getRecord", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"uri": "at://" + r.URL.Query().Get("repo") + "/" + r.URL.Query().Get("collection") + "/" + r.URL.Query().Get("rkey"),
+			"cid": "bafyreie5737gdxlw5i64vzichcalba3z2v5n6icifvx5xytvske7mr3hpm",
+			"value": map[string]any{
+				"$type":     "app.bsky.graph.list",
+				"name":      "Cached Session List",
+				"purpose":   "app.bsky.graph.defs#curatelist",
+				"createdAt": "2024-01-01T00:00:00Z",
+			},
+		})
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusNotImplemented, map[string]any{"error": "MethodNotImplemented", "message": r.URL.Path})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return s, server
+}
+
+// session returns the output of createSession and refreshSession. The caller
+// must hold the lock.
+func (s *sessionCacheStandIn) session() map[string]any {
+	return map[string]any{
+		"accessJwt":  "access",
+		"refreshJwt": s.refreshToken,
+		"did":        "did:plc:sessioncachetest000000000",
+		"handle":     "cache.test",
+	}
+}
+
+func TestAccProviderSessionCache(t *testing.T) {
+	standIn, pds := newSessionCacheStandIn(t)
+	cacheFile := filepath.Join(t.TempDir(), "session-cache.json")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderSessionCacheConfig(pds.URL, cacheFile),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.bsky_list.test", "name", "Cached Session List"),
+				),
+			},
+			{
+				Config: testAccProviderSessionCacheConfig(pds.URL, cacheFile),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					// Every provider instance after the first resumed the
+					// cached session.
+					func(*terraform.State) error {
+						standIn.mu.Lock()
+						defer standIn.mu.Unlock()
+						if standIn.created != 1 {
+							return fmt.Errorf("expected 1 session to be created, got %d", standIn.created)
+						}
+						if standIn.refreshed == 0 {
+							return fmt.Errorf("expected the cached session to be refreshed")
+						}
+						return nil
+					},
+					// The refresh token is not stored in plain text.
+					func(*terraform.State) error {
+						data, err := os.ReadFile(cacheFile)
+						if err != nil {
+							return err
+						}
+						standIn.mu.Lock()
+						defer standIn.mu.Unlock()
+						if strings.Contains(string(data), standIn.refreshToken) {
+							return fmt.Errorf("session cache contains the refresh token in plain text")
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func testAccProviderSessionCacheConfig(pdsHost string, cacheFile string) string {
+	return fmt.Sprintf(`
+		provider "bsky" {
+			pds_host           = %[1]q
+			handle             = "cache.test"
+			password           = "password"
+			session_cache_file = %[2]q
+			session_cache_key  = "correct horse battery staple"
+		}
+
+		data "bsky_list" "test" {
+			uri = "at://did:plc:sessioncachetest000000000/app.bsky.graph.list/3lbo5zov45j2q"
+		}
+	`, pdsHost, cacheFile)
+}
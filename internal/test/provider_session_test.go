@@ -0,0 +1,270 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+// sessionStandIn is a PDS whose access tokens expire on demand. It keeps the
+// records of its only account in memory.
+type sessionStandIn struct {
+	mu      sync.Mutex
+	records map[string]json.RawMessage
+	nextKey int
+
+	// created and refreshed count the createSession and refreshSession calls,
+	// and issued holds the access tokens they handed out.
+	created   int
+	refreshed int
+	issued    map[string]bool
+	tokens    int
+
+	// expireOn names the method whose next request expires the access token
+	// it was sent with. Requests with an expired token are answered once
+	// concurrent of them arrived, so that they are all in flight together.
+	expireOn   string
+	expired    map[string]bool
+	concurrent int
+	rejected   int
+	release    chan struct{}
+	// rejectRefresh makes refreshSession fail, and fallbacks counts the
+	// sessions created right after it did.
+	rejectRefresh   bool
+	refreshRejected bool
+	fallbacks       int
+}
+
+const sessionStandInDid = "did:plc:sessiontest0000000000000"
+
+func newSessionStandIn(t *testing.T) (*sessionStandIn, *httptest.Server) {
+	s := &sessionStandIn{
+		records: map[string]json.RawMessage{},
+		issued:  map[string]bool{},
+		expired: map[string]bool{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /xrpc/com.atproto.server.createSession", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.created++
+		if s.refreshRejected {
+			s.refreshRejected = false
+			s.fallbacks++
+		}
+		writeJSON(w, http.StatusOK, s.session())
+	})
+	mux.HandleFunc("POST /xrpc/com.atproto.server.refreshSession", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.rejectRefresh {
+			s.refreshRejected = true
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "ExpiredToken", "message": "Token has been revoked"})
+			return
+		}
+		s.refreshed++
+		writeJSON(w, http.StatusOK, s.session())
+	})
+	mux.HandleFunc("POST /xrpc/com.atproto.repo.createRecord", func(w http.ResponseWriter, r *http.Request) {
+		if !s.authenticate(w, r, "com.atproto.repo.createRecord") {
+			return
+		}
+		var input struct {
+			Collection string          `json:"collection"`
+			Record     json.RawMessage `json:"record"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&input)
+
+		s.mu.Lock()
+		s.nextKey++
+		uri := fmt.Sprintf("at://%s/%s/3lbo5zov45j%03d", sessionStandInDid, input.Collection, s.nextKey)
+		s.records[uri] = input.Record
+		s.mu.Unlock()
+
+		writeJSON(w, http.StatusOK, map[string]any{"uri": uri, "cid": accountsStandInCid(input.Record)})
+	})
+	mux.HandleFunc("GET /xrpc/com.atproto.repo.getRecord", func(w http.ResponseWriter, r *http.Request) {
+		if !s.authenticate(w, r, "com.atproto.repo.getRecord") {
+			return
+		}
+		uri := "at://" + r.URL.Query().Get("repo") + "/" + r.URL.Query().Get("collection") + "/" + r.URL.Query().Get("rkey")
+
+		s.mu.Lock()
+		record, ok := s.records[uri]
+		s.mu.Unlock()
+		if !ok {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "RecordNotFound", "message": "Could not locate record: " + uri})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"uri": uri, "cid": accountsStandInCid(record), "value": record})
+	})
+	mux.HandleFunc("POST /xrpc/com.atproto.repo.deleteRecord", func(w http.ResponseWriter, r *http.Request) {
+		if !s.authenticate(w, r, "com.atproto.repo.deleteRecord") {
+			return
+		}
+		var input struct {
+			Collection string `json:"collection"`
+			Rkey       string `json:"rkey"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&input)
+
+		s.mu.Lock()
+		delete(s.records, "at://"+sessionStandInDid+"/"+input.Collection+"/"+input.Rkey)
+		s.mu.Unlock()
+		writeJSON(w, http.StatusOK, map[string]any{})
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusNotImplemented, map[string]any{"error": "MethodNotImplemented", "message": r.URL.Path})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return s, server
+}
+
+// session returns the output of createSession and refreshSession, with a new
+// access token. The caller must hold the lock.
+func (s *sessionStandIn) session() map[string]any {
+	s.tokens++
+	accessJwt := fmt.Sprintf("access-%d", s.tokens)
+	s.issued[accessJwt] = true
+	return map[string]any{
+		"accessJwt":  accessJwt,
+		"refreshJwt": fmt.Sprintf("refresh-%d", s.tokens),
+		"did":        sessionStandInDid,
+		"handle":     "session.test",
+	}
+}
+
+// expire returns a PreConfig function which expires the access token of the
+// next request of method. concurrent is the number of requests expected to be
+// sent with the token before any of them is answered.
+func (s *sessionStandIn) expire(method string, concurrent int, rejectRefresh bool) func() {
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.expireOn = method
+		s.concurrent = concurrent
+		s.rejected = 0
+		s.release = make(chan struct{})
+		s.rejectRefresh = rejectRefresh
+		s.refreshed = 0
+		s.fallbacks = 0
+	}
+}
+
+// authenticate checks the access token of a request, and answers it with
+// ExpiredToken if the token expired.
+func (s *sessionStandIn) authenticate(w http.ResponseWriter, r *http.Request, method string) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	s.mu.Lock()
+	if s.expireOn == method {
+		s.expireOn = ""
+		s.expired[token] = true
+	}
+	if s.issued[token] && !s.expired[token] {
+		s.mu.Unlock()
+		return true
+	}
+	s.rejected++
+	if s.rejected == s.concurrent {
+		close(s.release)
+	}
+	release := s.release
+	s.mu.Unlock()
+
+	// Hold the response until the other requests with the token arrived, but
+	// don't hang if fewer of them were sent concurrently.
+	if release != nil {
+		select {
+		case <-release:
+		case <-time.After(2 * time.Second):
+		}
+	}
+	writeJSON(w, http.StatusBadRequest, map[string]any{"error": "ExpiredToken", "message": "Token has expired"})
+	return false
+}
+
+// expectRefreshes checks the refreshSession calls and the sessions created
+// because the refresh token was rejected since the last expire.
+func (s *sessionStandIn) expectRefreshes(refreshed int, fallbacks int) resource.TestCheckFunc {
+	return func(*terraform.State) error {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.refreshed != refreshed {
+			return fmt.Errorf("expected %d session refreshes, got %d", refreshed, s.refreshed)
+		}
+		if s.fallbacks != fallbacks {
+			return fmt.Errorf("expected %d sessions created after a rejected refresh, got %d", fallbacks, s.fallbacks)
+		}
+		return nil
+	}
+}
+
+// TestAccProviderSessionExpiredToken checks that requests rejected because
+// the access token expired are retried after one refresh of the session.
+func TestAccProviderSessionExpiredToken(t *testing.T) {
+	standIn, pds := newSessionStandIn(t)
+	lists := 5
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderSessionConfig(pds.URL, lists),
+			},
+			// The lists are refreshed concurrently with the expired token,
+			// and the session is refreshed once for all of them.
+			{
+				PreConfig: standIn.expire("com.atproto.repo.getRecord", lists, false),
+				Config:    testAccProviderSessionConfig(pds.URL, lists),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					standIn.expectRefreshes(1, 0),
+					resource.TestCheckResourceAttr("bsky_list.test.0", "name", "Session List 0"),
+				),
+			},
+			// Writes are retried too.
+			{
+				PreConfig: standIn.expire("com.atproto.repo.createRecord", 1, false),
+				Config:    testAccProviderSessionConfig(pds.URL, lists+1),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					standIn.expectRefreshes(1, 0),
+					resource.TestCheckResourceAttrSet(fmt.Sprintf("bsky_list.test.%d", lists), "uri"),
+				),
+			},
+			// A rejected refresh token falls back to a new session.
+			{
+				PreConfig: standIn.expire("com.atproto.repo.getRecord", lists+1, true),
+				Config:    testAccProviderSessionConfig(pds.URL, lists+1),
+				Check:     standIn.expectRefreshes(0, 1),
+			},
+		},
+	})
+}
+
+func testAccProviderSessionConfig(pdsHost string, lists int) string {
+	return fmt.Sprintf(`
+		provider "bsky" {
+			pds_host = %q
+			handle   = "session.test"
+			password = "password"
+		}
+
+		resource "bsky_list" "test" {
+			count       = %d
+			name        = "Session List ${count.index}"
+			purpose     = "app.bsky.graph.defs#curatelist"
+			description = "Refreshed with an expired token"
+		}
+	`, pdsHost, lists)
+}
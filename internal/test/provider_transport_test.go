@@ -0,0 +1,164 @@
+package test
+
+import (
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+// newTLSStandIn starts a PDS serving HTTPS with a self-signed certificate,
+// which records the user agents of the requests it receives.
+func newTLSStandIn(t *testing.T) (server *httptest.Server, userAgents func() []string) {
+	var mu sync.Mutex
+	var seen []string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /xrpc/com.atproto.server.createSession", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"accessJwt":  "access",
+			"refreshJwt": "refresh",
+			"did":        "did:plc:transporttest000000000000",
+			"handle":     "transport.test",
+		})
+	})
+	mux.HandleFunc("GET /xrpc/com.atproto.repo.getRecord", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"uri": "at://" + r.URL.Query().Get("repo") + "/" + r.URL.Query().Get("collection") + "/" + r.URL.Query().Get("rkey"),
+			"cid": "bafyreie5737gdxlw5i64vzichcalba3z2v5n6icifvx5xytvske7mr3hpm",
+			"value": map[string]any{
+				"$type":     "app.bsky.graph.list",
+				"name":      "Internal CA List",
+				"purpose":   "app.bsky.graph.defs#curatelist",
+				"createdAt": "2024-01-01T00:00:00Z",
+			},
+		})
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusNotImplemented, map[string]any{"error": "MethodNotImplemented", "message": r.URL.Path})
+	})
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, r.Header.Get("User-Agent"))
+		mu.Unlock()
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), seen...)
+	}
+}
+
+func TestAccProviderTransport(t *testing.T) {
+	pds, userAgents := newTLSStandIn(t)
+
+	caBundle := filepath.Join(t.TempDir(), "ca.pem")
+	err := os.WriteFile(caBundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: pds.Certificate().Raw}), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderTransportConfig(pds.URL, caBundle),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.bsky_list.test", "name", "Internal CA List"),
+					func(*terraform.State) error {
+						for _, userAgent := range userAgents() {
+							if !strings.Contains(userAgent, "terraform-provider-bsky/test") || !strings.HasSuffix(userAgent, " acceptance-tests") {
+								return fmt.Errorf("unexpected user agent %q", userAgent)
+							}
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func testAccProviderTransportConfig(pdsHost string, caBundle string) string {
+	return fmt.Sprintf(`
+		provider "bsky" {
+			pds_host        = %[1]q
+			handle          = "transport.test"
+			password        = "password"
+			ca_bundle_file  = %[2]q
+			request_timeout = "10s"
+			user_agent      = "acceptance-tests"
+		}
+
+		data "bsky_list" "test" {
+			uri = "at://did:plc:transporttest000000000000/app.bsky.graph.list/3lbo5zov45j2q"
+		}
+	`, pdsHost, caBundle)
+}
+
+// TestAccProviderTransportWriteRetries commits every follow but answers with a
+// 502 as if the response was lost, which must not be retried into duplicate
+// follows.
+func TestAccProviderTransportWriteRetries(t *testing.T) {
+	var mu sync.Mutex
+	creates := 0
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /xrpc/com.atproto.server.createSession", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"accessJwt":  "access",
+			"refreshJwt": "refresh",
+			"did":        "did:plc:transporttest000000000000",
+			"handle":     "transport.test",
+		})
+	})
+	mux.HandleFunc("POST /xrpc/com.atproto.repo.createRecord", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		creates++
+		mu.Unlock()
+		writeJSON(w, http.StatusBadGateway, map[string]any{"error": "UpstreamFailure", "message": "response lost"})
+	})
+	pds := httptest.NewServer(mux)
+	t.Cleanup(pds.Close)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+					provider "bsky" {
+						pds_host    = %q
+						handle      = "transport.test"
+						password    = "password"
+						max_retries = 3
+					}
+
+					resource "bsky_follow" "test" {
+						subject_did = "did:plc:partner"
+					}
+				`, pds.URL),
+				ExpectError: regexp.MustCompile("Error creating follow"),
+			},
+		},
+		CheckDestroy: func(*terraform.State) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if creates != 1 {
+				return fmt.Errorf("expected the follow to be sent once, got %d createRecord calls", creates)
+			}
+			return nil
+		},
+	})
+}
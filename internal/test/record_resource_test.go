@@ -0,0 +1,155 @@
+package test
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+func TestAccRecordResource(t *testing.T) {
+	standIn, pds := newAccountsStandIn(t)
+	uri := "at://" + accountsStandInDid("default.test") + "/com.example.config/main"
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Create and Read testing
+			{
+				Config: testAccRecordResourceConfig(pds.URL, `
+					rkey   = "main"
+					record = jsonencode({
+						name    = "Production"
+						enabled = true
+						limits  = { posts = 30 }
+					})
+				`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("bsky_record.test", "uri", uri),
+					resource.TestCheckResourceAttr("bsky_record.test", "rkey", "main"),
+					resource.TestCheckResourceAttrSet("bsky_record.test", "cid"),
+					expectStandInRecord(standIn, "bsky_record.test", `{"$type":"com.example.config","enabled":true,"limits":{"posts":30},"name":"Production"}`),
+				),
+			},
+			// ImportState testing
+			{
+				ResourceName:                         "bsky_record.test",
+				ImportState:                          true,
+				ImportStateId:                        uri,
+				ImportStateVerify:                    true,
+				ImportStateVerifyIdentifierAttribute: "uri",
+			},
+			// A record written with another formatting and key order isn't a
+			// change.
+			{
+				PreConfig: func() {
+					standIn.mu.Lock()
+					standIn.records[uri] = []byte(`{ "name": "Production", "limits": { "posts": 30 }, "enabled": true, "$type": "com.example.config" }`)
+					standIn.mu.Unlock()
+				},
+				RefreshState: true,
+			},
+			// Neither are numbers written in another notation.
+			{
+				PreConfig: func() {
+					standIn.mu.Lock()
+					standIn.records[uri] = []byte(`{"$type":"com.example.config","name":"Production","enabled":true,"limits":{"posts":3.0e1}}`)
+					standIn.mu.Unlock()
+				},
+				RefreshState: true,
+				Check:        resource.TestCheckResourceAttr("bsky_record.test", "record", `{"enabled":true,"limits":{"posts":30},"name":"Production"}`),
+			},
+			// A record changed outside of Terraform is.
+			{
+				PreConfig: func() {
+					standIn.mu.Lock()
+					standIn.records[uri] = []byte(`{"$type":"com.example.config","name":"Production","enabled":false,"limits":{"posts":30}}`)
+					standIn.mu.Unlock()
+				},
+				RefreshState:       true,
+				ExpectNonEmptyPlan: true,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("bsky_record.test", "record", `{"enabled":false,"limits":{"posts":30},"name":"Production"}`),
+				),
+			},
+			// Update and Read testing
+			{
+				Config: testAccRecordResourceConfig(pds.URL, `
+					rkey   = "main"
+					record = jsonencode({
+						"$type" = "com.example.config"
+						name    = "Staging"
+					})
+				`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("bsky_record.test", "uri", uri),
+					expectStandInRecord(standIn, "bsky_record.test", `{"$type":"com.example.config","name":"Staging"}`),
+				),
+			},
+			// The PDS generates the record key when there is none.
+			{
+				Config: testAccRecordResourceConfig(pds.URL, `
+					rkey   = "main"
+					record = jsonencode({ name = "Staging" })
+				`) + `
+					resource "bsky_record" "generated" {
+						collection = "com.example.config"
+						record     = jsonencode({ name = "Generated" })
+					}
+				`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet("bsky_record.generated", "rkey"),
+					resource.TestCheckResourceAttrWith("bsky_record.generated", "uri", func(value string) error {
+						return expectRepo(value, accountsStandInDid("default.test"))
+					}),
+					expectStandInRecord(standIn, "bsky_record.generated", `{"$type":"com.example.config","name":"Generated"}`),
+				),
+			},
+			{
+				Config: testAccRecordResourceConfig(pds.URL, `
+					record = jsonencode({ "$type" = "com.example.other" })
+				`),
+				ExpectError: regexp.MustCompile("doesn't match the collection"),
+			},
+			{
+				Config: testAccRecordResourceConfig(pds.URL, `
+					record = jsonencode(["not", "an", "object"])
+				`),
+				ExpectError: regexp.MustCompile("Invalid JSON object"),
+			},
+		},
+	})
+}
+
+// expectStandInRecord checks the JSON of the record stored by the stand-in at
+// the uri of the resource.
+func expectStandInRecord(standIn *accountsStandIn, resourceName string, expected string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		uri := s.RootModule().Resources[resourceName].Primary.Attributes["uri"]
+
+		standIn.mu.Lock()
+		record := string(standIn.records[uri])
+		standIn.mu.Unlock()
+		if record != expected {
+			return fmt.Errorf("expected record %s, got %s", expected, record)
+		}
+		return nil
+	}
+}
+
+func testAccRecordResourceConfig(pdsHost string, attributes string) string {
+	return fmt.Sprintf(`
+		provider "bsky" {
+			pds_host = %q
+			handle   = "default.test"
+			password = "password"
+		}
+
+		resource "bsky_record" "test" {
+			collection = "com.example.config"
+			%s
+		}
+	`, pdsHost, attributes)
+}
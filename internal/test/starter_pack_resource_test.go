@@ -21,6 +21,7 @@ func TestAccStarterPackResource(t *testing.T) {
 					resource.TestCheckResourceAttr("bsky_starter_pack.test", "name", "Test Starter Pack"),
 					resource.TestCheckResourceAttr("bsky_starter_pack.test", "description", "Test description"),
 					resource.TestCheckResourceAttrSet("bsky_starter_pack.test", "uri"),
+					resource.TestCheckResourceAttrSet("bsky_starter_pack.test", "cid"),
 					resource.TestCheckResourceAttrSet("bsky_starter_pack.test", "list_uri"),
 					resource.TestCheckResourceAttrPair(
 						"bsky_list.test1", "uri",
@@ -0,0 +1,114 @@
+package test
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+// TestAccSubjectRecordResources checks the resources of records whose subject
+// is the DID of an account.
+func TestAccSubjectRecordResources(t *testing.T) {
+	for _, tc := range []struct {
+		typeName string
+		noun     string
+		subject  string
+	}{
+		{typeName: "bsky_follow", noun: "Follow", subject: "partner.test"},
+		{typeName: "bsky_block", noun: "Block", subject: "spammer.test"},
+	} {
+		t.Run(tc.typeName, func(t *testing.T) {
+			standIn, pds := newAccountsStandIn(t)
+			name := tc.typeName + ".test"
+			var firstURI string
+
+			resource.Test(t, resource.TestCase{
+				ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+				Steps: []resource.TestStep{
+					// Create and Read testing
+					{
+						Config: testAccSubjectRecordResourceConfig(pds.URL, tc.typeName, accountsStandInDid(tc.subject)),
+						Check: resource.ComposeAggregateTestCheckFunc(
+							resource.TestCheckResourceAttr(name, "subject_did", accountsStandInDid(tc.subject)),
+							resource.TestCheckResourceAttrSet(name, "cid"),
+							resource.TestCheckResourceAttrWith(name, "uri", func(uri string) error {
+								firstURI = uri
+								return expectRepo(uri, accountsStandInDid("org.test"))
+							}),
+						),
+					},
+					// ImportState testing
+					{
+						ResourceName: name,
+						ImportState:  true,
+						ImportStateIdFunc: func(s *terraform.State) (string, error) {
+							return s.RootModule().Resources[name].Primary.Attributes["uri"], nil
+						},
+						ImportStateVerify:                    true,
+						ImportStateVerifyIdentifierAttribute: "uri",
+					},
+					// A record deleted outside of Terraform is created again.
+					{
+						PreConfig: func() {
+							standIn.mu.Lock()
+							delete(standIn.records, firstURI)
+							standIn.mu.Unlock()
+						},
+						Config: testAccSubjectRecordResourceConfig(pds.URL, tc.typeName, accountsStandInDid(tc.subject)),
+						Check: resource.TestCheckResourceAttrWith(name, "uri", func(uri string) error {
+							if uri == firstURI {
+								return fmt.Errorf("expected the record to be created again")
+							}
+							return nil
+						}),
+					},
+					// Changing the subject replaces the record.
+					{
+						Config: testAccSubjectRecordResourceConfig(pds.URL, tc.typeName, accountsStandInDid("other.test")),
+						Check: resource.ComposeAggregateTestCheckFunc(
+							resource.TestCheckResourceAttr(name, "subject_did", accountsStandInDid("other.test")),
+							func(s *terraform.State) error {
+								record, err := standInRecord(standIn, s, name)
+								if err != nil {
+									return err
+								}
+								if record["subject"] != accountsStandInDid("other.test") {
+									return fmt.Errorf("unexpected subject %v", record["subject"])
+								}
+								return nil
+							},
+						),
+					},
+					// A record changed after it was read isn't deleted.
+					{
+						PreConfig:   standIn.race("com.atproto.repo.getRecord", true),
+						Config:      testAccSubjectRecordResourceConfig(pds.URL, tc.typeName, accountsStandInDid(tc.subject)),
+						ExpectError: regexp.MustCompile(tc.noun + " changed outside of Terraform"),
+					},
+					{
+						PreConfig:   standIn.race("com.atproto.repo.getRecord", false),
+						Config:      testAccSubjectRecordResourceConfig(pds.URL, tc.typeName, tc.subject),
+						ExpectError: regexp.MustCompile("Invalid subject DID"),
+					},
+				},
+			})
+		})
+	}
+}
+
+func testAccSubjectRecordResourceConfig(pdsHost string, typeName string, subjectDid string) string {
+	return fmt.Sprintf(`
+		provider "bsky" {
+			pds_host = %q
+			handle   = "org.test"
+			password = "password"
+		}
+
+		resource %q "test" {
+			subject_did = %q
+		}
+	`, pdsHost, typeName, subjectDid)
+}
@@ -0,0 +1,179 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+func TestAccThreadgateResource(t *testing.T) {
+	standIn, pds := newAccountsStandIn(t)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Create and Read testing
+			{
+				Config: testAccThreadgateResourceConfig(pds.URL, "bsky_post.test", `
+					allow = {
+						mentioned = true
+						lists     = [bsky_list.test.uri]
+					}
+					hidden_replies = ["at://did:plc:spammer/app.bsky.feed.post/3lbo5zov45j2q"]
+				`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("bsky_threadgate.test", "allow.mentioned", "true"),
+					resource.TestCheckResourceAttr("bsky_threadgate.test", "allow.following", "false"),
+					resource.TestCheckResourceAttrSet("bsky_threadgate.test", "cid"),
+					expectGateOfPost("bsky_threadgate.test", "app.bsky.feed.threadgate"),
+					func(s *terraform.State) error {
+						threadgate, err := standInRecord(standIn, s, "bsky_threadgate.test")
+						if err != nil {
+							return err
+						}
+						expected := []any{
+							map[string]any{"$type": "app.bsky.feed.threadgate#mentionRule"},
+							map[string]any{"$type": "app.bsky.feed.threadgate#listRule", "list": s.RootModule().Resources["bsky_list.test"].Primary.Attributes["uri"]},
+						}
+						if !reflect.DeepEqual(threadgate["allow"], expected) {
+							return fmt.Errorf("expected allow rules %v, got %v", expected, threadgate["allow"])
+						}
+						if threadgate["post"] != s.RootModule().Resources["bsky_post.test"].Primary.Attributes["uri"] {
+							return fmt.Errorf("unexpected post %v", threadgate["post"])
+						}
+						return nil
+					},
+				),
+			},
+			// ImportState testing
+			{
+				ResourceName: "bsky_threadgate.test",
+				ImportState:  true,
+				ImportStateIdFunc: func(s *terraform.State) (string, error) {
+					return s.RootModule().Resources["bsky_post.test"].Primary.Attributes["uri"], nil
+				},
+				ImportStateVerify:                    true,
+				ImportStateVerifyIdentifierAttribute: "post_uri",
+			},
+			// An allow block without rules is kept as an empty list, so that
+			// nobody can reply.
+			{
+				Config: testAccThreadgateResourceConfig(pds.URL, "bsky_post.test", `
+					allow = {}
+				`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("bsky_threadgate.test", "allow.mentioned", "false"),
+					resource.TestCheckNoResourceAttr("bsky_threadgate.test", "hidden_replies"),
+					func(s *terraform.State) error {
+						threadgate, err := standInRecord(standIn, s, "bsky_threadgate.test")
+						if err != nil {
+							return err
+						}
+						if allow, ok := threadgate["allow"].([]any); !ok || len(allow) != 0 {
+							return fmt.Errorf("expected an empty allow list, got %v", threadgate["allow"])
+						}
+						return nil
+					},
+				),
+			},
+			{
+				Config: testAccThreadgateResourceConfig(pds.URL, "bsky_post.test", `
+					allow = {
+						lists = ["at://did:plc:defaulttest/app.bsky.graph.starterpack/3lbo5zov45j2q"]
+					}
+				`),
+				ExpectError: regexp.MustCompile("Invalid list URI"),
+			},
+			// Only the author of a post can gate it, which is checked when
+			// planning.
+			{
+				Config:      testAccThreadgateResourceConfig(pds.URL, "bsky_post.other", ""),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("Invalid post URI"),
+			},
+		},
+	})
+}
+
+// standInRecord returns the record stored by the stand-in at the uri of the
+// resource.
+func standInRecord(standIn *accountsStandIn, s *terraform.State, resourceName string) (map[string]any, error) {
+	uri := s.RootModule().Resources[resourceName].Primary.Attributes["uri"]
+
+	standIn.mu.Lock()
+	record, ok := standIn.records[uri]
+	standIn.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("record %s not found", uri)
+	}
+
+	var value map[string]any
+	if err := json.Unmarshal(record, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// expectGateOfPost checks that the gate resource is a record of collection
+// with the record key of bsky_post.test.
+func expectGateOfPost(resourceName string, collection string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		postUri := s.RootModule().Resources["bsky_post.test"].Primary.Attributes["uri"]
+		uri := s.RootModule().Resources[resourceName].Primary.Attributes["uri"]
+		expected := "at://" + accountsStandInDid("default.test") + "/" + collection + "/" + path.Base(postUri)
+		if uri != expected {
+			return fmt.Errorf("expected %s at %s, got %s", resourceName, expected, uri)
+		}
+		return nil
+	}
+}
+
+// testAccGatedPostConfig configures a post to gate, and a post of another
+// account which can't be gated.
+func testAccGatedPostConfig(pdsHost string) string {
+	return fmt.Sprintf(`
+		provider "bsky" {
+			pds_host = %[1]q
+			handle   = "default.test"
+			password = "password"
+
+			accounts = {
+				other = {
+					pds_host = %[1]q
+					handle   = "other.test"
+					password = "password"
+				}
+			}
+		}
+
+		resource "bsky_post" "test" {
+			text = "Announcement"
+		}
+
+		resource "bsky_post" "other" {
+			account = "other"
+			text    = "Someone else's post"
+		}
+	`, pdsHost)
+}
+
+func testAccThreadgateResourceConfig(pdsHost string, post string, attributes string) string {
+	return testAccGatedPostConfig(pdsHost) + fmt.Sprintf(`
+		resource "bsky_list" "test" {
+			name        = "Moderators"
+			purpose     = "app.bsky.graph.defs#curatelist"
+			description = "Accounts which can reply"
+		}
+
+		resource "bsky_threadgate" "test" {
+			post_uri = %s.uri
+			%s
+		}
+	`, post, attributes)
+}